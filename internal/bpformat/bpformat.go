@@ -0,0 +1,76 @@
+// Package bpformat reads and writes breakpoints in the files other Go
+// debugging front ends keep them in: dlv --init scripts and VS Code's
+// .vscode/launch.json.
+package bpformat
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/kjbreil/dlc-sidecar/internal/debugger"
+)
+
+// Format names a breakpoint file format.
+type Format string
+
+const (
+	// FormatDlv is a script of Delve terminal commands, as run by dlv --init.
+	FormatDlv Format = "dlv"
+	// FormatVSCode is a VS Code launch.json with a breakpoints array.
+	FormatVSCode Format = "vscode"
+)
+
+// ParseFormat parses dlv or vscode.
+func ParseFormat(s string) (Format, error) {
+	switch f := Format(s); f {
+	case FormatDlv, FormatVSCode:
+		return f, nil
+	}
+	return "", fmt.Errorf("invalid format %q: want dlv or vscode", s)
+}
+
+// DetectFormat guesses the format of path from its extension: .json files
+// are VS Code launch configurations and anything else is a dlv script.
+func DetectFormat(path string) Format {
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		return FormatVSCode
+	}
+	return FormatDlv
+}
+
+// DefaultWorkspace returns the folder ${workspaceFolder} stands for in the
+// VS Code file at path: the parent of its .vscode directory, or the working
+// directory when the file is elsewhere.
+func DefaultWorkspace(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		if dir := filepath.Dir(abs); filepath.Base(dir) == ".vscode" {
+			return filepath.Dir(dir)
+		}
+	}
+	wd, _ := os.Getwd()
+	return wd
+}
+
+// Entry is a breakpoint read from a file.
+type Entry struct {
+	// Source says where in the file the breakpoint was defined, such as
+	// "line 3".
+	Source     string
+	Breakpoint debugger.SavedBreakpoint
+}
+
+// Skipped is a part of a file that does not describe a breakpoint the
+// sidecar can set.
+type Skipped struct {
+	Source string
+	Text   string
+	Reason string
+}
+
+// Import holds what was read from a breakpoint file.
+type Import struct {
+	Entries []Entry
+	Skipped []Skipped
+}
@@ -0,0 +1,284 @@
+package bpformat
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/kjbreil/dlc-sidecar/internal/debugger"
+)
+
+// script tracks the breakpoints a dlv script defines, so later commands can
+// refer to them by name or by number.
+type script struct {
+	defs   []*scriptDef
+	byName map[string]*scriptDef
+	imp    Import
+}
+
+type scriptDef struct {
+	entry   Entry
+	cleared bool
+}
+
+// ParseInitScript reads the breakpoints a dlv --init script sets: break and
+// trace commands, and the cond, on, toggle and clear commands that refer to
+// them. Breakpoint numbers in the script count the breakpoints it defines,
+// as they would in a fresh Delve. Other commands are skipped.
+func ParseInitScript(r io.Reader) (*Import, error) {
+	s := &script{byName: make(map[string]*scriptDef)}
+	sc := bufio.NewScanner(r)
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if reason := s.command(fmt.Sprintf("line %d", n), line); reason != "" {
+			s.imp.Skipped = append(s.imp.Skipped, Skipped{Source: fmt.Sprintf("line %d", n), Text: line, Reason: reason})
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	for _, d := range s.defs {
+		if !d.cleared {
+			s.imp.Entries = append(s.imp.Entries, d.entry)
+		}
+	}
+	return &s.imp, nil
+}
+
+// command applies one script line and returns why it was skipped, or "".
+func (s *script) command(source, line string) string {
+	cmd, rest := split2(line)
+	switch cmd {
+	case "break", "b":
+		return s.define(source, rest, false)
+	case "trace", "t":
+		return s.define(source, rest, true)
+	case "cond", "condition":
+		return s.cond(rest)
+	case "on":
+		return s.on(rest)
+	case "toggle":
+		d, reason := s.ref(rest)
+		if d != nil {
+			d.entry.Breakpoint.Disabled = !d.entry.Breakpoint.Disabled
+		}
+		return reason
+	case "clear":
+		d, reason := s.ref(rest)
+		if d != nil {
+			d.cleared = true
+		}
+		return reason
+	case "clearall":
+		if rest != "" {
+			return "clearall with a location is not supported"
+		}
+		for _, d := range s.defs {
+			d.cleared = true
+		}
+		return ""
+	}
+	return "not a breakpoint command"
+}
+
+// define handles break and trace: [name] <locspec> [if <condition>].
+func (s *script) define(source, args string, trace bool) string {
+	var cond string
+	if i := strings.Index(" "+args, " if "); i >= 0 {
+		args, cond = strings.TrimSpace(args[:max(i-1, 0)]), strings.TrimSpace(args[i+3:])
+	}
+	var name string
+	if first, rest := split2(args); rest != "" && validName(first) {
+		name, args = first, rest
+	}
+	if args == "" {
+		return "no location; a breakpoint at the current position cannot be restored"
+	}
+	if name != "" && s.byName[name] != nil && !s.byName[name].cleared {
+		return fmt.Sprintf("breakpoint name %s already used", name)
+	}
+
+	d := &scriptDef{entry: Entry{Source: source, Breakpoint: debugger.SavedBreakpoint{
+		Location:   args,
+		Name:       name,
+		Condition:  cond,
+		Tracepoint: trace,
+	}}}
+	// Numbers keep counting cleared breakpoints, as Delve's IDs do.
+	s.defs = append(s.defs, d)
+	if name != "" {
+		s.byName[name] = d
+	}
+	return ""
+}
+
+// cond handles cond [-hitcount|-per-g-hitcount|-clear] <bp> [<argument>].
+func (s *script) cond(args string) string {
+	flag, rest := split2(args)
+	switch flag {
+	case "-hitcount", "-per-g-hitcount":
+		ref, hit := split2(rest)
+		d, reason := s.ref(ref)
+		if d == nil {
+			return reason
+		}
+		if hit == "" {
+			return "missing hit count condition"
+		}
+		d.entry.Breakpoint.HitCondition = hit
+		d.entry.Breakpoint.HitConditionPerGoroutine = flag == "-per-g-hitcount"
+		return ""
+	case "-clear":
+		d, reason := s.ref(rest)
+		if d != nil {
+			d.entry.Breakpoint.Condition = ""
+		}
+		return reason
+	}
+
+	ref, expr := split2(args)
+	d, reason := s.ref(ref)
+	if d == nil {
+		return reason
+	}
+	if expr == "" {
+		return "missing condition"
+	}
+	d.entry.Breakpoint.Condition = expr
+	return ""
+}
+
+// on handles on <bp> <command>, for the commands that configure what a
+// breakpoint captures.
+func (s *script) on(args string) string {
+	ref, command := split2(args)
+	d, reason := s.ref(ref)
+	if d == nil {
+		return reason
+	}
+	bp := &d.entry.Breakpoint
+	sub, arg := split2(command)
+	switch sub {
+	case "print", "p":
+		if arg == "" {
+			return "missing expression"
+		}
+		bp.Expressions = append(bp.Expressions, arg)
+	case "stack":
+		depth, err := strconv.Atoi(arg)
+		if err != nil || depth < 0 {
+			return fmt.Sprintf("invalid stack depth %q", arg)
+		}
+		bp.StackDepth = depth
+	case "trace":
+		bp.Tracepoint = true
+	case "cond", "condition":
+		if arg == "" {
+			return "missing condition"
+		}
+		bp.Condition = arg
+	default:
+		return fmt.Sprintf("on %s is not supported", sub)
+	}
+	return ""
+}
+
+// ref finds the breakpoint a command refers to, by name or by its number
+// among the breakpoints the script defined.
+func (s *script) ref(ref string) (*scriptDef, string) {
+	ref = strings.TrimSpace(ref)
+	if ref == "" {
+		return nil, "missing breakpoint"
+	}
+	var d *scriptDef
+	if n, err := strconv.Atoi(ref); err == nil {
+		if n >= 1 && n <= len(s.defs) {
+			d = s.defs[n-1]
+		}
+	} else {
+		d = s.byName[ref]
+	}
+	if d == nil || d.cleared {
+		return nil, fmt.Sprintf("breakpoint %s is not defined earlier in the script", ref)
+	}
+	return d, ""
+}
+
+// FormatInitScript writes bps as a script for dlv --init. Breakpoints
+// without a name are referred to by number, which matches their ID when the
+// script runs in a fresh Delve. Settings a script cannot express are
+// reported as warnings.
+func FormatInitScript(bps []debugger.SavedBreakpoint) ([]byte, []string) {
+	var buf bytes.Buffer
+	var warnings []string
+	buf.WriteString("# Breakpoints exported by dlc-sidecar; run with dlv --init <file>.\n")
+	for i, bp := range bps {
+		ref := strconv.Itoa(i + 1)
+		cmd := "break"
+		if bp.Tracepoint {
+			cmd = "trace"
+		}
+		if bp.Name != "" {
+			ref = bp.Name
+			fmt.Fprintf(&buf, "%s %s %s\n", cmd, bp.Name, bp)
+		} else {
+			fmt.Fprintf(&buf, "%s %s\n", cmd, bp)
+		}
+
+		if bp.Condition != "" {
+			fmt.Fprintf(&buf, "cond %s %s\n", ref, bp.Condition)
+		}
+		if bp.HitCondition != "" {
+			flag := "-hitcount"
+			if bp.HitConditionPerGoroutine {
+				flag = "-per-g-hitcount"
+			}
+			fmt.Fprintf(&buf, "cond %s %s %s\n", flag, ref, bp.HitCondition)
+		}
+		for _, expr := range bp.Expressions {
+			fmt.Fprintf(&buf, "on %s print %s\n", ref, expr)
+		}
+		if bp.StackDepth > 0 {
+			fmt.Fprintf(&buf, "on %s stack %d\n", ref, bp.StackDepth)
+		}
+		if bp.Disabled {
+			fmt.Fprintf(&buf, "toggle %s\n", ref)
+		}
+		if bp.Args || bp.Locals {
+			warnings = append(warnings, fmt.Sprintf("%s: capturing args and locals cannot be expressed in a dlv script", bp))
+		}
+		if bp.LogMessage != "" {
+			warnings = append(warnings, fmt.Sprintf("%s: the log message cannot be expressed in a dlv script; "+
+				"its expressions are traced instead", bp))
+		}
+	}
+	return buf.Bytes(), warnings
+}
+
+// split2 splits s at its first run of spaces.
+func split2(s string) (string, string) {
+	s = strings.TrimSpace(s)
+	i := strings.IndexFunc(s, unicode.IsSpace)
+	if i < 0 {
+		return s, ""
+	}
+	return s[:i], strings.TrimSpace(s[i:])
+}
+
+// validName reports whether s can name a breakpoint: Delve accepts Go
+// identifiers, which no location expression is mistaken for.
+func validName(s string) bool {
+	for i, r := range s {
+		if r != '_' && !unicode.IsLetter(r) && (i == 0 || !unicode.IsDigit(r)) {
+			return false
+		}
+	}
+	return s != ""
+}
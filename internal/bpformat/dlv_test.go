@@ -0,0 +1,108 @@
+package bpformat
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/kjbreil/dlc-sidecar/internal/debugger"
+)
+
+const initScript = `# breakpoints for the handler bug
+break main.go:42
+b handler api.(*Server).Handle if req == nil
+trace main.run
+cond 1 n > 10
+cond -hitcount handler > 5
+cond -per-g-hitcount 3 % 2
+on 3 print x
+on 3 print y.Name
+on 3 stack 4
+on handler goroutine
+toggle 1
+break /src/old.go:7
+clear 4
+cond 9 x
+continue
+break
+`
+
+func TestParseInitScript(t *testing.T) {
+	imp, err := ParseInitScript(strings.NewReader(initScript))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Entry{
+		{Source: "line 2", Breakpoint: debugger.SavedBreakpoint{Location: "main.go:42", Condition: "n > 10", Disabled: true}},
+		{Source: "line 3", Breakpoint: debugger.SavedBreakpoint{Location: "api.(*Server).Handle", Name: "handler", Condition: "req == nil", HitCondition: "> 5"}},
+		{Source: "line 4", Breakpoint: debugger.SavedBreakpoint{Location: "main.run", Tracepoint: true, HitCondition: "% 2", HitConditionPerGoroutine: true,
+			Expressions: []string{"x", "y.Name"}, StackDepth: 4}},
+	}
+	if !reflect.DeepEqual(imp.Entries, want) {
+		t.Errorf("entries:\n got %+v\nwant %+v", imp.Entries, want)
+	}
+
+	var skipped []string
+	for _, s := range imp.Skipped {
+		skipped = append(skipped, fmt.Sprintf("%s: %s", s.Source, s.Reason))
+	}
+	wantSkipped := []string{
+		"line 11: on goroutine is not supported",
+		"line 15: breakpoint 9 is not defined earlier in the script",
+		"line 16: not a breakpoint command",
+		"line 17: no location; a breakpoint at the current position cannot be restored",
+	}
+	if !reflect.DeepEqual(skipped, wantSkipped) {
+		t.Errorf("skipped:\n got %q\nwant %q", skipped, wantSkipped)
+	}
+}
+
+func TestFormatInitScript(t *testing.T) {
+	bps := []debugger.SavedBreakpoint{
+		{File: "/src/main.go", Line: 42, Condition: "n > 10", HitCondition: "% 3", Disabled: true},
+		{Location: "api.(*Server).Handle", Name: "handler", HitCondition: "> 5", HitConditionPerGoroutine: true},
+		{Location: "main.run", Tracepoint: true, Expressions: []string{"x"}, StackDepth: 4, Args: true},
+	}
+	script, warnings := FormatInitScript(bps)
+
+	want := `# Breakpoints exported by dlc-sidecar; run with dlv --init <file>.
+break /src/main.go:42
+cond 1 n > 10
+cond -hitcount 1 % 3
+toggle 1
+break handler api.(*Server).Handle
+cond -per-g-hitcount handler > 5
+trace main.run
+on 3 print x
+on 3 stack 4
+`
+	if string(script) != want {
+		t.Errorf("script:\n%s\nwant:\n%s", script, want)
+	}
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "main.run: capturing args and locals") {
+		t.Errorf("warnings = %q", warnings)
+	}
+
+	// Reading the script back gives the same breakpoints, located by
+	// expression.
+	imp, err := ParseInitScript(strings.NewReader(string(script)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(imp.Entries) != 3 || len(imp.Skipped) != 0 {
+		t.Fatalf("round trip = %+v", imp)
+	}
+	if got := imp.Entries[0].Breakpoint; got.Location != "/src/main.go:42" || got.HitCondition != "% 3" || !got.Disabled {
+		t.Errorf("first = %+v", got)
+	}
+
+	// A logpoint keeps its expressions but not its message.
+	script, warnings = FormatInitScript([]debugger.SavedBreakpoint{
+		{Location: "main.run", Tracepoint: true, Expressions: []string{"n"}, LogMessage: "n={n}"},
+	})
+	if !strings.Contains(string(script), "trace main.run\non 1 print n\n") || len(warnings) != 1 || !strings.Contains(warnings[0], "log message") {
+		t.Errorf("logpoint script:\n%s\nwarnings = %q", script, warnings)
+	}
+}
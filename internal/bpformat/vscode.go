@@ -0,0 +1,253 @@
+package bpformat
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/kjbreil/dlc-sidecar/internal/debugger"
+)
+
+// workspaceVar is the VS Code variable for the workspace folder.
+const workspaceVar = "${workspaceFolder}"
+
+// launchBreakpoint is an entry of the breakpoints array in launch.json. The
+// fields follow the Debug Adapter Protocol's SourceBreakpoint and
+// FunctionBreakpoint, which VS Code's Go extension hands to Delve.
+type launchBreakpoint struct {
+	Path         string `json:"path,omitempty"`
+	Line         int    `json:"line,omitempty"`
+	FunctionName string `json:"functionName,omitempty"`
+	Condition    string `json:"condition,omitempty"`
+	HitCondition string `json:"hitCondition,omitempty"`
+	LogMessage   string `json:"logMessage,omitempty"`
+	Enabled      *bool  `json:"enabled,omitempty"`
+}
+
+// ParseLaunchJSON reads the breakpoints array of a VS Code launch.json,
+// which may contain comments and trailing commas. Relative paths and
+// ${workspaceFolder} resolve against workspace. Entries with a log message
+// become logpoints.
+func ParseLaunchJSON(data []byte, workspace string) (*Import, error) {
+	var doc struct {
+		Breakpoints []json.RawMessage `json:"breakpoints"`
+	}
+	if err := json.Unmarshal(stripJSONC(data), &doc); err != nil {
+		return nil, fmt.Errorf("parse launch.json: %w", err)
+	}
+
+	imp := &Import{}
+	for i, raw := range doc.Breakpoints {
+		source := fmt.Sprintf("breakpoints[%d]", i)
+		skip := func(reason string) {
+			imp.Skipped = append(imp.Skipped, Skipped{Source: source, Text: string(raw), Reason: reason})
+		}
+		var lb launchBreakpoint
+		if err := json.Unmarshal(raw, &lb); err != nil {
+			skip(err.Error())
+			continue
+		}
+
+		bp := debugger.SavedBreakpoint{
+			Condition:    lb.Condition,
+			HitCondition: lb.HitCondition,
+			Disabled:     lb.Enabled != nil && !*lb.Enabled,
+		}
+		switch {
+		case lb.FunctionName != "":
+			bp.Location = lb.FunctionName
+		case lb.Path != "" && lb.Line > 0:
+			bp.File, bp.Line = resolvePath(lb.Path, workspace), lb.Line
+		default:
+			skip("needs a path and line or a functionName")
+			continue
+		}
+		if lb.LogMessage != "" {
+			t, err := debugger.ParseLogTemplate(lb.LogMessage)
+			if err != nil {
+				skip(err.Error())
+				continue
+			}
+			bp.Tracepoint, bp.Expressions, bp.LogMessage = true, t.Expressions(), lb.LogMessage
+		}
+		imp.Entries = append(imp.Entries, Entry{Source: source, Breakpoint: bp})
+	}
+	return imp, nil
+}
+
+// UpdateLaunchJSON returns data, a launch.json, with its breakpoints array
+// replaced by bps, or a new launch.json if data is empty. Files under
+// workspace are written relative to ${workspaceFolder}. Comments in data are
+// not preserved. Settings launch.json cannot express are reported as
+// warnings.
+func UpdateLaunchJSON(data []byte, bps []debugger.SavedBreakpoint, workspace string) ([]byte, []string, error) {
+	doc := map[string]json.RawMessage{
+		"version":        json.RawMessage(`"0.2.0"`),
+		"configurations": json.RawMessage(`[]`),
+	}
+	if len(strings.TrimSpace(string(data))) > 0 {
+		doc = nil
+		if err := json.Unmarshal(stripJSONC(data), &doc); err != nil {
+			return nil, nil, fmt.Errorf("parse launch.json: %w", err)
+		}
+	}
+
+	var warnings []string
+	list := make([]launchBreakpoint, 0, len(bps))
+	for _, bp := range bps {
+		lb := launchBreakpoint{
+			Condition:    bp.Condition,
+			HitCondition: bp.HitCondition,
+		}
+		if bp.Location != "" && !strings.Contains(bp.Location, ":") {
+			lb.FunctionName = bp.Location
+		} else {
+			lb.Path, lb.Line = workspacePath(bp.File, workspace), bp.Line
+		}
+		if bp.Disabled {
+			enabled := false
+			lb.Enabled = &enabled
+		}
+		switch {
+		case bp.LogMessage != "":
+			lb.LogMessage = bp.LogMessage
+		case bp.Tracepoint:
+			lb.LogMessage = logMessage(bp)
+		}
+
+		var lost []string
+		if bp.Name != "" {
+			lost = append(lost, "name")
+		}
+		if bp.HitConditionPerGoroutine {
+			lost = append(lost, "hitConditionPerGoroutine")
+		}
+		if bp.Args || bp.Locals {
+			lost = append(lost, "args and locals capture")
+		}
+		if bp.StackDepth > 0 {
+			lost = append(lost, "stackDepth")
+		}
+		if len(bp.Expressions) > 0 && !bp.Tracepoint {
+			lost = append(lost, "expressions")
+		}
+		if len(lost) > 0 {
+			warnings = append(warnings, fmt.Sprintf("%s: %s cannot be expressed in launch.json", bp, strings.Join(lost, ", ")))
+		}
+		list = append(list, lb)
+	}
+
+	raw, err := json.Marshal(list)
+	if err != nil {
+		return nil, nil, err
+	}
+	doc["breakpoints"] = raw
+	out, err := json.MarshalIndent(doc, "", "\t")
+	if err != nil {
+		return nil, nil, err
+	}
+	return append(out, '\n'), warnings, nil
+}
+
+// logMessage renders a tracepoint's expressions as a log message.
+func logMessage(bp debugger.SavedBreakpoint) string {
+	if len(bp.Expressions) == 0 {
+		return "reached " + bp.String()
+	}
+	parts := make([]string, 0, len(bp.Expressions))
+	for _, expr := range bp.Expressions {
+		parts = append(parts, fmt.Sprintf("%s={%s}", expr, expr))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// resolvePath expands ${workspaceFolder} in path and makes it absolute.
+func resolvePath(path, workspace string) string {
+	path = strings.ReplaceAll(path, workspaceVar, workspace)
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(workspace, path)
+	}
+	return filepath.Clean(path)
+}
+
+// workspacePath writes path relative to ${workspaceFolder} when it lies
+// inside workspace.
+func workspacePath(path, workspace string) string {
+	if workspace == "" {
+		return path
+	}
+	rel, err := filepath.Rel(workspace, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return path
+	}
+	return workspaceVar + "/" + filepath.ToSlash(rel)
+}
+
+// stripJSONC removes the comments and trailing commas VS Code allows in its
+// JSON files.
+func stripJSONC(data []byte) []byte {
+	out := make([]byte, 0, len(data))
+	for i := 0; i < len(data); i++ {
+		c := data[i]
+		switch {
+		case c == '"':
+			// Copy the string, including escaped quotes.
+			j := i + 1
+			for ; j < len(data) && data[j] != '"'; j++ {
+				if data[j] == '\\' {
+					j++
+				}
+			}
+			if j >= len(data) {
+				j = len(data) - 1
+			}
+			out = append(out, data[i:j+1]...)
+			i = j
+		case c == '/' && i+1 < len(data) && data[i+1] == '/':
+			for i < len(data) && data[i] != '\n' {
+				i++
+			}
+			i--
+		case c == '/' && i+1 < len(data) && data[i+1] == '*':
+			end := strings.Index(string(data[i+2:]), "*/")
+			if end < 0 {
+				return out
+			}
+			i += end + 3
+		case c == ',':
+			// Drop the comma if only whitespace and comments separate it
+			// from a closing bracket.
+			if next := nextToken(data[i+1:]); next == '}' || next == ']' {
+				continue
+			}
+			out = append(out, c)
+		default:
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// nextToken returns the first byte of data that is neither whitespace nor
+// part of a comment, or 0.
+func nextToken(data []byte) byte {
+	for i := 0; i < len(data); i++ {
+		switch c := data[i]; {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+		case c == '/' && i+1 < len(data) && data[i+1] == '/':
+			for i < len(data) && data[i] != '\n' {
+				i++
+			}
+		case c == '/' && i+1 < len(data) && data[i+1] == '*':
+			end := strings.Index(string(data[i+2:]), "*/")
+			if end < 0 {
+				return 0
+			}
+			i += end + 3
+		default:
+			return c
+		}
+	}
+	return 0
+}
@@ -0,0 +1,115 @@
+package bpformat
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/kjbreil/dlc-sidecar/internal/debugger"
+)
+
+const launchJSON = `{
+	// Use IntelliSense to learn about possible attributes.
+	"version": "0.2.0",
+	"configurations": [
+		{
+			"name": "Launch server", /* the main binary */
+			"type": "go",
+			"request": "launch",
+			"program": "${workspaceFolder}/cmd/server",
+		},
+	],
+	"breakpoints": [
+		{"path": "${workspaceFolder}/cmd/server/main.go", "line": 42, "condition": "n > 10", "hitCondition": "5"},
+		{"path": "internal/api/handler.go", "line": 7, "enabled": false},
+		{"functionName": "main.run", "logMessage": "got {req.URL} after {elapsed}"},
+		{"path": "https://example.com/a//b.go"},
+		{"functionName": "main.stop", "logMessage": "done {"},
+	]
+}
+`
+
+func TestParseLaunchJSON(t *testing.T) {
+	imp, err := ParseLaunchJSON([]byte(launchJSON), "/work")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Entry{
+		{Source: "breakpoints[0]", Breakpoint: debugger.SavedBreakpoint{File: "/work/cmd/server/main.go", Line: 42, Condition: "n > 10", HitCondition: "5"}},
+		{Source: "breakpoints[1]", Breakpoint: debugger.SavedBreakpoint{File: "/work/internal/api/handler.go", Line: 7, Disabled: true}},
+		{Source: "breakpoints[2]", Breakpoint: debugger.SavedBreakpoint{Location: "main.run", Tracepoint: true, Expressions: []string{"req.URL", "elapsed"},
+			LogMessage: "got {req.URL} after {elapsed}"}},
+	}
+	if !reflect.DeepEqual(imp.Entries, want) {
+		t.Errorf("entries:\n got %+v\nwant %+v", imp.Entries, want)
+	}
+	if len(imp.Skipped) != 2 || imp.Skipped[0].Source != "breakpoints[3]" || !strings.Contains(imp.Skipped[0].Reason, "path and line") ||
+		!strings.Contains(imp.Skipped[1].Reason, "unclosed {") {
+		t.Errorf("skipped = %+v", imp.Skipped)
+	}
+
+	if _, err := ParseLaunchJSON([]byte(`{"breakpoints": [}`), "/work"); err == nil {
+		t.Error("expected an error for malformed JSON")
+	}
+}
+
+func TestUpdateLaunchJSON(t *testing.T) {
+	bps := []debugger.SavedBreakpoint{
+		{File: "/work/cmd/server/main.go", Line: 42, Condition: "n > 10", Disabled: true},
+		{Location: "main.run", File: "/work/run.go", Line: 3, Tracepoint: true, Expressions: []string{"x"}, StackDepth: 2},
+		{Location: "/lib/util.go:9", File: "/lib/util.go", Line: 9, HitCondition: "> 5", HitConditionPerGoroutine: true},
+		{File: "/work/run.go", Line: 8, Tracepoint: true, Expressions: []string{"n"}, LogMessage: "n is {n}"},
+	}
+	out, warnings, err := UpdateLaunchJSON([]byte(launchJSON), bps, "/work")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var doc struct {
+		Version        string
+		Configurations []map[string]interface{}
+		Breakpoints    []launchBreakpoint
+	}
+	if err := json.Unmarshal(out, &doc); err != nil {
+		t.Fatalf("%v in:\n%s", err, out)
+	}
+	if doc.Version != "0.2.0" || len(doc.Configurations) != 1 || doc.Configurations[0]["program"] != "${workspaceFolder}/cmd/server" {
+		t.Errorf("other settings not kept:\n%s", out)
+	}
+	disabled := false
+	want := []launchBreakpoint{
+		{Path: "${workspaceFolder}/cmd/server/main.go", Line: 42, Condition: "n > 10", Enabled: &disabled},
+		{FunctionName: "main.run", LogMessage: "x={x}"},
+		{Path: "/lib/util.go", Line: 9, HitCondition: "> 5"},
+		{Path: "${workspaceFolder}/run.go", Line: 8, LogMessage: "n is {n}"},
+	}
+	if !reflect.DeepEqual(doc.Breakpoints, want) {
+		t.Errorf("breakpoints:\n got %+v\nwant %+v", doc.Breakpoints, want)
+	}
+	if len(warnings) != 2 || !strings.Contains(warnings[0], "main.run: stackDepth") || !strings.Contains(warnings[1], "hitConditionPerGoroutine") {
+		t.Errorf("warnings = %q", warnings)
+	}
+
+	// A new file gets the launch.json skeleton.
+	out, _, err = UpdateLaunchJSON(nil, bps[:1], "/work")
+	if err != nil {
+		t.Fatal(err)
+	}
+	imp, err := ParseLaunchJSON(out, "/work")
+	if err != nil || len(imp.Entries) != 1 || imp.Entries[0].Breakpoint.File != "/work/cmd/server/main.go" {
+		t.Errorf("round trip = %+v, %v", imp, err)
+	}
+}
+
+func TestStripJSONC(t *testing.T) {
+	in := `{"url": "http://x/*y*/", /* c */ "a": [1, 2, // two
+], "s": "q\"//",}`
+	var v map[string]interface{}
+	if err := json.Unmarshal(stripJSONC([]byte(in)), &v); err != nil {
+		t.Fatalf("%v: %s", err, stripJSONC([]byte(in)))
+	}
+	if v["url"] != "http://x/*y*/" || v["s"] != `q"//` || len(v["a"].([]interface{})) != 2 {
+		t.Errorf("parsed = %v", v)
+	}
+}
@@ -0,0 +1,45 @@
+package debugger
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// ParseAddr splits a Delve address into the network and address to dial.
+// Accepted forms are host:port, tcp://host:port, a bare port (dialed on
+// localhost), unix:/path/to/socket and unix:///path/to/socket.
+func ParseAddr(addr string) (network, address string, err error) {
+	switch {
+	case addr == "":
+		return "", "", addrError(addr, "address is empty")
+	case strings.HasPrefix(addr, "unix:"):
+		path := strings.TrimPrefix(addr, "unix:")
+		path = strings.TrimPrefix(path, "//")
+		if path == "" {
+			return "", "", addrError(addr, "socket path is empty")
+		}
+		return "unix", path, nil
+	case strings.HasPrefix(addr, "tcp://"):
+		addr = strings.TrimPrefix(addr, "tcp://")
+	case strings.Contains(addr, "://"):
+		return "", "", addrError(addr, "unsupported scheme")
+	}
+
+	if _, err := strconv.Atoi(addr); err == nil {
+		addr = "localhost:" + addr
+	}
+	_, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return "", "", addrError(addr, err.Error())
+	}
+	if n, err := strconv.Atoi(port); err != nil || n < 1 || n > 65535 {
+		return "", "", addrError(addr, fmt.Sprintf("invalid port %q", port))
+	}
+	return "tcp", addr, nil
+}
+
+func addrError(addr, reason string) error {
+	return fmt.Errorf("invalid Delve address %q: %s (expected host:port, tcp://host:port or unix:/path/to/socket)", addr, reason)
+}
@@ -0,0 +1,45 @@
+package debugger
+
+import "testing"
+
+func TestParseAddr(t *testing.T) {
+	tests := []struct {
+		addr        string
+		wantNetwork string
+		wantAddress string
+		wantErr     bool
+	}{
+		{addr: "localhost:2345", wantNetwork: "tcp", wantAddress: "localhost:2345"},
+		{addr: "10.0.0.5:40000", wantNetwork: "tcp", wantAddress: "10.0.0.5:40000"},
+		{addr: "[::1]:2345", wantNetwork: "tcp", wantAddress: "[::1]:2345"},
+		{addr: "tcp://dlv.internal:2345", wantNetwork: "tcp", wantAddress: "dlv.internal:2345"},
+		{addr: "2345", wantNetwork: "tcp", wantAddress: "localhost:2345"},
+		{addr: "unix:/run/dlv.sock", wantNetwork: "unix", wantAddress: "/run/dlv.sock"},
+		{addr: "unix:///run/dlv.sock", wantNetwork: "unix", wantAddress: "/run/dlv.sock"},
+		{addr: "", wantErr: true},
+		{addr: "unix:", wantErr: true},
+		{addr: "http://localhost:2345", wantErr: true},
+		{addr: "localhost", wantErr: true},
+		{addr: "localhost:0", wantErr: true},
+		{addr: "localhost:http", wantErr: true},
+		{addr: "tcp://localhost:70000", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.addr, func(t *testing.T) {
+			network, address, err := ParseAddr(tt.addr)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("ParseAddr(%q) = %q, %q; want error", tt.addr, network, address)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseAddr(%q): %v", tt.addr, err)
+			}
+			if network != tt.wantNetwork || address != tt.wantAddress {
+				t.Fatalf("ParseAddr(%q) = %q, %q; want %q, %q", tt.addr, network, address, tt.wantNetwork, tt.wantAddress)
+			}
+		})
+	}
+}
@@ -0,0 +1,375 @@
+package debugger
+
+import (
+	"context"
+)
+
+// RPCError wraps a failed Delve RPC with the method that was called.
+type RPCError struct {
+	Method string
+	Err    error
+}
+
+func (e *RPCError) Error() string {
+	return e.Method + ": " + e.Err.Error()
+}
+
+func (e *RPCError) Unwrap() error {
+	return e.Err
+}
+
+// invoke calls method over the main connection, wrapping failures in RPCError.
+func (p *Pool) invoke(ctx context.Context, method string, args interface{}, reply interface{}) error {
+	if err := p.CallContext(ctx, method, args, reply); err != nil {
+		return &RPCError{Method: method, Err: err}
+	}
+	return nil
+}
+
+// invokeControl calls method over the control connection, wrapping failures
+// in RPCError.
+func (p *Pool) invokeControl(ctx context.Context, method string, args interface{}, reply interface{}) error {
+	if err := p.Control(ctx, method, args, reply); err != nil {
+		return &RPCError{Method: method, Err: err}
+	}
+	return nil
+}
+
+// GetVersion returns the Delve and API versions reported by the server.
+func (p *Pool) GetVersion(ctx context.Context) (*GetVersionOut, error) {
+	var out GetVersionOut
+	if err := p.invoke(ctx, "GetVersion", GetVersionIn{}, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// State returns the debugger state. A non-blocking query goes over the
+// control connection so it answers while the target is running.
+func (p *Pool) State(ctx context.Context, nonBlocking bool) (*DebuggerState, error) {
+	var out StateOut
+	call := p.invoke
+	if nonBlocking {
+		call = p.invokeControl
+	}
+	if err := call(ctx, "State", StateIn{NonBlocking: nonBlocking}, &out); err != nil {
+		return nil, err
+	}
+	return out.State, nil
+}
+
+// Command runs an execution command and returns the state the target
+// stopped in. A continue carries on past tracepoints. Use Start to run it
+// without waiting and Halt to interrupt it.
+func (p *Pool) Command(ctx context.Context, cmd DebuggerCommand) (*DebuggerState, error) {
+	return p.traceCommand(ctx, cmd, func(out *CommandOut) error {
+		return p.invoke(ctx, "Command", cmd, out)
+	})
+}
+
+// CreateBreakpoint sets bp and returns the breakpoint as created by Delve.
+func (p *Pool) CreateBreakpoint(ctx context.Context, bp *Breakpoint) (*Breakpoint, error) {
+	return p.CreateBreakpointExpr(ctx, bp, "")
+}
+
+// CreateBreakpointExpr sets bp, which must carry a resolved location, and
+// records locExpr as the location expression it came from so Delve can
+// resolve it again after a restart.
+func (p *Pool) CreateBreakpointExpr(ctx context.Context, bp *Breakpoint, locExpr string) (*Breakpoint, error) {
+	var out CreateBreakpointOut
+	if err := p.invoke(ctx, "CreateBreakpoint", CreateBreakpointIn{Breakpoint: *bp, LocExpr: locExpr}, &out); err != nil {
+		return nil, err
+	}
+	return &out.Breakpoint, nil
+}
+
+// ClearBreakpoint deletes the breakpoint with the given ID and returns it.
+func (p *Pool) ClearBreakpoint(ctx context.Context, id int) (*Breakpoint, error) {
+	var out ClearBreakpointOut
+	if err := p.invoke(ctx, "ClearBreakpoint", ClearBreakpointIn{Id: id}, &out); err != nil {
+		return nil, err
+	}
+	return out.Breakpoint, nil
+}
+
+// ListBreakpoints returns the breakpoints set in the target. With all set,
+// Delve's internal breakpoints (such as the panic handlers) are included.
+func (p *Pool) ListBreakpoints(ctx context.Context, all bool) ([]*Breakpoint, error) {
+	var out ListBreakpointsOut
+	if err := p.invoke(ctx, "ListBreakpoints", ListBreakpointsIn{All: all}, &out); err != nil {
+		return nil, err
+	}
+	return out.Breakpoints, nil
+}
+
+// Stacktrace returns up to depth frames of the goroutine's stack. When cfg is
+// non-nil each frame includes its arguments and locals loaded with cfg.
+func (p *Pool) Stacktrace(ctx context.Context, goroutineID int64, depth int, cfg *LoadConfig) ([]Stackframe, error) {
+	return p.StacktraceOpts(ctx, goroutineID, depth, 0, cfg)
+}
+
+// StacktraceOpts is Stacktrace with extra options, such as
+// StacktraceReadDefers to include each frame's pending deferred calls.
+func (p *Pool) StacktraceOpts(ctx context.Context, goroutineID int64, depth int, opts StacktraceOptions, cfg *LoadConfig) ([]Stackframe, error) {
+	in := StacktraceIn{
+		Id:    goroutineID,
+		Depth: depth,
+		Full:  cfg != nil,
+		Opts:  opts,
+		Cfg:   cfg,
+	}
+	var out StacktraceOut
+	if err := p.invoke(ctx, "Stacktrace", in, &out); err != nil {
+		return nil, err
+	}
+	return out.Locations, nil
+}
+
+// ListGoroutines returns up to count goroutines starting at start, and the
+// start value for the next page, which is negative once all are listed.
+func (p *Pool) ListGoroutines(ctx context.Context, start, count int) ([]*Goroutine, int, error) {
+	var out ListGoroutinesOut
+	if err := p.invoke(ctx, "ListGoroutines", ListGoroutinesIn{Start: start, Count: count}, &out); err != nil {
+		return nil, 0, err
+	}
+	return out.Goroutines, out.Nextg, nil
+}
+
+// ListGoroutinesFiltered lists goroutines with the filters and grouping in
+// in. When grouping is requested the result's Groups index into Goroutines.
+func (p *Pool) ListGoroutinesFiltered(ctx context.Context, in ListGoroutinesIn) (*ListGoroutinesOut, error) {
+	var out ListGoroutinesOut
+	if err := p.invoke(ctx, "ListGoroutines", in, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// Ancestors returns up to numAncestors goroutines that created goroutineID,
+// each with up to depth frames. It requires the target to run with
+// GODEBUG=tracebackancestors=N.
+func (p *Pool) Ancestors(ctx context.Context, goroutineID int64, numAncestors, depth int) ([]Ancestor, error) {
+	in := AncestorsIn{GoroutineID: goroutineID, NumAncestors: numAncestors, Depth: depth}
+	var out AncestorsOut
+	if err := p.invoke(ctx, "Ancestors", in, &out); err != nil {
+		return nil, err
+	}
+	return out.Ancestors, nil
+}
+
+// ListLocalVars returns the local variables in scope.
+func (p *Pool) ListLocalVars(ctx context.Context, scope EvalScope, cfg LoadConfig) ([]Variable, error) {
+	var out ListLocalVarsOut
+	if err := p.invoke(ctx, "ListLocalVars", ListLocalVarsIn{Scope: scope, Cfg: cfg}, &out); err != nil {
+		return nil, err
+	}
+	return out.Variables, nil
+}
+
+// ListFunctionArgs returns the arguments of the function in scope.
+func (p *Pool) ListFunctionArgs(ctx context.Context, scope EvalScope, cfg LoadConfig) ([]Variable, error) {
+	var out ListFunctionArgsOut
+	if err := p.invoke(ctx, "ListFunctionArgs", ListFunctionArgsIn{Scope: scope, Cfg: cfg}, &out); err != nil {
+		return nil, err
+	}
+	return out.Args, nil
+}
+
+// Eval evaluates expr in scope.
+func (p *Pool) Eval(ctx context.Context, scope EvalScope, expr string, cfg *LoadConfig) (*Variable, error) {
+	var out EvalOut
+	if err := p.invoke(ctx, "Eval", EvalIn{Scope: scope, Expr: expr, Cfg: cfg}, &out); err != nil {
+		return nil, err
+	}
+	return out.Variable, nil
+}
+
+// Set assigns value to symbol in scope.
+func (p *Pool) Set(ctx context.Context, scope EvalScope, symbol, value string) error {
+	return p.invoke(ctx, "Set", SetIn{Scope: scope, Symbol: symbol, Value: value}, &SetOut{})
+}
+
+// GetBreakpoint returns the breakpoint with the given ID.
+func (p *Pool) GetBreakpoint(ctx context.Context, id int) (*Breakpoint, error) {
+	var out GetBreakpointOut
+	if err := p.invoke(ctx, "GetBreakpoint", GetBreakpointIn{Id: id}, &out); err != nil {
+		return nil, err
+	}
+	return &out.Breakpoint, nil
+}
+
+// ToggleBreakpoint enables or disables the breakpoint with the given ID and
+// returns its new state.
+func (p *Pool) ToggleBreakpoint(ctx context.Context, id int) (*Breakpoint, error) {
+	var out ToggleBreakpointOut
+	if err := p.invoke(ctx, "ToggleBreakpoint", ToggleBreakpointIn{Id: id}, &out); err != nil {
+		return nil, err
+	}
+	return out.Breakpoint, nil
+}
+
+// AmendBreakpoint replaces the condition, hit condition, tracepoint flag and
+// load settings of the existing breakpoint with bp's ID.
+func (p *Pool) AmendBreakpoint(ctx context.Context, bp *Breakpoint) error {
+	return p.invoke(ctx, "AmendBreakpoint", AmendBreakpointIn{Breakpoint: *bp}, &AmendBreakpointOut{})
+}
+
+// CreateWatchpoint sets a hardware watchpoint on expr, evaluated in scope.
+func (p *Pool) CreateWatchpoint(ctx context.Context, scope EvalScope, expr string, typ WatchType) (*Breakpoint, error) {
+	var out CreateWatchpointOut
+	if err := p.invoke(ctx, "CreateWatchpoint", CreateWatchpointIn{Scope: scope, Expr: expr, Type: typ}, &out); err != nil {
+		return nil, err
+	}
+	return out.Breakpoint, nil
+}
+
+// FindLocation resolves a Delve location expression to the locations it
+// refers to; a regular expression may match several functions.
+func (p *Pool) FindLocation(ctx context.Context, scope EvalScope, loc string, includeNonExecutableLines bool) ([]Location, error) {
+	in := FindLocationIn{Scope: scope, Loc: loc, IncludeNonExecutableLines: includeNonExecutableLines}
+	var out FindLocationOut
+	if err := p.invoke(ctx, "FindLocation", in, &out); err != nil {
+		return nil, err
+	}
+	return out.Locations, nil
+}
+
+// Restart restarts the target and returns the breakpoints Delve could not
+// restore, for instance because a rebuild moved or removed their lines.
+func (p *Pool) Restart(ctx context.Context, in RestartIn) ([]DiscardedBreakpoint, error) {
+	var out RestartOut
+	if err := p.invoke(ctx, "Restart", in, &out); err != nil {
+		return nil, err
+	}
+	return out.DiscardedBreakpoints, nil
+}
+
+// CancelNext abandons a next or step interrupted by another breakpoint.
+func (p *Pool) CancelNext(ctx context.Context) error {
+	return p.invoke(ctx, "CancelNext", CancelNextIn{}, &CancelNextOut{})
+}
+
+// ListThreads returns the target's threads.
+func (p *Pool) ListThreads(ctx context.Context) ([]*Thread, error) {
+	var out ListThreadsOut
+	if err := p.invoke(ctx, "ListThreads", ListThreadsIn{}, &out); err != nil {
+		return nil, err
+	}
+	return out.Threads, nil
+}
+
+// GetThread returns the thread with the given ID.
+func (p *Pool) GetThread(ctx context.Context, id int) (*Thread, error) {
+	var out GetThreadOut
+	if err := p.invoke(ctx, "GetThread", GetThreadIn{Id: id}, &out); err != nil {
+		return nil, err
+	}
+	return out.Thread, nil
+}
+
+// ListRegisters returns the registers of threadID, or of the frame in scope
+// when scope is non-nil.
+func (p *Pool) ListRegisters(ctx context.Context, threadID int, includeFp bool, scope *EvalScope) ([]Register, error) {
+	in := ListRegistersIn{ThreadID: threadID, IncludeFp: includeFp, Scope: scope}
+	var out ListRegistersOut
+	if err := p.invoke(ctx, "ListRegisters", in, &out); err != nil {
+		return nil, err
+	}
+	return out.Regs, nil
+}
+
+// ListPackageVars returns the package variables matching filter.
+func (p *Pool) ListPackageVars(ctx context.Context, filter string, cfg LoadConfig) ([]Variable, error) {
+	var out ListPackageVarsOut
+	if err := p.invoke(ctx, "ListPackageVars", ListPackageVarsIn{Filter: filter, Cfg: cfg}, &out); err != nil {
+		return nil, err
+	}
+	return out.Variables, nil
+}
+
+// ListSources returns the source files matching the regexp filter.
+func (p *Pool) ListSources(ctx context.Context, filter string) ([]string, error) {
+	var out ListSourcesOut
+	if err := p.invoke(ctx, "ListSources", ListSourcesIn{Filter: filter}, &out); err != nil {
+		return nil, err
+	}
+	return out.Sources, nil
+}
+
+// ListFunctions returns the functions matching the regexp filter.
+func (p *Pool) ListFunctions(ctx context.Context, filter string) ([]string, error) {
+	var out ListFunctionsOut
+	if err := p.invoke(ctx, "ListFunctions", ListFunctionsIn{Filter: filter}, &out); err != nil {
+		return nil, err
+	}
+	return out.Funcs, nil
+}
+
+// ListTypes returns the types matching the regexp filter.
+func (p *Pool) ListTypes(ctx context.Context, filter string) ([]string, error) {
+	var out ListTypesOut
+	if err := p.invoke(ctx, "ListTypes", ListTypesIn{Filter: filter}, &out); err != nil {
+		return nil, err
+	}
+	return out.Types, nil
+}
+
+// Disassemble returns the instructions between startPC and endPC, or of the
+// whole function containing startPC when endPC is zero.
+func (p *Pool) Disassemble(ctx context.Context, scope EvalScope, startPC, endPC uint64, flavour AssemblyFlavour) ([]AsmInstruction, error) {
+	in := DisassembleIn{Scope: scope, StartPC: startPC, EndPC: endPC, Flavour: flavour}
+	var out DisassembleOut
+	if err := p.invoke(ctx, "Disassemble", in, &out); err != nil {
+		return nil, err
+	}
+	return out.Disassemble, nil
+}
+
+// ExamineMemory reads length bytes of target memory starting at address.
+func (p *Pool) ExamineMemory(ctx context.Context, address uint64, length int) (*ExamineMemoryOut, error) {
+	var out ExamineMemoryOut
+	if err := p.invoke(ctx, "ExamineMemory", ExamineMemoryIn{Address: address, Length: length}, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// FunctionReturnLocations returns the addresses of fnName's return
+// instructions.
+func (p *Pool) FunctionReturnLocations(ctx context.Context, fnName string) ([]uint64, error) {
+	var out FunctionReturnLocationsOut
+	if err := p.invoke(ctx, "FunctionReturnLocations", FunctionReturnLocationsIn{FnName: fnName}, &out); err != nil {
+		return nil, err
+	}
+	return out.Addrs, nil
+}
+
+// ListDynamicLibraries returns the shared libraries loaded by the target.
+func (p *Pool) ListDynamicLibraries(ctx context.Context) ([]Image, error) {
+	var out ListDynamicLibrariesOut
+	if err := p.invoke(ctx, "ListDynamicLibraries", ListDynamicLibrariesIn{}, &out); err != nil {
+		return nil, err
+	}
+	return out.List, nil
+}
+
+// ListPackagesBuildInfo returns the packages compiled into the target whose
+// import path matches filter.
+func (p *Pool) ListPackagesBuildInfo(ctx context.Context, filter string, includeFiles bool) ([]PackageBuildInfo, error) {
+	in := ListPackagesBuildInfoIn{Filter: filter, IncludeFiles: includeFiles}
+	var out ListPackagesBuildInfoOut
+	if err := p.invoke(ctx, "ListPackagesBuildInfo", in, &out); err != nil {
+		return nil, err
+	}
+	return out.List, nil
+}
+
+// AttachedToExistingProcess reports whether Delve attached to the target
+// rather than starting it.
+func (p *Pool) AttachedToExistingProcess(ctx context.Context) (bool, error) {
+	var out AttachedToExistingProcessOut
+	if err := p.invoke(ctx, "AttachedToExistingProcess", AttachedToExistingProcessIn{}, &out); err != nil {
+		return false, err
+	}
+	return out.Answer, nil
+}
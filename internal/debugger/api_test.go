@@ -0,0 +1,128 @@
+package debugger
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+)
+
+// apiService records the requests it receives and answers with canned data.
+type apiService struct {
+	mu       sync.Mutex
+	created  []Breakpoint
+	lastEval EvalIn
+}
+
+func (a *apiService) GetVersion(args *GetVersionIn, out *GetVersionOut) error {
+	out.APIVersion = 2
+	return nil
+}
+
+func (a *apiService) IsMulticlient(args *IsMulticlientIn, out *IsMulticlientOut) error {
+	out.IsMulticlient = true
+	return nil
+}
+
+func (a *apiService) CreateBreakpoint(args *CreateBreakpointIn, out *CreateBreakpointOut) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.created = append(a.created, args.Breakpoint)
+	out.Breakpoint = args.Breakpoint
+	out.Breakpoint.ID = len(a.created)
+	return nil
+}
+
+func (a *apiService) ClearBreakpoint(args *ClearBreakpointIn, out *ClearBreakpointOut) error {
+	return errors.New("Breakpoint not found")
+}
+
+func (a *apiService) Eval(args *EvalIn, out *EvalOut) error {
+	a.mu.Lock()
+	a.lastEval = *args
+	a.mu.Unlock()
+	out.Variable = &Variable{Name: args.Expr, Value: "42"}
+	return nil
+}
+
+func (a *apiService) Stacktrace(args *StacktraceIn, out *StacktraceOut) error {
+	out.Locations = []Stackframe{{Location: Location{File: "main.go", Line: args.Depth}}}
+	if args.Full {
+		out.Locations[0].Locals = []Variable{{Name: "x"}}
+	}
+	return nil
+}
+
+func TestAPI_CreateBreakpoint(t *testing.T) {
+	svc := &apiService{}
+	pool := NewPool(serveRPC(t, svc))
+	defer pool.Close()
+
+	bp, err := pool.CreateBreakpoint(context.Background(), &Breakpoint{File: "/src/main.go", Line: 10, Cond: "x > 1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bp.ID != 1 || bp.File != "/src/main.go" || bp.Line != 10 {
+		t.Fatalf("unexpected breakpoint: %+v", bp)
+	}
+	if len(svc.created) != 1 || svc.created[0].Cond != "x > 1" {
+		t.Fatalf("server received %+v", svc.created)
+	}
+}
+
+func TestAPI_ErrorsNameMethod(t *testing.T) {
+	pool := NewPool(serveRPC(t, &apiService{}))
+	defer pool.Close()
+
+	_, err := pool.ClearBreakpoint(context.Background(), 7)
+	var rpcErr *RPCError
+	if !errors.As(err, &rpcErr) {
+		t.Fatalf("expected *RPCError, got %T: %v", err, err)
+	}
+	if rpcErr.Method != "ClearBreakpoint" {
+		t.Fatalf("Method = %q, want ClearBreakpoint", rpcErr.Method)
+	}
+	if got, want := err.Error(), "ClearBreakpoint: Breakpoint not found"; got != want {
+		t.Fatalf("Error() = %q, want %q", got, want)
+	}
+}
+
+func TestAPI_Eval(t *testing.T) {
+	svc := &apiService{}
+	pool := NewPool(serveRPC(t, svc))
+	defer pool.Close()
+
+	cfg := DefaultLoadConfig()
+	v, err := pool.Eval(context.Background(), EvalScope{GoroutineID: -1, Frame: 2}, "answer", &cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.Value != "42" {
+		t.Fatalf("Value = %q, want 42", v.Value)
+	}
+	if svc.lastEval.Scope.Frame != 2 || svc.lastEval.Cfg == nil {
+		t.Fatalf("server received %+v", svc.lastEval)
+	}
+}
+
+func TestAPI_StacktraceFull(t *testing.T) {
+	pool := NewPool(serveRPC(t, &apiService{}))
+	defer pool.Close()
+
+	frames, err := pool.Stacktrace(context.Background(), -1, 5, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(frames) != 1 || frames[0].Line != 5 || frames[0].Locals != nil {
+		t.Fatalf("unexpected frames without cfg: %+v", frames)
+	}
+
+	cfg := DefaultLoadConfig()
+	frames, err = pool.Stacktrace(context.Background(), -1, 5, &cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(frames[0].Locals) != 1 {
+		t.Fatalf("expected locals with cfg, got %+v", frames)
+	}
+}
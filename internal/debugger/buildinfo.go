@@ -0,0 +1,52 @@
+package debugger
+
+import (
+	"debug/buildinfo"
+	"fmt"
+)
+
+// BuildInfo summarizes the build metadata embedded in a Go binary.
+type BuildInfo struct {
+	GoVersion     string            `json:"goVersion"`
+	Path          string            `json:"path,omitempty"`
+	Module        string            `json:"module,omitempty"`
+	ModuleVersion string            `json:"moduleVersion,omitempty"`
+	VCS           string            `json:"vcs,omitempty"`
+	Revision      string            `json:"revision,omitempty"`
+	RevisionTime  string            `json:"revisionTime,omitempty"`
+	Modified      bool              `json:"modified,omitempty"`
+	Settings      map[string]string `json:"settings,omitempty"`
+}
+
+// ReadBuildInfo reads the build metadata from the Go binary at path.
+func ReadBuildInfo(path string) (*BuildInfo, error) {
+	info, err := buildinfo.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read build info from %s: %w", path, err)
+	}
+
+	bi := &BuildInfo{
+		GoVersion:     info.GoVersion,
+		Path:          info.Path,
+		Module:        info.Main.Path,
+		ModuleVersion: info.Main.Version,
+	}
+	for _, s := range info.Settings {
+		switch s.Key {
+		case "vcs":
+			bi.VCS = s.Value
+		case "vcs.revision":
+			bi.Revision = s.Value
+		case "vcs.time":
+			bi.RevisionTime = s.Value
+		case "vcs.modified":
+			bi.Modified = s.Value == "true"
+		default:
+			if bi.Settings == nil {
+				bi.Settings = make(map[string]string)
+			}
+			bi.Settings[s.Key] = s.Value
+		}
+	}
+	return bi, nil
+}
@@ -0,0 +1,35 @@
+package debugger
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestReadBuildInfo(t *testing.T) {
+	self, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bi, err := ReadBuildInfo(self)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bi.GoVersion != runtime.Version() {
+		t.Fatalf("GoVersion = %q, want %q", bi.GoVersion, runtime.Version())
+	}
+	if bi.Settings["GOOS"] != runtime.GOOS {
+		t.Fatalf("GOOS setting = %q, want %q", bi.Settings["GOOS"], runtime.GOOS)
+	}
+}
+
+func TestReadBuildInfo_NotGo(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "script.sh")
+	os.WriteFile(path, []byte("#!/bin/sh\n"), 0o755)
+
+	if _, err := ReadBuildInfo(path); err == nil {
+		t.Fatal("expected error for non-Go file")
+	}
+}
@@ -1,6 +1,8 @@
 package debugger
 
 import (
+	"context"
+	"errors"
 	"net"
 	netrpc "net/rpc"
 	"net/rpc/jsonrpc"
@@ -14,9 +16,14 @@ type Client struct {
 	rpc  *netrpc.Client
 }
 
-// Dial connects to the Delve debugger at the given address and returns a Client.
+// Dial connects to the Delve debugger at the given address and returns a
+// Client. The address may use any form accepted by ParseAddr.
 func Dial(addr string) (*Client, error) {
-	conn, err := net.Dial("tcp", addr)
+	network, address, err := ParseAddr(addr)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := net.Dial(network, address)
 	if err != nil {
 		return nil, err
 	}
@@ -31,10 +38,25 @@ func (c *Client) Call(method string, args interface{}, reply interface{}) error
 	return c.rpc.Call("RPCServer."+method, args, reply)
 }
 
+// CallContext invokes an RPC method on the Delve debugger, returning early with
+// ctx.Err() if the context is done before the reply arrives. The reply value
+// must not be reused after an early return since a late response may still be
+// decoded into it.
+func (c *Client) CallContext(ctx context.Context, method string, args interface{}, reply interface{}) error {
+	call := c.rpc.Go("RPCServer."+method, args, reply, make(chan *netrpc.Call, 1))
+	select {
+	case <-call.Done:
+		return call.Error
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
 // Close closes the connection to the Delve debugger.
 func (c *Client) Close() error {
 	err := c.rpc.Close()
-	if err2 := c.conn.Close(); err == nil {
+	// Closing the RPC client normally closes conn as well.
+	if err2 := c.conn.Close(); err == nil && !errors.Is(err2, net.ErrClosed) {
 		err = err2
 	}
 	return err
@@ -0,0 +1,95 @@
+package debuggertest
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// DlvEnv holds the address of the Server that a test binary started as dlv
+// stands in for. It is set by InstallDlv and read by MainDlv.
+const DlvEnv = "DLC_SIDECAR_FAKE_DLV"
+
+// BuildErrorEnv makes a fake dlv fail the way a build error would when it is
+// set in the environment Delve is launched with.
+const BuildErrorEnv = "DLC_SIDECAR_FAKE_BUILD_ERROR"
+
+// InstallDlv puts a dlv on PATH for the rest of the test that runs the test
+// binary again as a headless Delve for s. The test binary's TestMain must
+// call MainDlv. The environment is changed with t.Setenv, so the test must
+// not be parallel.
+func (s *Server) InstallDlv(t testing.TB) {
+	t.Helper()
+	exe, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	if err := os.Symlink(exe, filepath.Join(dir, "dlv")); err != nil {
+		t.Skipf("cannot link dlv: %v", err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	t.Setenv(DlvEnv, s.Addr)
+}
+
+// MainDlv runs the fake dlv and exits if the test binary was started by
+// one installed with InstallDlv; otherwise it returns. Call it first in
+// TestMain.
+func MainDlv() {
+	if addr := os.Getenv(DlvEnv); addr != "" && filepath.Base(os.Args[0]) == "dlv" {
+		os.Exit(runDlv(addr, os.Args[1:]))
+	}
+}
+
+// runDlv listens on the --listen address like a headless Delve and relays
+// every connection to the Server at upstream until it is interrupted.
+func runDlv(upstream string, args []string) int {
+	if os.Getenv(BuildErrorEnv) != "" {
+		fmt.Println("build failed: syntax error")
+		return 1
+	}
+	var addr string
+	for _, arg := range args {
+		if v, ok := strings.CutPrefix(arg, "--listen="); ok {
+			addr = v
+		}
+	}
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		fmt.Println(err)
+		return 1
+	}
+	fmt.Println("API server listening at:", addr)
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			return 1
+		}
+		go relay(conn, upstream)
+	}
+}
+
+// relay copies traffic between conn and a new connection to upstream until
+// either side closes.
+func relay(conn net.Conn, upstream string) {
+	defer conn.Close()
+	up, err := net.Dial("tcp", upstream)
+	if err != nil {
+		return
+	}
+	defer up.Close()
+	done := make(chan struct{}, 2)
+	go func() {
+		io.Copy(up, conn)
+		done <- struct{}{}
+	}()
+	go func() {
+		io.Copy(conn, up)
+		done <- struct{}{}
+	}()
+	<-done
+}
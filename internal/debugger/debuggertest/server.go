@@ -0,0 +1,873 @@
+// Package debuggertest provides an in-process fake of Delve's JSON-RPC v2
+// server for testing code built on the debugger package.
+package debuggertest
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/rpc"
+	"net/rpc/jsonrpc"
+	"sort"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/kjbreil/dlc-sidecar/internal/debugger"
+)
+
+// ErrClosed is returned to a continue that is still running when the server
+// shuts down.
+var ErrClosed = errors.New("debuggertest: server closed")
+
+// Call is a request received by the server.
+type Call struct {
+	Method string
+	Args   interface{}
+}
+
+// Server is a scriptable fake of Delve's RPCServer. Tests program its
+// breakpoints, goroutines, variables and the states that execution commands
+// stop in, and may inject errors into any method.
+//
+// A continue with no queued stop blocks, like a target that never hits a
+// breakpoint, until QueueStop delivers a state, a halt arrives or the server
+// is closed. Other execution commands with no queued stop return the
+// current state unchanged.
+type Server struct {
+	// Addr is the address the server listens on.
+	Addr string
+
+	listener net.Listener
+
+	mu          sync.Mutex
+	conns       map[net.Conn]struct{}
+	version     debugger.GetVersionOut
+	multiclient bool
+	state       debugger.DebuggerState
+	stops       []debugger.DebuggerState
+	running     chan debugger.DebuggerState
+	breakpoints map[int]*debugger.Breakpoint
+	nextID      int
+	goroutines  []*debugger.Goroutine
+	stacks      map[int64][]debugger.Stackframe
+	locals      []debugger.Variable
+	args        []debugger.Variable
+	evals       map[string]debugger.Variable
+	locations   map[string][]debugger.Location
+	errs        map[string]error
+	calls       []Call
+	detached    *bool
+	discards    map[int]string
+	closed      bool
+}
+
+// NewServer starts a fake Delve listening on a loopback port. It is closed
+// when the test finishes.
+func NewServer(t testing.TB) *Server {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &Server{
+		Addr:     l.Addr().String(),
+		listener: l,
+		conns:    make(map[net.Conn]struct{}),
+		version: debugger.GetVersionOut{
+			DelveVersion:    "Version: 1.23.1 (debuggertest)",
+			APIVersion:      debugger.APIVersion,
+			Backend:         "native",
+			TargetGoVersion: "go1.23.2",
+		},
+		multiclient: true,
+		state: debugger.DebuggerState{
+			Pid:               4242,
+			TargetCommandLine: "/tmp/__debug_bin",
+		},
+		breakpoints: make(map[int]*debugger.Breakpoint),
+		nextID:      1,
+		stacks:      make(map[int64][]debugger.Stackframe),
+		evals:       make(map[string]debugger.Variable),
+		locations:   make(map[string][]debugger.Location),
+		errs:        make(map[string]error),
+		discards:    make(map[int]string),
+	}
+
+	srv := rpc.NewServer()
+	if err := srv.RegisterName("RPCServer", &service{s}); err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			s.mu.Lock()
+			if s.closed {
+				s.mu.Unlock()
+				conn.Close()
+				return
+			}
+			s.conns[conn] = struct{}{}
+			s.mu.Unlock()
+			go func() {
+				srv.ServeCodec(jsonrpc.NewServerCodec(conn))
+				s.mu.Lock()
+				delete(s.conns, conn)
+				s.mu.Unlock()
+			}()
+		}
+	}()
+	t.Cleanup(s.Close)
+	return s
+}
+
+// Close shuts the server down as if Delve had died: it stops accepting
+// connections, drops the open ones and fails any continue still running.
+func (s *Server) Close() {
+	s.mu.Lock()
+	s.closed = true
+	if s.running != nil {
+		close(s.running)
+		s.running = nil
+	}
+	s.mu.Unlock()
+	s.listener.Close()
+	s.Disconnect()
+}
+
+// Disconnect drops every open client connection while still accepting new
+// ones, like a network interruption.
+func (s *Server) Disconnect() {
+	s.mu.Lock()
+	conns := s.conns
+	s.conns = make(map[net.Conn]struct{})
+	s.mu.Unlock()
+	for conn := range conns {
+		conn.Close()
+	}
+}
+
+// Reset makes the server look like a Delve restarted at the same address:
+// it drops every open connection and forgets its breakpoints.
+func (s *Server) Reset() {
+	s.mu.Lock()
+	s.breakpoints = make(map[int]*debugger.Breakpoint)
+	s.nextID = 1
+	s.mu.Unlock()
+	s.Disconnect()
+}
+
+// SetVersion sets the reply to GetVersion.
+func (s *Server) SetVersion(v debugger.GetVersionOut) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.version = v
+}
+
+// SetMulticlient sets whether the server reports accepting multiple clients.
+func (s *Server) SetMulticlient(multiclient bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.multiclient = multiclient
+}
+
+// SetState replaces the current debugger state.
+func (s *Server) SetState(st debugger.DebuggerState) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.state = st
+}
+
+// State returns the current debugger state.
+func (s *Server) State() debugger.DebuggerState {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	st := s.state
+	st.Running = s.running != nil
+	return st
+}
+
+// QueueStop makes the running continue, or else the next execution
+// command, stop in st.
+func (s *Server) QueueStop(st debugger.DebuggerState) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.running != nil {
+		s.running <- st
+		s.running = nil
+		return
+	}
+	s.stops = append(s.stops, st)
+}
+
+// HitBreakpoint queues a stop at the breakpoint with the given ID on
+// goroutine 1, counting the hit and capturing the data the breakpoint asks
+// for as Delve would.
+func (s *Server) HitBreakpoint(id int) {
+	s.mu.Lock()
+	bp, ok := s.breakpoints[id]
+	if !ok {
+		s.mu.Unlock()
+		panic(fmt.Sprintf("debuggertest: no breakpoint %d", id))
+	}
+	bp.TotalHitCount++
+	bp.HitCount["1"]++
+	hit := *bp
+	info := s.breakpointInfo(bp)
+	st := s.state
+	s.mu.Unlock()
+
+	st.CurrentThread = &debugger.Thread{
+		ID:             1,
+		PC:             bp.Addr,
+		File:           bp.File,
+		Line:           bp.Line,
+		Function:       &debugger.Function{Name: bp.FunctionName},
+		GoroutineID:    1,
+		Breakpoint:     &hit,
+		BreakpointInfo: info,
+	}
+	st.Threads = []*debugger.Thread{st.CurrentThread}
+	st.SelectedGoroutine = &debugger.Goroutine{
+		ID:         1,
+		CurrentLoc: debugger.Location{PC: bp.Addr, File: bp.File, Line: bp.Line},
+	}
+	s.QueueStop(st)
+}
+
+// HitWatchpoint queues a stop on goroutine 1 at loc, where the code that
+// accessed the memory watched by the watchpoint with the given ID runs.
+func (s *Server) HitWatchpoint(id int, loc debugger.Location) {
+	s.mu.Lock()
+	bp, ok := s.breakpoints[id]
+	if !ok || bp.WatchExpr == "" {
+		s.mu.Unlock()
+		panic(fmt.Sprintf("debuggertest: no watchpoint %d", id))
+	}
+	bp.TotalHitCount++
+	bp.HitCount["1"]++
+	hit := *bp
+	st := s.state
+	s.mu.Unlock()
+
+	st.CurrentThread = &debugger.Thread{
+		ID:          1,
+		PC:          loc.PC,
+		File:        loc.File,
+		Line:        loc.Line,
+		Function:    loc.Function,
+		GoroutineID: 1,
+		Breakpoint:  &hit,
+	}
+	st.Threads = []*debugger.Thread{st.CurrentThread}
+	st.SelectedGoroutine = &debugger.Goroutine{ID: 1, CurrentLoc: loc}
+	s.QueueStop(st)
+}
+
+// EndWatchScope removes the watchpoint with the given ID and queues a stop
+// at loc reporting it in WatchOutOfScope, as Delve does when the function
+// owning a watched stack variable returns.
+func (s *Server) EndWatchScope(id int, loc debugger.Location) {
+	s.mu.Lock()
+	bp, ok := s.breakpoints[id]
+	if !ok || bp.WatchExpr == "" {
+		s.mu.Unlock()
+		panic(fmt.Sprintf("debuggertest: no watchpoint %d", id))
+	}
+	delete(s.breakpoints, id)
+	st := s.state
+	s.mu.Unlock()
+
+	st.CurrentThread = &debugger.Thread{
+		ID:          1,
+		PC:          loc.PC,
+		File:        loc.File,
+		Line:        loc.Line,
+		Function:    loc.Function,
+		GoroutineID: 1,
+	}
+	st.Threads = []*debugger.Thread{st.CurrentThread}
+	st.SelectedGoroutine = &debugger.Goroutine{ID: 1, CurrentLoc: loc}
+	st.WatchOutOfScope = []*debugger.Breakpoint{bp}
+	s.QueueStop(st)
+}
+
+// Running reports whether a continue is blocked waiting for a stop.
+func (s *Server) Running() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.running != nil
+}
+
+// Breakpoints returns copies of the breakpoints set, ordered by ID.
+func (s *Server) Breakpoints() []debugger.Breakpoint {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var bps []debugger.Breakpoint
+	for _, bp := range s.sortedBreakpoints() {
+		bps = append(bps, *bp)
+	}
+	return bps
+}
+
+// SetGoroutines sets the goroutines returned by ListGoroutines.
+func (s *Server) SetGoroutines(gs ...*debugger.Goroutine) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.goroutines = gs
+}
+
+// SetStack sets the frames returned by Stacktrace for a goroutine. Frames
+// set for goroutine -1 are returned for the selected goroutine.
+func (s *Server) SetStack(goroutineID int64, frames ...debugger.Stackframe) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stacks[goroutineID] = frames
+}
+
+// SetLocals sets the variables returned by ListLocalVars.
+func (s *Server) SetLocals(vars ...debugger.Variable) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.locals = vars
+}
+
+// SetArgs sets the variables returned by ListFunctionArgs.
+func (s *Server) SetArgs(vars ...debugger.Variable) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.args = vars
+}
+
+// SetEval sets the result of evaluating expr. Expressions without a result
+// fail as an unknown symbol would.
+func (s *Server) SetEval(expr string, v debugger.Variable) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.evals[expr] = v
+}
+
+// DiscardOnRestart makes the next Restart drop breakpoint id, reporting
+// reason as Delve does when a rebuild moved its line.
+func (s *Server) DiscardOnRestart(id int, reason string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.discards[id] = reason
+}
+
+// SetLocation sets the locations FindLocation resolves expr to. Breakpoints
+// created at one of their addresses are placed there; as in Delve, a
+// location expression passed to CreateBreakpoint is only recorded.
+// Unknown expressions are not found.
+func (s *Server) SetLocation(expr string, locs ...debugger.Location) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.locations[expr] = locs
+}
+
+// Fail makes every call to method return err until Fail is called again
+// with a nil error.
+func (s *Server) Fail(method string, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err == nil {
+		delete(s.errs, method)
+		return
+	}
+	s.errs[method] = err
+}
+
+// Calls returns the requests received for method, oldest first, or every
+// request when method is empty.
+func (s *Server) Calls(method string) []Call {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var calls []Call
+	for _, c := range s.calls {
+		if method == "" || c.Method == method {
+			calls = append(calls, c)
+		}
+	}
+	return calls
+}
+
+// Detached reports whether a Detach request arrived and whether it asked to
+// kill the target.
+func (s *Server) Detached() (detached, kill bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.detached == nil {
+		return false, false
+	}
+	return true, *s.detached
+}
+
+// record logs a request and returns the error injected for its method.
+// It must be called with s.mu held.
+func (s *Server) record(method string, args interface{}) error {
+	s.calls = append(s.calls, Call{Method: method, Args: args})
+	return s.errs[method]
+}
+
+func (s *Server) sortedBreakpoints() []*debugger.Breakpoint {
+	bps := make([]*debugger.Breakpoint, 0, len(s.breakpoints))
+	for _, bp := range s.breakpoints {
+		bps = append(bps, bp)
+	}
+	sort.Slice(bps, func(i, j int) bool { return bps[i].ID < bps[j].ID })
+	return bps
+}
+
+func (s *Server) breakpoint(id int, name string) (*debugger.Breakpoint, error) {
+	if name != "" {
+		for _, bp := range s.breakpoints {
+			if bp.Name == name {
+				return bp, nil
+			}
+		}
+		return nil, fmt.Errorf("no breakpoint with name %s", name)
+	}
+	bp, ok := s.breakpoints[id]
+	if !ok {
+		return nil, fmt.Errorf("no breakpoint with id %d", id)
+	}
+	return bp, nil
+}
+
+// breakpointInfo gathers what bp asks to capture when it is hit on goroutine
+// 1. Expressions without a SetEval result are reported unreadable. It must be
+// called with s.mu held.
+func (s *Server) breakpointInfo(bp *debugger.Breakpoint) *debugger.BreakpointInfo {
+	if len(bp.Variables) == 0 && bp.LoadArgs == nil && bp.LoadLocals == nil && bp.Stacktrace == 0 {
+		return nil
+	}
+	info := &debugger.BreakpointInfo{}
+	for _, expr := range bp.Variables {
+		v, ok := s.evals[expr]
+		if !ok {
+			v = debugger.Variable{Unreadable: "could not find symbol value for " + expr}
+		}
+		v.Name = expr
+		info.Variables = append(info.Variables, v)
+	}
+	if bp.LoadArgs != nil {
+		info.Arguments = append(info.Arguments, s.args...)
+	}
+	if bp.LoadLocals != nil {
+		info.Locals = append(info.Locals, s.locals...)
+	}
+	if bp.Stacktrace > 0 {
+		frames, ok := s.stacks[1]
+		if !ok {
+			frames = s.stacks[-1]
+		}
+		if len(frames) > bp.Stacktrace {
+			frames = frames[:bp.Stacktrace]
+		}
+		info.Stacktrace = append(info.Stacktrace, frames...)
+	}
+	return info
+}
+
+// locationAt returns the location set with SetLocation at pc. It must be
+// called with s.mu held.
+func (s *Server) locationAt(pc uint64) (debugger.Location, bool) {
+	for _, locs := range s.locations {
+		for _, loc := range locs {
+			if loc.PC == pc {
+				return loc, true
+			}
+		}
+	}
+	return debugger.Location{}, false
+}
+
+// command runs an execution command, blocking continues as described on
+// Server.
+func (s *Server) command(cmd debugger.DebuggerCommand) (debugger.DebuggerState, error) {
+	s.mu.Lock()
+	if err := s.record("Command", cmd); err != nil {
+		s.mu.Unlock()
+		return debugger.DebuggerState{}, err
+	}
+	if cmd.Name == debugger.CmdHalt {
+		st := s.state
+		if s.running != nil {
+			s.running <- st
+			s.running = nil
+		}
+		s.mu.Unlock()
+		return st, nil
+	}
+	if s.running != nil {
+		s.mu.Unlock()
+		return debugger.DebuggerState{}, errors.New("target is running")
+	}
+	if len(s.stops) > 0 {
+		s.state = s.stops[0]
+		s.stops = s.stops[1:]
+		st := s.state
+		s.mu.Unlock()
+		return st, nil
+	}
+	if cmd.Name != debugger.CmdContinue || s.closed {
+		st := s.state
+		s.mu.Unlock()
+		return st, nil
+	}
+
+	running := make(chan debugger.DebuggerState, 1)
+	s.running = running
+	s.mu.Unlock()
+
+	st, ok := <-running
+	if !ok {
+		return debugger.DebuggerState{}, ErrClosed
+	}
+	s.mu.Lock()
+	s.state = st
+	s.mu.Unlock()
+	return st, nil
+}
+
+// service exposes Server under Delve's RPCServer method names.
+type service struct {
+	s *Server
+}
+
+func (v *service) GetVersion(args *debugger.GetVersionIn, out *debugger.GetVersionOut) error {
+	v.s.mu.Lock()
+	defer v.s.mu.Unlock()
+	if err := v.s.record("GetVersion", *args); err != nil {
+		return err
+	}
+	*out = v.s.version
+	return nil
+}
+
+func (v *service) IsMulticlient(args *debugger.IsMulticlientIn, out *debugger.IsMulticlientOut) error {
+	v.s.mu.Lock()
+	defer v.s.mu.Unlock()
+	if err := v.s.record("IsMulticlient", *args); err != nil {
+		return err
+	}
+	out.IsMulticlient = v.s.multiclient
+	return nil
+}
+
+func (v *service) State(args *debugger.StateIn, out *debugger.StateOut) error {
+	v.s.mu.Lock()
+	err := v.s.record("State", *args)
+	v.s.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	st := v.s.State()
+	out.State = &st
+	return nil
+}
+
+func (v *service) Command(args *debugger.DebuggerCommand, out *debugger.CommandOut) error {
+	st, err := v.s.command(*args)
+	if err != nil {
+		return err
+	}
+	out.State = st
+	return nil
+}
+
+func (v *service) Detach(args *debugger.DetachIn, out *debugger.DetachOut) error {
+	v.s.mu.Lock()
+	defer v.s.mu.Unlock()
+	if err := v.s.record("Detach", *args); err != nil {
+		return err
+	}
+	if v.s.running != nil {
+		// Delve waits for the target to stop before it detaches.
+		return errors.New("cannot detach while the target is running")
+	}
+	kill := args.Kill
+	v.s.detached = &kill
+	return nil
+}
+
+func (v *service) Restart(args *debugger.RestartIn, out *debugger.RestartOut) error {
+	v.s.mu.Lock()
+	defer v.s.mu.Unlock()
+	if err := v.s.record("Restart", *args); err != nil {
+		return err
+	}
+	if v.s.running != nil {
+		return errors.New("cannot restart process while it is running")
+	}
+	for _, bp := range v.s.sortedBreakpoints() {
+		reason, ok := v.s.discards[bp.ID]
+		if !ok {
+			bp.HitCount = make(map[string]uint64)
+			bp.TotalHitCount = 0
+			continue
+		}
+		delete(v.s.breakpoints, bp.ID)
+		out.DiscardedBreakpoints = append(out.DiscardedBreakpoints, debugger.DiscardedBreakpoint{Breakpoint: bp, Reason: reason})
+	}
+	v.s.discards = make(map[int]string)
+	v.s.stops = nil
+	v.s.state.Exited = false
+	v.s.state.ExitStatus = 0
+	v.s.state.CurrentThread = nil
+	v.s.state.SelectedGoroutine = nil
+	return nil
+}
+
+func (v *service) CreateBreakpoint(args *debugger.CreateBreakpointIn, out *debugger.CreateBreakpointOut) error {
+	v.s.mu.Lock()
+	defer v.s.mu.Unlock()
+	if err := v.s.record("CreateBreakpoint", *args); err != nil {
+		return err
+	}
+	bp := args.Breakpoint
+	switch {
+	case bp.Addr != 0:
+		loc, ok := v.s.locationAt(bp.Addr)
+		if !ok {
+			return fmt.Errorf("could not find location at %#x", bp.Addr)
+		}
+		for _, other := range v.s.breakpoints {
+			if other.Addr == bp.Addr {
+				return fmt.Errorf("Breakpoint exists at %s:%d at %#x", other.File, other.Line, other.Addr)
+			}
+		}
+		bp.File, bp.Line = loc.File, loc.Line
+		if loc.Function != nil {
+			bp.FunctionName = loc.Function.Name
+		}
+		bp.ExprString = args.LocExpr
+	case bp.File == "" && bp.FunctionName == "":
+		return errors.New("invalid location: no file, function or location expression")
+	}
+	if bp.Name != "" {
+		if _, err := v.s.breakpoint(0, bp.Name); err == nil {
+			return fmt.Errorf("breakpoint name %q already exists", bp.Name)
+		}
+	}
+	bp.ID = v.s.nextID
+	v.s.nextID++
+	if bp.Addr == 0 {
+		bp.Addr = uint64(0x401000 + 0x10*bp.ID)
+	}
+	bp.Addrs = []uint64{bp.Addr}
+	bp.HitCount = make(map[string]uint64)
+	v.s.breakpoints[bp.ID] = &bp
+	out.Breakpoint = bp
+	return nil
+}
+
+func (v *service) CreateWatchpoint(args *debugger.CreateWatchpointIn, out *debugger.CreateWatchpointOut) error {
+	v.s.mu.Lock()
+	defer v.s.mu.Unlock()
+	if err := v.s.record("CreateWatchpoint", *args); err != nil {
+		return err
+	}
+	val, ok := v.s.evals[args.Expr]
+	if !ok {
+		return fmt.Errorf("could not find symbol value for %s", args.Expr)
+	}
+	if val.Addr == 0 {
+		return fmt.Errorf("can not watch %s: not addressable", args.Expr)
+	}
+	bp := &debugger.Breakpoint{
+		ID:        v.s.nextID,
+		Addr:      val.Addr,
+		Addrs:     []uint64{val.Addr},
+		WatchExpr: args.Expr,
+		WatchType: args.Type,
+		HitCount:  make(map[string]uint64),
+	}
+	v.s.nextID++
+	v.s.breakpoints[bp.ID] = bp
+	c := *bp
+	out.Breakpoint = &c
+	return nil
+}
+
+func (v *service) FindLocation(args *debugger.FindLocationIn, out *debugger.FindLocationOut) error {
+	v.s.mu.Lock()
+	defer v.s.mu.Unlock()
+	if err := v.s.record("FindLocation", *args); err != nil {
+		return err
+	}
+	locs, ok := v.s.locations[args.Loc]
+	if !ok {
+		return fmt.Errorf("location %q not found", args.Loc)
+	}
+	out.Locations = append(out.Locations, locs...)
+	return nil
+}
+
+func (v *service) ClearBreakpoint(args *debugger.ClearBreakpointIn, out *debugger.ClearBreakpointOut) error {
+	v.s.mu.Lock()
+	defer v.s.mu.Unlock()
+	if err := v.s.record("ClearBreakpoint", *args); err != nil {
+		return err
+	}
+	bp, err := v.s.breakpoint(args.Id, args.Name)
+	if err != nil {
+		return err
+	}
+	delete(v.s.breakpoints, bp.ID)
+	out.Breakpoint = bp
+	return nil
+}
+
+func (v *service) GetBreakpoint(args *debugger.GetBreakpointIn, out *debugger.GetBreakpointOut) error {
+	v.s.mu.Lock()
+	defer v.s.mu.Unlock()
+	if err := v.s.record("GetBreakpoint", *args); err != nil {
+		return err
+	}
+	bp, err := v.s.breakpoint(args.Id, args.Name)
+	if err != nil {
+		return err
+	}
+	out.Breakpoint = *bp
+	return nil
+}
+
+func (v *service) ListBreakpoints(args *debugger.ListBreakpointsIn, out *debugger.ListBreakpointsOut) error {
+	v.s.mu.Lock()
+	defer v.s.mu.Unlock()
+	if err := v.s.record("ListBreakpoints", *args); err != nil {
+		return err
+	}
+	for _, bp := range v.s.sortedBreakpoints() {
+		c := *bp
+		out.Breakpoints = append(out.Breakpoints, &c)
+	}
+	return nil
+}
+
+func (v *service) ToggleBreakpoint(args *debugger.ToggleBreakpointIn, out *debugger.ToggleBreakpointOut) error {
+	v.s.mu.Lock()
+	defer v.s.mu.Unlock()
+	if err := v.s.record("ToggleBreakpoint", *args); err != nil {
+		return err
+	}
+	bp, err := v.s.breakpoint(args.Id, args.Name)
+	if err != nil {
+		return err
+	}
+	bp.Disabled = !bp.Disabled
+	c := *bp
+	out.Breakpoint = &c
+	return nil
+}
+
+func (v *service) AmendBreakpoint(args *debugger.AmendBreakpointIn, out *debugger.AmendBreakpointOut) error {
+	v.s.mu.Lock()
+	defer v.s.mu.Unlock()
+	if err := v.s.record("AmendBreakpoint", *args); err != nil {
+		return err
+	}
+	bp, err := v.s.breakpoint(args.Breakpoint.ID, "")
+	if err != nil {
+		return err
+	}
+	amended := args.Breakpoint
+	amended.Addr, amended.Addrs = bp.Addr, bp.Addrs
+	amended.File, amended.Line, amended.FunctionName = bp.File, bp.Line, bp.FunctionName
+	amended.HitCount, amended.TotalHitCount = bp.HitCount, bp.TotalHitCount
+	*bp = amended
+	return nil
+}
+
+func (v *service) Stacktrace(args *debugger.StacktraceIn, out *debugger.StacktraceOut) error {
+	v.s.mu.Lock()
+	defer v.s.mu.Unlock()
+	if err := v.s.record("Stacktrace", *args); err != nil {
+		return err
+	}
+	id := args.Id
+	if id == -1 && v.s.state.SelectedGoroutine != nil {
+		id = v.s.state.SelectedGoroutine.ID
+	}
+	frames, ok := v.s.stacks[id]
+	if !ok {
+		frames, ok = v.s.stacks[-1]
+	}
+	if !ok {
+		return errors.New("unknown goroutine " + strconv.FormatInt(args.Id, 10))
+	}
+	if args.Depth >= 0 && len(frames) > args.Depth+1 {
+		frames = frames[:args.Depth+1]
+	}
+	for _, f := range frames {
+		if !args.Full {
+			f.Locals, f.Arguments = nil, nil
+		}
+		if args.Opts&debugger.StacktraceReadDefers == 0 {
+			f.Defers = nil
+		}
+		out.Locations = append(out.Locations, f)
+	}
+	return nil
+}
+
+func (v *service) ListGoroutines(args *debugger.ListGoroutinesIn, out *debugger.ListGoroutinesOut) error {
+	v.s.mu.Lock()
+	defer v.s.mu.Unlock()
+	if err := v.s.record("ListGoroutines", *args); err != nil {
+		return err
+	}
+	gs := v.s.goroutines
+	start := args.Start
+	if start > len(gs) {
+		start = len(gs)
+	}
+	end := len(gs)
+	if args.Count > 0 && start+args.Count < end {
+		end = start + args.Count
+	}
+	out.Goroutines = gs[start:end]
+	out.Nextg = -1
+	if end < len(gs) {
+		out.Nextg = end
+	}
+	return nil
+}
+
+func (v *service) ListLocalVars(args *debugger.ListLocalVarsIn, out *debugger.ListLocalVarsOut) error {
+	v.s.mu.Lock()
+	defer v.s.mu.Unlock()
+	if err := v.s.record("ListLocalVars", *args); err != nil {
+		return err
+	}
+	out.Variables = v.s.locals
+	return nil
+}
+
+func (v *service) ListFunctionArgs(args *debugger.ListFunctionArgsIn, out *debugger.ListFunctionArgsOut) error {
+	v.s.mu.Lock()
+	defer v.s.mu.Unlock()
+	if err := v.s.record("ListFunctionArgs", *args); err != nil {
+		return err
+	}
+	out.Args = v.s.args
+	return nil
+}
+
+func (v *service) Eval(args *debugger.EvalIn, out *debugger.EvalOut) error {
+	v.s.mu.Lock()
+	defer v.s.mu.Unlock()
+	if err := v.s.record("Eval", *args); err != nil {
+		return err
+	}
+	val, ok := v.s.evals[args.Expr]
+	if !ok {
+		return fmt.Errorf("could not find symbol value for %s", args.Expr)
+	}
+	out.Variable = &val
+	return nil
+}
@@ -0,0 +1,137 @@
+package debuggertest
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kjbreil/dlc-sidecar/internal/debugger"
+)
+
+func TestServer_Breakpoints(t *testing.T) {
+	s := NewServer(t)
+	pool := debugger.NewPool(s.Addr)
+	defer pool.Close()
+	ctx := context.Background()
+
+	bp, err := pool.CreateBreakpoint(ctx, &debugger.Breakpoint{File: "main.go", Line: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bp.ID != 1 || bp.Addr == 0 {
+		t.Errorf("created = %+v, want ID 1 with an address", bp)
+	}
+	if _, err := pool.CreateBreakpoint(ctx, &debugger.Breakpoint{}); err == nil {
+		t.Error("expected an error for a breakpoint without a location")
+	}
+
+	toggled, err := pool.ToggleBreakpoint(ctx, bp.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !toggled.Disabled {
+		t.Error("expected toggle to disable the breakpoint")
+	}
+
+	if _, err := pool.ClearBreakpoint(ctx, 7); err == nil || !strings.Contains(err.Error(), "no breakpoint with id 7") {
+		t.Errorf("clear unknown: err = %v", err)
+	}
+	if _, err := pool.ClearBreakpoint(ctx, bp.ID); err != nil {
+		t.Fatal(err)
+	}
+	if bps := s.Breakpoints(); len(bps) != 0 {
+		t.Errorf("breakpoints after clear = %+v", bps)
+	}
+}
+
+func TestServer_ContinueBlocksUntilStop(t *testing.T) {
+	s := NewServer(t)
+	pool := debugger.NewPool(s.Addr)
+	defer pool.Close()
+	ctx := context.Background()
+
+	if _, err := pool.CreateBreakpoint(ctx, &debugger.Breakpoint{File: "main.go", Line: 10}); err != nil {
+		t.Fatal(err)
+	}
+	r, err := pool.Start(debugger.DebuggerCommand{Name: debugger.CmdContinue})
+	if err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, s.Running)
+
+	s.HitBreakpoint(1)
+	st, err := r.Wait(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.CurrentThread == nil || st.CurrentThread.Breakpoint == nil || st.CurrentThread.Breakpoint.ID != 1 {
+		t.Fatalf("state = %+v, want stop at breakpoint 1", st)
+	}
+	if got := s.Breakpoints()[0].TotalHitCount; got != 1 {
+		t.Errorf("TotalHitCount = %d, want 1", got)
+	}
+}
+
+func TestServer_HaltReleasesContinue(t *testing.T) {
+	s := NewServer(t)
+	pool := debugger.NewPool(s.Addr)
+	defer pool.Close()
+
+	r, err := pool.Start(debugger.DebuggerCommand{Name: debugger.CmdContinue})
+	if err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, s.Running)
+
+	st, err := pool.State(context.Background(), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !st.Running {
+		t.Error("expected a non-blocking State to report the target running")
+	}
+	if _, err := pool.Halt(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestServer_Fail(t *testing.T) {
+	s := NewServer(t)
+	pool := debugger.NewPool(s.Addr)
+	defer pool.Close()
+	ctx := context.Background()
+
+	s.Fail("Eval", errors.New("boom"))
+	if _, err := pool.Eval(ctx, debugger.EvalScope{GoroutineID: -1}, "x", nil); err == nil || !strings.Contains(err.Error(), "boom") {
+		t.Errorf("err = %v, want injected error", err)
+	}
+	s.Fail("Eval", nil)
+	s.SetEval("x", debugger.Variable{Name: "x", Value: "1"})
+	v, err := pool.Eval(ctx, debugger.EvalScope{GoroutineID: -1}, "x", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.Value != "1" {
+		t.Errorf("value = %q, want 1", v.Value)
+	}
+	if n := len(s.Calls("Eval")); n != 2 {
+		t.Errorf("recorded %d Eval calls, want 2", n)
+	}
+}
+
+// waitFor polls cond until it holds or the test times out.
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
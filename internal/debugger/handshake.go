@@ -0,0 +1,124 @@
+package debugger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// APIVersion is the Delve JSON-RPC API version the sidecar speaks.
+const APIVersion = 2
+
+// handshakeTimeout bounds the version checks made on a fresh connection.
+const handshakeTimeout = 5 * time.Second
+
+// ErrUnsupportedAPI is returned when the server is not a Delve API v2 server.
+var ErrUnsupportedAPI = errors.New("unsupported Delve API version")
+
+// ServerInfo describes the Delve server a pool is connected to, as learned
+// from the handshake on the most recent connection.
+type ServerInfo struct {
+	DelveVersion            string    `json:"delveVersion"`
+	APIVersion              int       `json:"apiVersion"`
+	Backend                 string    `json:"backend"`
+	TargetGoVersion         string    `json:"targetGoVersion,omitempty"`
+	MinSupportedVersionOfGo string    `json:"minSupportedGoVersion,omitempty"`
+	MaxSupportedVersionOfGo string    `json:"maxSupportedGoVersion,omitempty"`
+	Multiclient             bool      `json:"multiclient"`
+	Warnings                []string  `json:"warnings,omitempty"`
+	ConnectedAt             time.Time `json:"connectedAt"`
+}
+
+// handshake checks the API version of a freshly dialed server and records
+// what it reports. It refuses servers that do not speak API v2.
+func (p *Pool) handshake(c *Client) error {
+	ctx, cancel := context.WithTimeout(context.Background(), handshakeTimeout)
+	defer cancel()
+
+	var version GetVersionOut
+	if err := c.CallContext(ctx, "GetVersion", GetVersionIn{}, &version); err != nil {
+		return fmt.Errorf("handshake: GetVersion: %w (is %s a Delve server started with --api-version=2?)", err, p.addr)
+	}
+	if version.APIVersion != APIVersion {
+		return fmt.Errorf("%w %d (Delve %s); restart Delve with --api-version=%d",
+			ErrUnsupportedAPI, version.APIVersion, version.DelveVersion, APIVersion)
+	}
+
+	info := &ServerInfo{
+		DelveVersion:            version.DelveVersion,
+		APIVersion:              version.APIVersion,
+		Backend:                 version.Backend,
+		TargetGoVersion:         version.TargetGoVersion,
+		MinSupportedVersionOfGo: version.MinSupportedVersionOfGo,
+		MaxSupportedVersionOfGo: version.MaxSupportedVersionOfGo,
+		ConnectedAt:             time.Now(),
+	}
+
+	var multi IsMulticlientOut
+	if err := c.CallContext(ctx, "IsMulticlient", IsMulticlientIn{}, &multi); err != nil {
+		info.Warnings = append(info.Warnings, fmt.Sprintf("IsMulticlient failed: %v", err))
+	} else {
+		info.Multiclient = multi.IsMulticlient
+	}
+	if !info.Multiclient {
+		info.Warnings = append(info.Warnings,
+			"Delve was not started with --accept-multiclient; halt cannot interrupt a running continue")
+	}
+
+	p.infoMu.Lock()
+	p.info = info
+	p.infoMu.Unlock()
+	return nil
+}
+
+// Info returns what the server reported during the most recent handshake, or
+// nil if the pool has not connected yet.
+func (p *Pool) Info() *ServerInfo {
+	p.infoMu.Lock()
+	defer p.infoMu.Unlock()
+	if p.info == nil {
+		return nil
+	}
+	info := *p.info
+	return &info
+}
+
+// Connect dials the main connection if needed, performing the handshake.
+func (p *Pool) Connect() error {
+	if _, err := p.dial(&p.main); err != nil {
+		return fmt.Errorf("connect to %s: %w", p.addr, err)
+	}
+	return nil
+}
+
+// OnConnect registers fn to be called each time the main connection is
+// established, after the handshake and before the call that dialed it
+// proceeds. fn may use the pool; connections it dials do not run the
+// callbacks again.
+func (p *Pool) OnConnect(fn func()) {
+	p.infoMu.Lock()
+	defer p.infoMu.Unlock()
+	p.onConnect = append(p.onConnect, fn)
+}
+
+// connected runs the OnConnect callbacks unless they are already running.
+func (p *Pool) connected() {
+	p.infoMu.Lock()
+	if p.connecting {
+		p.infoMu.Unlock()
+		return
+	}
+	p.connecting = true
+	callbacks := append([]func(){}, p.onConnect...)
+	p.infoMu.Unlock()
+
+	defer func() {
+		p.infoMu.Lock()
+		p.connecting = false
+		p.infoMu.Unlock()
+	}()
+	for _, fn := range callbacks {
+		fn()
+	}
+}
@@ -0,0 +1,96 @@
+package debugger
+
+import (
+	"errors"
+	"testing"
+)
+
+// versionService reports a configurable API version and multiclient mode.
+type versionService struct {
+	apiVersion  int
+	multiclient bool
+}
+
+func (v *versionService) GetVersion(args *GetVersionIn, out *GetVersionOut) error {
+	out.DelveVersion = "Version: 1.25.0"
+	out.APIVersion = v.apiVersion
+	out.Backend = "core"
+	out.TargetGoVersion = "go1.24.2"
+	return nil
+}
+
+func (v *versionService) IsMulticlient(args *IsMulticlientIn, out *IsMulticlientOut) error {
+	out.IsMulticlient = v.multiclient
+	return nil
+}
+
+func (v *versionService) State(args *StateIn, out *StateOut) error {
+	out.State = &DebuggerState{Pid: 42}
+	return nil
+}
+
+func TestPool_HandshakeRecordsInfo(t *testing.T) {
+	pool := NewPool(serveRPC(t, &versionService{apiVersion: 2, multiclient: true}))
+	defer pool.Close()
+
+	if pool.Info() != nil {
+		t.Fatal("Info should be nil before connecting")
+	}
+	if err := pool.Connect(); err != nil {
+		t.Fatal(err)
+	}
+
+	info := pool.Info()
+	if info == nil {
+		t.Fatal("Info should be set after connecting")
+	}
+	if info.DelveVersion != "Version: 1.25.0" || info.Backend != "core" || info.TargetGoVersion != "go1.24.2" {
+		t.Fatalf("unexpected info: %+v", info)
+	}
+	if !info.Multiclient || len(info.Warnings) != 0 {
+		t.Fatalf("expected multiclient without warnings, got %+v", info)
+	}
+}
+
+func TestPool_HandshakeRejectsAPIVersion(t *testing.T) {
+	pool := NewPool(serveRPC(t, &versionService{apiVersion: 1, multiclient: true}))
+	defer pool.Close()
+
+	var out StateOut
+	err := pool.Call("State", StateIn{}, &out)
+	if !errors.Is(err, ErrUnsupportedAPI) {
+		t.Fatalf("expected ErrUnsupportedAPI, got %v", err)
+	}
+}
+
+func TestPool_HandshakeRejectsNonDelve(t *testing.T) {
+	// commandOnly has no GetVersion method, so it cannot be a Delve server.
+	pool := NewPool(serveRPC(t, commandOnly{}))
+	defer pool.Close()
+	if err := pool.Connect(); err == nil {
+		t.Fatal("expected handshake error")
+	}
+}
+
+type commandOnly struct{}
+
+func (commandOnly) Command(cmd *DebuggerCommand, out *CommandOut) error { return nil }
+
+func TestPool_ControlFallsBackWithoutMulticlient(t *testing.T) {
+	pool := NewPool(serveRPC(t, &versionService{apiVersion: 2}))
+	defer pool.Close()
+
+	var out StateOut
+	if err := pool.Control(t.Context(), "State", StateIn{NonBlocking: true}, &out); err != nil {
+		t.Fatal(err)
+	}
+	if out.State == nil || out.State.Pid != 42 {
+		t.Fatalf("unexpected state: %+v", out.State)
+	}
+	if pool.control.client != nil {
+		t.Fatal("control connection should not be dialed when Delve is not multiclient")
+	}
+	if info := pool.Info(); info == nil || len(info.Warnings) == 0 {
+		t.Fatal("expected a multiclient warning")
+	}
+}
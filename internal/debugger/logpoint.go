@@ -0,0 +1,255 @@
+package debugger
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultLogBufferSize is the number of logpoint lines a session retains.
+const DefaultLogBufferSize = 1000
+
+// LogTemplate is a logpoint message whose {expression} placeholders are
+// replaced by the values of their expressions on every hit.
+type LogTemplate struct {
+	Message string
+	// text holds the literal text around the placeholders, one element more
+	// than refs, which index exprs.
+	text  []string
+	refs  []int
+	exprs []string
+}
+
+// ParseLogTemplate parses a message with {expression} placeholders, such as
+// "user={u.ID} n={len(items)}". As in Delve's DAP server, braces inside a
+// placeholder nest, so it may hold a composite literal, and every brace must
+// be paired.
+func ParseLogTemplate(msg string) (*LogTemplate, error) {
+	t := &LogTemplate{Message: msg}
+	index := make(map[string]int)
+	var text strings.Builder
+	depth, start := 0, 0
+	for i, r := range msg {
+		switch {
+		case r == '{':
+			if depth == 0 {
+				start = i + 1
+			}
+			depth++
+		case r == '}' && depth == 0:
+			return nil, fmt.Errorf("invalid log message %q: unexpected } at offset %d", msg, i)
+		case r == '}':
+			depth--
+			if depth > 0 {
+				continue
+			}
+			expr := strings.TrimSpace(msg[start:i])
+			if expr == "" {
+				return nil, fmt.Errorf("invalid log message %q: empty {} at offset %d", msg, start-1)
+			}
+			n, ok := index[expr]
+			if !ok {
+				n = len(t.exprs)
+				index[expr] = n
+				t.exprs = append(t.exprs, expr)
+			}
+			t.text = append(t.text, text.String())
+			t.refs = append(t.refs, n)
+			text.Reset()
+		case depth == 0:
+			text.WriteRune(r)
+		}
+	}
+	if depth > 0 {
+		return nil, fmt.Errorf("invalid log message %q: unclosed { at offset %d", msg, start-1)
+	}
+	t.text = append(t.text, text.String())
+	return t, nil
+}
+
+// Expressions returns the distinct expressions the template interpolates,
+// in the order they first appear. A logpoint's tracepoint evaluates these.
+func (t *LogTemplate) Expressions() []string {
+	return append([]string(nil), t.exprs...)
+}
+
+// Render fills in the template with the values a tracepoint captured for
+// its expressions. Values are matched by expression, or by position when
+// Delve reports the expressions in another form.
+func (t *LogTemplate) Render(vars []Variable) string {
+	values := make([]string, len(t.exprs))
+	for i, expr := range t.exprs {
+		values[i] = "<not captured>"
+		if v := capturedValue(vars, expr, i, len(t.exprs)); v != nil {
+			values[i] = formatValue(v)
+		}
+	}
+
+	var b strings.Builder
+	for i, ref := range t.refs {
+		b.WriteString(t.text[i])
+		b.WriteString(values[ref])
+	}
+	b.WriteString(t.text[len(t.text)-1])
+	return b.String()
+}
+
+// capturedValue finds the value of the i-th of n expressions in vars.
+func capturedValue(vars []Variable, expr string, i, n int) *Variable {
+	for j := range vars {
+		if vars[j].Name == expr {
+			return &vars[j]
+		}
+	}
+	if len(vars) == n {
+		return &vars[i]
+	}
+	return nil
+}
+
+// LogLine is a message a logpoint printed.
+type LogLine struct {
+	// Seq numbers lines in the order they were recorded, starting at 1.
+	Seq          int64
+	At           time.Time
+	BreakpointID int
+	GoroutineID  int64
+	File         string
+	Line         int
+	Function     string
+	Message      string
+}
+
+// LogBuffer knows the templates of a session's logpoints and retains the
+// most recent lines they printed. It is safe for concurrent use.
+type LogBuffer struct {
+	mu        sync.Mutex
+	size      int
+	templates map[int]*LogTemplate
+	lines     []LogLine
+	seq       int64
+	dropped   int64
+}
+
+// NewLogBuffer creates a buffer holding up to size lines, or
+// DefaultLogBufferSize if size is not positive.
+func NewLogBuffer(size int) *LogBuffer {
+	if size <= 0 {
+		size = DefaultLogBufferSize
+	}
+	return &LogBuffer{size: size, templates: make(map[int]*LogTemplate)}
+}
+
+// SetTemplate makes the tracepoint with the given ID a logpoint printing t.
+func (b *LogBuffer) SetTemplate(id int, t *LogTemplate) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.templates[id] = t
+}
+
+// Template returns the template of the logpoint with the given ID.
+func (b *LogBuffer) Template(id int) (*LogTemplate, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	t, ok := b.templates[id]
+	return t, ok
+}
+
+// RemoveTemplate forgets the logpoint with the given ID. Its lines are kept.
+func (b *LogBuffer) RemoveTemplate(id int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.templates, id)
+}
+
+// Record renders and retains the line for h if it is a logpoint hit, and
+// reports whether it was. The oldest line is dropped when the buffer is
+// full.
+func (b *LogBuffer) Record(h TraceHit) bool {
+	if h.Breakpoint == nil {
+		return false
+	}
+	t, ok := b.Template(h.Breakpoint.ID)
+	if !ok {
+		return false
+	}
+	var vars []Variable
+	if h.Info != nil {
+		vars = h.Info.Variables
+	}
+	line := LogLine{
+		At:           h.At,
+		BreakpointID: h.Breakpoint.ID,
+		GoroutineID:  h.GoroutineID,
+		File:         h.File,
+		Line:         h.Line,
+		Function:     h.Function,
+		Message:      t.Render(vars),
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.seq++
+	line.Seq = b.seq
+	if len(b.lines) == b.size {
+		b.lines = append(b.lines[:0], b.lines[1:]...)
+		b.dropped++
+	}
+	b.lines = append(b.lines, line)
+	return true
+}
+
+// Lines returns the retained lines matching f, oldest first.
+func (b *LogBuffer) Lines(f TraceFilter) []LogLine {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var out []LogLine
+	for _, l := range b.lines {
+		if l.Seq <= f.After {
+			continue
+		}
+		if f.BreakpointID != 0 && l.BreakpointID != f.BreakpointID {
+			continue
+		}
+		if f.GoroutineID != 0 && l.GoroutineID != f.GoroutineID {
+			continue
+		}
+		out = append(out, l)
+	}
+	if f.Limit > 0 && len(out) > f.Limit {
+		out = out[len(out)-f.Limit:]
+	}
+	return out
+}
+
+// Dropped returns how many lines were discarded because the buffer was full.
+func (b *LogBuffer) Dropped() int64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.dropped
+}
+
+// Inherit takes over the lines retained by prev, with their sequence
+// numbers, for a session that replaces prev's. Templates are not copied,
+// since the logpoints get new IDs. It is called before b records a line.
+func (b *LogBuffer) Inherit(prev *LogBuffer) {
+	prev.mu.Lock()
+	lines, seq, dropped := append([]LogLine(nil), prev.lines...), prev.seq, prev.dropped
+	prev.mu.Unlock()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if over := len(lines) - b.size; over > 0 {
+		lines, dropped = lines[over:], dropped+int64(over)
+	}
+	b.lines, b.seq, b.dropped = lines, seq, dropped
+}
+
+// Clear discards every retained line. Sequence numbers keep increasing.
+func (b *LogBuffer) Clear() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.lines = nil
+	b.dropped = 0
+}
@@ -0,0 +1,113 @@
+package debugger
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseLogTemplate(t *testing.T) {
+	tmpl, err := ParseLogTemplate("user={u.ID} n={ len(items) } again {u.ID} p={T{X: 1}.X}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := tmpl.Expressions(), []string{"u.ID", "len(items)", "T{X: 1}.X"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Expressions() = %q, want %q", got, want)
+	}
+
+	for msg, want := range map[string]string{
+		"a } b":   "unexpected } at offset 2",
+		"n={n":    "unclosed { at offset 2",
+		"n={ }":   "empty {} at offset 2",
+		"{a{b}":   "unclosed {",
+		"no args": "",
+	} {
+		_, err := ParseLogTemplate(msg)
+		if want == "" {
+			if err != nil {
+				t.Errorf("ParseLogTemplate(%q) = %v", msg, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("ParseLogTemplate(%q) = %v, want %q", msg, err, want)
+		}
+	}
+}
+
+func TestLogTemplate_Render(t *testing.T) {
+	tmpl, err := ParseLogTemplate("user={u.ID} name={u.Name} again {u.ID}!")
+	if err != nil {
+		t.Fatal(err)
+	}
+	vars := []Variable{
+		{Name: "u.Name", Type: "string", Kind: int(reflect.String), Value: "ann", Len: 3},
+		{Name: "u.ID", Type: "int", Kind: int(reflect.Int), Value: "7"},
+	}
+	if got, want := tmpl.Render(vars), `user=7 name="ann" again 7!`; got != want {
+		t.Errorf("Render = %q, want %q", got, want)
+	}
+	// Values Delve names differently are matched by position.
+	vars = []Variable{
+		{Name: "(u).ID", Type: "int", Kind: int(reflect.Int), Value: "7"},
+		{Name: "(u).Name", Type: "string", Kind: int(reflect.String), Value: "ann", Len: 3},
+	}
+	if got, want := tmpl.Render(vars), `user=7 name="ann" again 7!`; got != want {
+		t.Errorf("Render by position = %q, want %q", got, want)
+	}
+	if got, want := tmpl.Render(nil), "user=<not captured> name=<not captured> again <not captured>!"; got != want {
+		t.Errorf("Render(nil) = %q, want %q", got, want)
+	}
+}
+
+func TestLogBuffer(t *testing.T) {
+	b := NewLogBuffer(2)
+	tmpl, err := ParseLogTemplate("n={n}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.SetTemplate(1, tmpl)
+
+	if b.Record(TraceHit{Breakpoint: &Breakpoint{ID: 2}}) {
+		t.Error("a tracepoint without a template was recorded as a logpoint")
+	}
+	for i := 1; i <= 3; i++ {
+		ok := b.Record(TraceHit{
+			Breakpoint:  &Breakpoint{ID: 1},
+			GoroutineID: int64(i),
+			Info:        &BreakpointInfo{Variables: []Variable{{Name: "n", Value: fmt.Sprint(i)}}},
+		})
+		if !ok {
+			t.Fatalf("hit %d was not recorded", i)
+		}
+	}
+	lines := b.Lines(TraceFilter{})
+	if len(lines) != 2 || b.Dropped() != 1 {
+		t.Fatalf("lines = %+v, dropped %d", lines, b.Dropped())
+	}
+	if l := lines[1]; l.Seq != 3 || l.Message != "n=3" || l.BreakpointID != 1 || l.GoroutineID != 3 {
+		t.Errorf("last line = %+v", l)
+	}
+	if got := b.Lines(TraceFilter{GoroutineID: 2}); len(got) != 1 || got[0].Message != "n=2" {
+		t.Errorf("goroutine 2 = %+v", got)
+	}
+
+	next := NewLogBuffer(0)
+	next.Inherit(b)
+	if got := next.Lines(TraceFilter{After: 2}); len(got) != 1 || got[0].Message != "n=3" || next.Dropped() != 1 {
+		t.Errorf("inherited = %+v, dropped %d", got, next.Dropped())
+	}
+	if _, ok := next.Template(1); ok {
+		t.Error("templates were inherited")
+	}
+
+	b.RemoveTemplate(1)
+	if b.Record(TraceHit{Breakpoint: &Breakpoint{ID: 1}}) {
+		t.Error("hit recorded after the template was removed")
+	}
+	b.Clear()
+	if len(b.Lines(TraceFilter{})) != 0 || b.Dropped() != 0 {
+		t.Error("Clear kept lines")
+	}
+}
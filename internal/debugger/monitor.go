@@ -0,0 +1,248 @@
+package debugger
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// ConnState is the health of a Pool's connection to Delve as seen by a Monitor.
+type ConnState int
+
+const (
+	// ConnUnknown means the monitor has not checked the connection yet.
+	ConnUnknown ConnState = iota
+	// ConnConnected means Delve answered the last ping.
+	ConnConnected
+	// ConnDisconnected means Delve could not be reached; the monitor keeps
+	// retrying with exponential backoff.
+	ConnDisconnected
+	// ConnTargetExited means Delve is reachable but the target has exited.
+	ConnTargetExited
+)
+
+func (s ConnState) String() string {
+	switch s {
+	case ConnConnected:
+		return "connected"
+	case ConnDisconnected:
+		return "disconnected"
+	case ConnTargetExited:
+		return "target exited"
+	}
+	return "unknown"
+}
+
+// MonitorConfig controls how often a Monitor pings Delve.
+type MonitorConfig struct {
+	// Interval is the time between pings while Delve is reachable.
+	Interval time.Duration
+	// MinBackoff and MaxBackoff bound the delay between reconnect attempts,
+	// which doubles after every failure.
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+	// PingTimeout bounds each ping.
+	PingTimeout time.Duration
+}
+
+// DefaultMonitorConfig returns the settings used by the sidecar.
+func DefaultMonitorConfig() MonitorConfig {
+	return MonitorConfig{
+		Interval:    5 * time.Second,
+		MinBackoff:  500 * time.Millisecond,
+		MaxBackoff:  30 * time.Second,
+		PingTimeout: 5 * time.Second,
+	}
+}
+
+// Transition is a change of connection state reported by a Monitor.
+type Transition struct {
+	From, To ConnState
+	At       time.Time
+	// Err is the ping failure that caused a transition to ConnDisconnected.
+	Err error
+	// Attempts is the number of failed pings before a reconnect.
+	Attempts int
+	// ExitStatus is the target's exit status on a transition to
+	// ConnTargetExited.
+	ExitStatus int
+}
+
+// Health is a snapshot of a Monitor's view of the connection.
+type Health struct {
+	State ConnState
+	// Since is when the connection entered State.
+	Since time.Time
+	// LastPing is when Delve last answered a ping.
+	LastPing time.Time
+	// Err is the last ping failure while disconnected.
+	Err error
+	// Attempts counts failed pings since the connection was lost.
+	Attempts int
+	// NextAttempt is when the next reconnect attempt is due while
+	// disconnected.
+	NextAttempt time.Time
+}
+
+// Monitor pings a Pool's Delve in the background, reconnecting with
+// exponential backoff when it becomes unreachable, and reports connection
+// state transitions to OnChange callbacks.
+type Monitor struct {
+	pool *Pool
+	cfg  MonitorConfig
+
+	mu       sync.Mutex
+	health   Health
+	onChange []func(Transition)
+	started  bool
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+// NewMonitor creates a monitor for p. Zero fields in cfg take their values
+// from DefaultMonitorConfig.
+func NewMonitor(p *Pool, cfg MonitorConfig) *Monitor {
+	def := DefaultMonitorConfig()
+	if cfg.Interval <= 0 {
+		cfg.Interval = def.Interval
+	}
+	if cfg.MinBackoff <= 0 {
+		cfg.MinBackoff = def.MinBackoff
+	}
+	if cfg.MaxBackoff <= 0 {
+		cfg.MaxBackoff = def.MaxBackoff
+	}
+	if cfg.MaxBackoff < cfg.MinBackoff {
+		cfg.MaxBackoff = cfg.MinBackoff
+	}
+	if cfg.PingTimeout <= 0 {
+		cfg.PingTimeout = def.PingTimeout
+	}
+	return &Monitor{
+		pool:   p,
+		cfg:    cfg,
+		health: Health{Since: time.Now()},
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+}
+
+// OnChange registers fn to be called, from the monitor's goroutine, on every
+// connection state transition.
+func (m *Monitor) OnChange(fn func(Transition)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.onChange = append(m.onChange, fn)
+}
+
+// Health returns the current view of the connection.
+func (m *Monitor) Health() Health {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.health
+}
+
+// Start begins pinging in the background. It pings immediately.
+func (m *Monitor) Start() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.started {
+		return
+	}
+	m.started = true
+	go m.run()
+}
+
+// Stop ends monitoring and waits for an in-progress ping to finish.
+func (m *Monitor) Stop() {
+	m.mu.Lock()
+	started := m.started
+	select {
+	case <-m.stop:
+	default:
+		close(m.stop)
+	}
+	m.mu.Unlock()
+	if started {
+		<-m.done
+	}
+}
+
+func (m *Monitor) run() {
+	defer close(m.done)
+	backoff := m.cfg.MinBackoff
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	for {
+		select {
+		case <-m.stop:
+			return
+		case <-timer.C:
+		}
+
+		delay := m.cfg.Interval
+		if !m.Check() {
+			delay = backoff
+			backoff *= 2
+			if backoff > m.cfg.MaxBackoff {
+				backoff = m.cfg.MaxBackoff
+			}
+		} else {
+			backoff = m.cfg.MinBackoff
+		}
+
+		m.mu.Lock()
+		if m.health.State == ConnDisconnected {
+			m.health.NextAttempt = time.Now().Add(delay)
+		}
+		m.mu.Unlock()
+		timer.Reset(delay)
+	}
+}
+
+// Check pings Delve once, records the result and reports any transition.
+// It returns whether Delve answered. A failed dial is retried by the pool on
+// the next ping, so reconnecting needs no extra work here.
+func (m *Monitor) Check() bool {
+	ctx, cancel := context.WithTimeout(context.Background(), m.cfg.PingTimeout)
+	defer cancel()
+	st, err := m.pool.State(ctx, true)
+	now := time.Now()
+
+	m.mu.Lock()
+	from := m.health.State
+	t := Transition{From: from, At: now}
+	switch {
+	case err != nil:
+		t.To = ConnDisconnected
+		t.Err = err
+		m.health.Err = err
+		m.health.Attempts++
+	case st != nil && st.Exited:
+		t.To = ConnTargetExited
+		t.ExitStatus = st.ExitStatus
+	default:
+		t.To = ConnConnected
+	}
+	if err == nil {
+		t.Attempts = m.health.Attempts
+		m.health.LastPing = now
+		m.health.Err = nil
+		m.health.Attempts = 0
+		m.health.NextAttempt = time.Time{}
+	}
+	changed := t.To != from
+	if changed {
+		m.health.State = t.To
+		m.health.Since = now
+	}
+	callbacks := append([]func(Transition){}, m.onChange...)
+	m.mu.Unlock()
+
+	if changed {
+		for _, fn := range callbacks {
+			fn(t)
+		}
+	}
+	return err == nil
+}
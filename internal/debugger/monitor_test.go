@@ -0,0 +1,216 @@
+package debugger
+
+import (
+	"net"
+	"net/rpc"
+	"net/rpc/jsonrpc"
+	"sync"
+	"testing"
+	"time"
+)
+
+// healthService answers the handshake and State, reporting a configurable
+// exit status.
+type healthService struct {
+	mu         sync.Mutex
+	exited     bool
+	exitStatus int
+}
+
+func (h *healthService) GetVersion(args *GetVersionIn, out *GetVersionOut) error {
+	out.APIVersion = 2
+	return nil
+}
+
+func (h *healthService) IsMulticlient(args *IsMulticlientIn, out *IsMulticlientOut) error {
+	out.IsMulticlient = true
+	return nil
+}
+
+func (h *healthService) State(args *StateIn, out *StateOut) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	out.State = &DebuggerState{Exited: h.exited, ExitStatus: h.exitStatus}
+	return nil
+}
+
+func (h *healthService) exit(status int) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.exited, h.exitStatus = true, status
+}
+
+// flakyServer serves svc on a fixed address and can be stopped, dropping its
+// connections, and started again.
+type flakyServer struct {
+	t    *testing.T
+	addr string
+	srv  *rpc.Server
+
+	mu    sync.Mutex
+	ln    net.Listener
+	conns []net.Conn
+}
+
+func newFlakyServer(t *testing.T, svc interface{}) *flakyServer {
+	t.Helper()
+	f := &flakyServer{t: t, addr: "127.0.0.1:0", srv: rpc.NewServer()}
+	if err := f.srv.RegisterName("RPCServer", svc); err != nil {
+		t.Fatal(err)
+	}
+	f.start()
+	t.Cleanup(f.stop)
+	return f
+}
+
+func (f *flakyServer) start() {
+	f.t.Helper()
+	ln, err := net.Listen("tcp", f.addr)
+	if err != nil {
+		f.t.Fatal(err)
+	}
+	f.mu.Lock()
+	f.ln = ln
+	f.addr = ln.Addr().String()
+	f.mu.Unlock()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			f.mu.Lock()
+			f.conns = append(f.conns, conn)
+			f.mu.Unlock()
+			go f.srv.ServeCodec(jsonrpc.NewServerCodec(conn))
+		}
+	}()
+}
+
+func (f *flakyServer) stop() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.ln != nil {
+		f.ln.Close()
+		f.ln = nil
+	}
+	for _, c := range f.conns {
+		c.Close()
+	}
+	f.conns = nil
+}
+
+func fastMonitorConfig() MonitorConfig {
+	return MonitorConfig{
+		Interval:    10 * time.Millisecond,
+		MinBackoff:  5 * time.Millisecond,
+		MaxBackoff:  20 * time.Millisecond,
+		PingTimeout: time.Second,
+	}
+}
+
+// collectTransitions records a monitor's transitions on a channel.
+func collectTransitions(m *Monitor) <-chan Transition {
+	ch := make(chan Transition, 16)
+	m.OnChange(func(t Transition) { ch <- t })
+	return ch
+}
+
+func nextTransition(t *testing.T, ch <-chan Transition) Transition {
+	t.Helper()
+	select {
+	case tr := <-ch:
+		return tr
+	case <-time.After(5 * time.Second):
+		t.Fatal("no transition")
+	}
+	return Transition{}
+}
+
+func TestMonitor_DisconnectAndReconnect(t *testing.T) {
+	srv := newFlakyServer(t, &healthService{})
+	pool := NewPool(srv.addr)
+	defer pool.Close()
+
+	m := NewMonitor(pool, fastMonitorConfig())
+	ch := collectTransitions(m)
+	m.Start()
+	defer m.Stop()
+
+	tr := nextTransition(t, ch)
+	if tr.From != ConnUnknown || tr.To != ConnConnected {
+		t.Fatalf("first transition = %v -> %v, want unknown -> connected", tr.From, tr.To)
+	}
+
+	srv.stop()
+	tr = nextTransition(t, ch)
+	if tr.To != ConnDisconnected || tr.Err == nil {
+		t.Fatalf("transition = %+v, want disconnected with an error", tr)
+	}
+	time.Sleep(50 * time.Millisecond)
+	h := m.Health()
+	if h.State != ConnDisconnected || h.Attempts < 2 || h.NextAttempt.IsZero() {
+		t.Errorf("health while down = %+v, want repeated attempts", h)
+	}
+
+	srv.start()
+	tr = nextTransition(t, ch)
+	if tr.From != ConnDisconnected || tr.To != ConnConnected || tr.Attempts < 2 {
+		t.Fatalf("transition = %+v, want reconnect after several attempts", tr)
+	}
+	if h := m.Health(); h.Err != nil || h.Attempts != 0 || h.LastPing.IsZero() {
+		t.Errorf("health after reconnect = %+v", h)
+	}
+}
+
+func TestMonitor_TargetExited(t *testing.T) {
+	svc := &healthService{}
+	pool := NewPool(serveRPC(t, svc))
+	defer pool.Close()
+
+	m := NewMonitor(pool, fastMonitorConfig())
+	ch := collectTransitions(m)
+	m.Start()
+	defer m.Stop()
+
+	nextTransition(t, ch)
+	svc.exit(3)
+	tr := nextTransition(t, ch)
+	if tr.To != ConnTargetExited || tr.ExitStatus != 3 {
+		t.Fatalf("transition = %+v, want target exited with status 3", tr)
+	}
+
+	select {
+	case tr := <-ch:
+		t.Errorf("unexpected repeated transition %+v", tr)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestMonitor_Backoff(t *testing.T) {
+	pool := NewPool("127.0.0.1:1")
+	defer pool.Close()
+
+	m := NewMonitor(pool, MonitorConfig{
+		Interval:    time.Hour,
+		MinBackoff:  10 * time.Millisecond,
+		MaxBackoff:  40 * time.Millisecond,
+		PingTimeout: time.Second,
+	})
+	m.Start()
+	time.Sleep(200 * time.Millisecond)
+	m.Stop()
+
+	// Delays of 10, 20, 40, 40, ... ms allow only a handful of attempts.
+	if a := m.Health().Attempts; a < 3 || a > 8 {
+		t.Errorf("attempts = %d, want backoff between 10ms and 40ms", a)
+	}
+}
+
+func TestMonitor_StopWithoutStart(t *testing.T) {
+	m := NewMonitor(NewPool("127.0.0.1:1"), MonitorConfig{})
+	m.Stop()
+	if m.cfg != DefaultMonitorConfig() {
+		t.Errorf("zero config = %+v, want defaults", m.cfg)
+	}
+}
@@ -1,87 +1,296 @@
 package debugger
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	netrpc "net/rpc"
 	"sync"
+	"time"
 )
 
+// DefaultTimeout bounds RPC calls whose method has no timeout of its own.
+const DefaultTimeout = 30 * time.Second
+
+// methodTimeouts holds the default deadline for individual RPC methods. It is
+// applied only when the caller's context does not already carry a deadline.
+// Command gets a long deadline because continue blocks until the target stops.
+var methodTimeouts = map[string]time.Duration{
+	"State":   5 * time.Second,
+	"Eval":    10 * time.Second,
+	"Command": 10 * time.Minute,
+	"Restart": 10 * time.Minute,
+}
+
+// noRetry holds the methods that are not resent on a fresh connection after
+// a transport failure. The first attempt may already have reached Delve and
+// moved the target, so the caller gets the error and can query State instead.
+var noRetry = map[string]bool{
+	"Command": true,
+	"Restart": true,
+}
+
+// timeoutFor returns the default deadline for the given RPC method: the
+// pool's own setting, else the package default.
+func (p *Pool) timeoutFor(method string) time.Duration {
+	p.timeoutMu.Lock()
+	d, ok := p.timeouts[method]
+	p.timeoutMu.Unlock()
+	if ok {
+		return d
+	}
+	if d, ok := methodTimeouts[method]; ok {
+		return d
+	}
+	return DefaultTimeout
+}
+
 // Pool maintains a persistent JSON-RPC client connection to a Delve debugger,
-// reconnecting automatically on failure.
+// reconnecting automatically on failure. A second, out-of-band control
+// connection carries calls such as halt that must reach Delve while an
+// execution command is blocked on the main connection.
 type Pool struct {
-	addr   string
+	addr    string
+	main    connSlot
+	control connSlot
+
+	timeoutMu sync.Mutex
+	timeouts  map[string]time.Duration
+
+	infoMu     sync.Mutex
+	info       *ServerInfo
+	onConnect  []func()
+	connecting bool
+
+	runMu     sync.Mutex
+	run       *Run
+	lastRun   *Run
+	nextRunID int64
+	onStop    []func(*Run)
+	onTrace   []func(TraceHit)
+	onState   []func(context.Context, *DebuggerState)
+	// haltRequested stops a continue from resuming after a tracepoint.
+	haltRequested bool
+	// resuming is set while a continue is reissued after a tracepoint.
+	resuming bool
+}
+
+// connSlot holds one lazily dialed connection.
+type connSlot struct {
 	mu     sync.Mutex
 	client *Client
 }
 
+// get returns the existing connection or dials a new one, running onDial, if
+// set, before the new connection is used. dialed reports whether the
+// connection is new.
+func (s *connSlot) get(addr string, onDial func(*Client) error) (c *Client, dialed bool, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.client != nil {
+		return s.client, false, nil
+	}
+
+	c, err = Dial(addr)
+	if err != nil {
+		return nil, false, err
+	}
+	if onDial != nil {
+		if err := onDial(c); err != nil {
+			c.Close()
+			return nil, false, err
+		}
+	}
+	s.client = c
+	return s.client, true, nil
+}
+
+// discard closes c and removes it so the next call redials. It does nothing if
+// c has already been replaced, so a stale failure never tears down a newer
+// connection.
+func (s *connSlot) discard(c *Client) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.client != nil && s.client == c {
+		s.client.Close()
+		s.client = nil
+	}
+}
+
+// close closes the current connection, if any.
+func (s *connSlot) close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.client == nil {
+		return nil
+	}
+	err := s.client.Close()
+	s.client = nil
+	return err
+}
+
 // NewPool creates a connection pool for the given Delve debugger address.
 func NewPool(addr string) *Pool {
 	return &Pool{addr: addr}
 }
 
+// SetTimeout overrides the default deadline of an RPC method for calls made
+// through this pool. Like the built-in defaults, it applies only when the
+// caller's context has no deadline.
+func (p *Pool) SetTimeout(method string, d time.Duration) {
+	p.timeoutMu.Lock()
+	defer p.timeoutMu.Unlock()
+	if p.timeouts == nil {
+		p.timeouts = make(map[string]time.Duration)
+	}
+	p.timeouts[method] = d
+}
+
 // Addr returns the address this pool connects to.
 func (p *Pool) Addr() string {
 	return p.addr
 }
 
-// getClient returns an existing healthy connection or dials a new one.
-func (p *Pool) getClient() (*Client, error) {
-	p.mu.Lock()
-	defer p.mu.Unlock()
+// Call invokes an RPC method using the pooled connection with the method's
+// default deadline. It is shorthand for CallContext with a background context.
+func (p *Pool) Call(method string, args interface{}, reply interface{}) error {
+	return p.CallContext(context.Background(), method, args, reply)
+}
 
-	if p.client != nil {
-		return p.client, nil
-	}
+// CallContext invokes an RPC method using the pooled connection, honoring
+// cancellation of ctx. If ctx has no deadline the method's default timeout is
+// applied. On a connection failure it retries once with a fresh connection
+// unless the context is already done or the method is Command or Restart,
+// which may have taken effect before the connection dropped.
+func (p *Pool) CallContext(ctx context.Context, method string, args interface{}, reply interface{}) error {
+	return p.call(ctx, &p.main, method, args, reply, p.defaultTimeout(ctx, method))
+}
 
-	c, err := Dial(p.addr)
-	if err != nil {
-		return nil, err
+// Control invokes an RPC method over the out-of-band control connection. Use
+// it for calls that must not queue behind a blocked execution command, such as
+// halting the target or a non-blocking State query while it runs. Delve only
+// accepts a second connection with --accept-multiclient; otherwise the call
+// falls back to the main connection.
+func (p *Pool) Control(ctx context.Context, method string, args interface{}, reply interface{}) error {
+	if err := p.Connect(); err != nil {
+		return err
+	}
+	slot := &p.control
+	if info := p.Info(); info != nil && !info.Multiclient {
+		slot = &p.main
 	}
-	p.client = c
-	return p.client, nil
+	return p.call(ctx, slot, method, args, reply, p.defaultTimeout(ctx, method))
 }
 
-// discard closes and removes the current connection so the next call redials.
-func (p *Pool) discard() {
-	p.mu.Lock()
-	defer p.mu.Unlock()
-	if p.client != nil {
-		p.client.Close()
-		p.client = nil
+// defaultTimeout returns the method's default deadline, or zero if ctx
+// already carries one.
+func (p *Pool) defaultTimeout(ctx context.Context, method string) time.Duration {
+	if _, ok := ctx.Deadline(); ok {
+		return 0
 	}
+	return p.timeoutFor(method)
 }
 
-// Call invokes an RPC method using the pooled connection.
-// On failure it retries once with a fresh connection.
-func (p *Pool) Call(method string, args interface{}, reply interface{}) error {
-	c, err := p.getClient()
+// call performs the RPC over slot with retry, bounding it by timeout when
+// non-zero. Errors returned by Delve itself leave the connection in place;
+// only transport failures cause a redial, and methods in noRetry are not
+// resent.
+func (p *Pool) call(ctx context.Context, slot *connSlot, method string, args interface{}, reply interface{}, timeout time.Duration) error {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	c, err := p.dial(slot)
 	if err != nil {
 		return fmt.Errorf("connect to %s: %w", p.addr, err)
 	}
 
-	err = c.Call(method, args, reply)
+	err = c.CallContext(ctx, method, args, reply)
 	if err == nil {
 		return nil
 	}
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return contextError(method, timeout, ctxErr)
+	}
+	if !isConnError(err) {
+		return err
+	}
 
 	// Connection may be stale; discard and retry once.
-	p.discard()
+	slot.discard(c)
+	if noRetry[method] {
+		return fmt.Errorf("connection to %s lost: %w", p.addr, err)
+	}
 
-	c, err = p.getClient()
+	c, err = p.dial(slot)
 	if err != nil {
 		return fmt.Errorf("reconnect to %s: %w", p.addr, err)
 	}
 
-	return c.Call(method, args, reply)
+	if err := c.CallContext(ctx, method, args, reply); err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return contextError(method, timeout, ctxErr)
+		}
+		return err
+	}
+	return nil
+}
+
+// dial returns the connection in slot, dialing it if needed. A new main
+// connection runs the OnConnect callbacks before it is returned.
+func (p *Pool) dial(slot *connSlot) (*Client, error) {
+	c, dialed, err := slot.get(p.addr, p.onDial(slot))
+	if err != nil {
+		return nil, err
+	}
+	if dialed && slot == &p.main {
+		p.connected()
+	}
+	return c, nil
+}
+
+// onDial returns the hook run on new connections in slot: the main
+// connection performs the version handshake.
+func (p *Pool) onDial(slot *connSlot) func(*Client) error {
+	if slot == &p.main {
+		return p.handshake
+	}
+	return nil
+}
+
+// isConnError reports whether err came from the transport rather than from
+// Delve rejecting the request.
+func isConnError(err error) bool {
+	var serverErr netrpc.ServerError
+	return !errors.As(err, &serverErr)
+}
+
+// contextError describes why a call to method was abandoned. timeout is the
+// default deadline applied by the pool, or zero if the caller supplied its own.
+func contextError(method string, timeout time.Duration, err error) error {
+	if errors.Is(err, context.DeadlineExceeded) {
+		if timeout > 0 {
+			return fmt.Errorf("%s timed out after %s: %w", method, timeout, err)
+		}
+		return fmt.Errorf("%s timed out: %w", method, err)
+	}
+	return fmt.Errorf("%s canceled: %w", method, err)
 }
 
-// Close closes the pooled connection.
+// Close closes the pooled connections, abandoning any in-flight asynchronous command.
 func (p *Pool) Close() error {
-	p.mu.Lock()
-	defer p.mu.Unlock()
-	if p.client == nil {
-		return nil
+	p.runMu.Lock()
+	if p.run != nil {
+		p.run.cancel()
+	}
+	p.runMu.Unlock()
+
+	err := p.main.close()
+	if err2 := p.control.close(); err == nil {
+		err = err2
 	}
-	err := p.client.Close()
-	p.client = nil
 	return err
 }
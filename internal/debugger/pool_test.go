@@ -1,11 +1,17 @@
 package debugger
 
 import (
+	"context"
+	"errors"
 	"net"
 	"net/rpc"
 	"net/rpc/jsonrpc"
+	"path/filepath"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
 // echoService is a simple RPC service for testing.
@@ -19,11 +25,39 @@ type EchoReply struct {
 	Msg string
 }
 
+func (e *echoService) GetVersion(args *GetVersionIn, out *GetVersionOut) error {
+	out.DelveVersion = "Version: 1.25.0"
+	out.APIVersion = 2
+	out.Backend = "native"
+	return nil
+}
+
+func (e *echoService) IsMulticlient(args *IsMulticlientIn, out *IsMulticlientOut) error {
+	out.IsMulticlient = true
+	return nil
+}
+
 func (e *echoService) Echo(args *EchoArgs, reply *EchoReply) error {
 	reply.Msg = args.Msg
 	return nil
 }
 
+// Fail always returns an error from the server side.
+func (e *echoService) Fail(args *EchoArgs, reply *EchoReply) error {
+	return errors.New("rejected: " + args.Msg)
+}
+
+type SleepArgs struct {
+	Duration time.Duration
+}
+
+// Sleep blocks for the requested duration, simulating a long-running Command.
+func (e *echoService) Sleep(args *SleepArgs, reply *EchoReply) error {
+	time.Sleep(args.Duration)
+	reply.Msg = "awake"
+	return nil
+}
+
 // startTestServer starts a JSON-RPC server on a random port and returns its address
 // and a function to stop it.
 func startTestServer(t *testing.T) (string, func()) {
@@ -57,6 +91,31 @@ func startTestServer(t *testing.T) (string, func()) {
 	}
 }
 
+// serveRPC registers svc as Delve's RPCServer on a random local port for the
+// duration of the test and returns its address.
+func serveRPC(t *testing.T, svc interface{}) string {
+	t.Helper()
+	srv := rpc.NewServer()
+	if err := srv.RegisterName("RPCServer", svc); err != nil {
+		t.Fatal(err)
+	}
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go srv.ServeCodec(jsonrpc.NewServerCodec(conn))
+		}
+	}()
+	t.Cleanup(func() { ln.Close() })
+	return ln.Addr().String()
+}
+
 func TestPool_Call(t *testing.T) {
 	addr, stop := startTestServer(t)
 	defer stop()
@@ -137,6 +196,46 @@ func TestPool_ReconnectOnFailure(t *testing.T) {
 	}
 }
 
+func TestPool_OnConnect(t *testing.T) {
+	addr, stop := startTestServer(t)
+	defer stop()
+
+	pool := NewPool(addr)
+	defer pool.Close()
+
+	var connects int
+	pool.OnConnect(func() {
+		connects++
+		// Callbacks may use the pool while the dialing call waits.
+		var reply EchoReply
+		if err := pool.Call("Echo", &EchoArgs{Msg: "hook"}, &reply); err != nil {
+			t.Errorf("call from OnConnect: %v", err)
+		}
+	})
+
+	for i := 0; i < 2; i++ {
+		var reply EchoReply
+		if err := pool.Call("Echo", &EchoArgs{Msg: "main"}, &reply); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var reply EchoReply
+	if err := pool.Control(context.Background(), "Echo", &EchoArgs{Msg: "control"}, &reply); err != nil {
+		t.Fatal(err)
+	}
+	if connects != 1 {
+		t.Fatalf("OnConnect ran %d times, want once per main connection", connects)
+	}
+
+	pool.main.close()
+	if err := pool.Call("Echo", &EchoArgs{Msg: "again"}, &reply); err != nil {
+		t.Fatal(err)
+	}
+	if connects != 2 {
+		t.Fatalf("OnConnect ran %d times after reconnecting, want 2", connects)
+	}
+}
+
 func TestPool_ConnectError(t *testing.T) {
 	// Use an address where nothing is listening.
 	pool := NewPool("127.0.0.1:1")
@@ -205,3 +304,195 @@ func TestPool_ConcurrentCalls(t *testing.T) {
 		}
 	}
 }
+
+func TestPool_CallContextDeadline(t *testing.T) {
+	addr, stop := startTestServer(t)
+	defer stop()
+
+	pool := NewPool(addr)
+	defer pool.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	var reply EchoReply
+	err := pool.CallContext(ctx, "Sleep", &SleepArgs{Duration: time.Second}, &reply)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Fatalf("call returned after %s, expected prompt timeout", elapsed)
+	}
+
+	// The connection must remain usable after an abandoned call.
+	if err := pool.Call("Echo", &EchoArgs{Msg: "still here"}, &reply); err != nil {
+		t.Fatalf("call after timeout: %v", err)
+	}
+}
+
+func TestPool_CallContextCanceled(t *testing.T) {
+	addr, stop := startTestServer(t)
+	defer stop()
+
+	pool := NewPool(addr)
+	defer pool.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		cancel()
+	}()
+
+	var reply EchoReply
+	err := pool.CallContext(ctx, "Sleep", &SleepArgs{Duration: time.Second}, &reply)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected canceled, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "Sleep canceled") {
+		t.Errorf("error %q does not name the method", err)
+	}
+}
+
+func TestPool_MethodDefaultTimeout(t *testing.T) {
+	addr, stop := startTestServer(t)
+	defer stop()
+
+	pool := NewPool(addr)
+	defer pool.Close()
+
+	pool.SetTimeout("Sleep", 50*time.Millisecond)
+
+	var reply EchoReply
+	err := pool.Call("Sleep", &SleepArgs{Duration: time.Second}, &reply)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected default deadline to apply, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "Sleep timed out after 50ms") {
+		t.Errorf("error %q does not name the method and timeout", err)
+	}
+	if d := NewPool(addr).timeoutFor("Sleep"); d != DefaultTimeout {
+		t.Fatalf("another pool's Sleep timeout = %s, want %s", d, DefaultTimeout)
+	}
+}
+
+func TestPool_ServerErrorKeepsConnection(t *testing.T) {
+	addr, stop := startTestServer(t)
+	defer stop()
+
+	pool := NewPool(addr)
+	defer pool.Close()
+
+	var reply EchoReply
+	if err := pool.Call("Echo", &EchoArgs{Msg: "first"}, &reply); err != nil {
+		t.Fatal(err)
+	}
+	before := pool.main.client
+
+	err := pool.Call("Fail", &EchoArgs{Msg: "nope"}, &reply)
+	if err == nil || err.Error() != "rejected: nope" {
+		t.Fatalf("expected server error, got %v", err)
+	}
+	if pool.main.client != before {
+		t.Fatal("server error should not replace the pooled connection")
+	}
+}
+
+// dropCodec closes the connection as soon as it reads a request for method,
+// so the client sees a transport failure after the request was delivered.
+type dropCodec struct {
+	rpc.ServerCodec
+	conn   net.Conn
+	method string
+	calls  *atomic.Int32
+}
+
+func (c *dropCodec) ReadRequestHeader(r *rpc.Request) error {
+	if err := c.ServerCodec.ReadRequestHeader(r); err != nil {
+		return err
+	}
+	if r.ServiceMethod == c.method {
+		c.calls.Add(1)
+		c.conn.Close()
+	}
+	return nil
+}
+
+func TestPool_ConnectionLossRetry(t *testing.T) {
+	for _, tt := range []struct {
+		method    string
+		wantCalls int32
+	}{
+		{"Echo", 2},
+		{"Command", 1},
+		{"Restart", 1},
+	} {
+		t.Run(tt.method, func(t *testing.T) {
+			srv := rpc.NewServer()
+			if err := srv.RegisterName("RPCServer", new(echoService)); err != nil {
+				t.Fatal(err)
+			}
+			ln, err := net.Listen("tcp", "127.0.0.1:0")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer ln.Close()
+			var calls atomic.Int32
+			go func() {
+				for {
+					conn, err := ln.Accept()
+					if err != nil {
+						return
+					}
+					codec := &dropCodec{jsonrpc.NewServerCodec(conn), conn, "RPCServer." + tt.method, &calls}
+					go srv.ServeCodec(codec)
+				}
+			}()
+
+			pool := NewPool(ln.Addr().String())
+			defer pool.Close()
+
+			var reply EchoReply
+			if err := pool.Call(tt.method, &EchoArgs{Msg: "once"}, &reply); err == nil {
+				t.Fatal("expected connection error")
+			}
+			if got := calls.Load(); got != tt.wantCalls {
+				t.Fatalf("server received %d requests, want %d", got, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestPool_UnixSocket(t *testing.T) {
+	sock := filepath.Join(t.TempDir(), "dlv.sock")
+	ln, err := net.Listen("unix", sock)
+	if err != nil {
+		t.Skipf("unix sockets unavailable: %v", err)
+	}
+	defer ln.Close()
+
+	srv := rpc.NewServer()
+	if err := srv.RegisterName("RPCServer", new(echoService)); err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go srv.ServeCodec(jsonrpc.NewServerCodec(conn))
+		}
+	}()
+
+	pool := NewPool("unix:" + sock)
+	defer pool.Close()
+
+	var reply EchoReply
+	if err := pool.Call("Echo", &EchoArgs{Msg: "over unix"}, &reply); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if reply.Msg != "over unix" {
+		t.Fatalf("got %q, want %q", reply.Msg, "over unix")
+	}
+}
@@ -0,0 +1,332 @@
+package debugger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Launch modes understood by LaunchConfig.
+const (
+	ModeDebug  = "debug"
+	ModeAttach = "attach"
+	ModeCore   = "core"
+	ModeExec   = "exec"
+	ModeTest   = "test"
+)
+
+const (
+	// StartTimeout bounds how long Launch waits for Delve to start listening,
+	// which includes building the target.
+	StartTimeout = 2 * time.Minute
+	// stopGrace is how long Stop waits after interrupting Delve before killing it.
+	stopGrace = 5 * time.Second
+	// maxOutput caps how much combined Delve and target output is retained.
+	maxOutput = 64 * 1024
+)
+
+// LaunchConfig describes a headless Delve process started by the sidecar.
+type LaunchConfig struct {
+	// Mode is the dlv subcommand; ModeDebug if empty.
+	Mode string
+	// Target is the package path to build and debug or test, the executable
+	// to run in ModeExec, or the executable that produced Core in ModeCore.
+	Target string
+	// Core is the core dump to open in ModeCore.
+	Core string
+	// Pid is the process to attach to in ModeAttach.
+	Pid int
+	// BuildFlags are passed to the go toolchain via --build-flags.
+	BuildFlags string
+	// Tags are joined into a -tags build flag.
+	Tags []string
+	// TestFlags select the tests to run in ModeTest, as returned by
+	// GoTestFlags. They are passed to the test binary ahead of Args.
+	TestFlags []string
+	// Args are passed to the debugged program.
+	Args []string
+	// Env holds KEY=VALUE pairs added to the sidecar's environment.
+	Env []string
+	// Dir is the working directory for Delve and the program.
+	Dir string
+	// Continue starts the program immediately instead of stopping on entry.
+	Continue bool
+	// DlvPath is the Delve executable; "dlv" from PATH if empty.
+	DlvPath string
+}
+
+// TargetPath returns where Delve finds Target: relative paths are resolved
+// against Dir, since Delve runs there.
+func (c LaunchConfig) TargetPath() string {
+	if filepath.IsAbs(c.Target) || c.Dir == "" {
+		return c.Target
+	}
+	return filepath.Join(c.Dir, c.Target)
+}
+
+// mode returns the configured mode or the default.
+func (c LaunchConfig) mode() string {
+	if c.Mode == "" {
+		return ModeDebug
+	}
+	return c.Mode
+}
+
+// buildFlags combines BuildFlags and Tags into a single --build-flags value.
+func (c LaunchConfig) buildFlags() string {
+	flags := c.BuildFlags
+	if len(c.Tags) > 0 {
+		tags := "-tags=" + strings.Join(c.Tags, ",")
+		if flags == "" {
+			flags = tags
+		} else {
+			flags += " " + tags
+		}
+	}
+	return flags
+}
+
+// args returns the dlv command line, excluding the executable, for a
+// headless server listening on addr.
+func (c LaunchConfig) args(addr string) []string {
+	args := []string{c.mode()}
+	if c.mode() == ModeAttach {
+		args = append(args, strconv.Itoa(c.Pid))
+	}
+	if c.Target != "" {
+		args = append(args, c.Target)
+	}
+	if c.mode() == ModeCore {
+		args = append(args, c.Core)
+	}
+	args = append(args,
+		"--headless",
+		"--listen="+addr,
+		"--api-version=2",
+		"--accept-multiclient",
+	)
+	if c.Continue {
+		args = append(args, "--continue")
+	}
+	if flags := c.buildFlags(); flags != "" {
+		args = append(args, "--build-flags="+flags)
+	}
+	if progArgs := c.ProgramArgs(); len(progArgs) > 0 {
+		args = append(args, "--")
+		args = append(args, progArgs...)
+	}
+	return args
+}
+
+// ProgramArgs returns the arguments the debugged program is started with:
+// TestFlags followed by Args.
+func (c LaunchConfig) ProgramArgs() []string {
+	if len(c.TestFlags) == 0 {
+		return c.Args
+	}
+	return append(append([]string{}, c.TestFlags...), c.Args...)
+}
+
+// GoTestFlags returns the test binary flags that run the tests matching run,
+// optionally narrowed to the subtest path subtest, count times with verbose
+// output. A count of zero leaves the go test default.
+func GoTestFlags(run, subtest string, count int) []string {
+	pattern := run
+	if subtest != "" {
+		pattern += "/" + subtest
+	}
+	flags := []string{"-test.v"}
+	if pattern != "" {
+		flags = append(flags, "-test.run", pattern)
+	}
+	if count > 0 {
+		flags = append(flags, "-test.count", strconv.Itoa(count))
+	}
+	return flags
+}
+
+// FreePort asks the kernel for an unused local TCP port.
+func FreePort() (int, error) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return 0, err
+	}
+	defer ln.Close()
+	return ln.Addr().(*net.TCPAddr).Port, nil
+}
+
+// Process is a headless Delve child process supervised by the sidecar.
+type Process struct {
+	// Addr is the address Delve listens on.
+	Addr string
+	// Config is the configuration the process was launched with.
+	Config LaunchConfig
+	// BuildInfo describes the prebuilt binary run in ModeExec, and is nil
+	// in other modes.
+	BuildInfo *BuildInfo
+
+	cmd    *exec.Cmd
+	output *outputBuffer
+	done   chan struct{}
+	err    error
+}
+
+// Launch starts Delve on a free local port and waits until it accepts
+// connections, the process exits, or ctx is done.
+func Launch(ctx context.Context, cfg LaunchConfig) (*Process, error) {
+	// Reading the build info up front also rejects anything that is not a
+	// Go binary before Delve is started.
+	var bi *BuildInfo
+	if cfg.mode() == ModeExec {
+		var err error
+		if bi, err = ReadBuildInfo(cfg.TargetPath()); err != nil {
+			return nil, err
+		}
+	}
+
+	port, err := FreePort()
+	if err != nil {
+		return nil, fmt.Errorf("find free port: %w", err)
+	}
+	addr := "127.0.0.1:" + strconv.Itoa(port)
+
+	dlv := cfg.DlvPath
+	if dlv == "" {
+		dlv = "dlv"
+	}
+	cmd := exec.Command(dlv, cfg.args(addr)...)
+	cmd.Dir = cfg.Dir
+	if len(cfg.Env) > 0 {
+		cmd.Env = append(os.Environ(), cfg.Env...)
+	}
+	out := &outputBuffer{}
+	cmd.Stdout = out
+	cmd.Stderr = out
+
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("start %s: %w", dlv, err)
+	}
+
+	p := &Process{
+		Addr:      addr,
+		Config:    cfg,
+		BuildInfo: bi,
+		cmd:       cmd,
+		output:    out,
+		done:      make(chan struct{}),
+	}
+	go func() {
+		p.err = cmd.Wait()
+		close(p.done)
+	}()
+
+	if err := p.waitReady(ctx); err != nil {
+		p.Stop()
+		return nil, err
+	}
+	return p, nil
+}
+
+// waitReady polls Addr until Delve accepts a connection.
+func (p *Process) waitReady(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, StartTimeout)
+	defer cancel()
+
+	ticker := time.NewTicker(50 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		conn, err := net.DialTimeout("tcp", p.Addr, time.Second)
+		if err == nil {
+			conn.Close()
+			return nil
+		}
+		select {
+		case <-p.done:
+			return fmt.Errorf("delve exited before listening: %v\n%s", p.err, p.Output())
+		case <-ctx.Done():
+			return fmt.Errorf("delve did not start listening on %s: %w\n%s", p.Addr, ctx.Err(), p.Output())
+		case <-ticker.C:
+		}
+	}
+}
+
+// Pid returns the process ID of the Delve process.
+func (p *Process) Pid() int {
+	return p.cmd.Process.Pid
+}
+
+// Done returns a channel that is closed when the Delve process exits.
+func (p *Process) Done() <-chan struct{} {
+	return p.done
+}
+
+// Exited reports whether the Delve process has exited.
+func (p *Process) Exited() bool {
+	select {
+	case <-p.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// Err returns the error from waiting on the process once it has exited.
+func (p *Process) Err() error {
+	if !p.Exited() {
+		return nil
+	}
+	return p.err
+}
+
+// Output returns the most recent output written by Delve and the target.
+func (p *Process) Output() string {
+	return p.output.String()
+}
+
+// Stop interrupts Delve and kills it if it has not exited within a grace
+// period. It is safe to call more than once.
+func (p *Process) Stop() error {
+	if p.Exited() {
+		return nil
+	}
+	if err := p.cmd.Process.Signal(os.Interrupt); err != nil && !errors.Is(err, os.ErrProcessDone) {
+		p.cmd.Process.Kill()
+	}
+	select {
+	case <-p.done:
+	case <-time.After(stopGrace):
+		p.cmd.Process.Kill()
+		<-p.done
+	}
+	return nil
+}
+
+// outputBuffer is a concurrency-safe writer retaining the last maxOutput bytes.
+type outputBuffer struct {
+	mu  sync.Mutex
+	buf []byte
+}
+
+func (b *outputBuffer) Write(data []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.buf = append(b.buf, data...)
+	if over := len(b.buf) - maxOutput; over > 0 {
+		b.buf = append(b.buf[:0], b.buf[over:]...)
+	}
+	return len(data), nil
+}
+
+func (b *outputBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return string(b.buf)
+}
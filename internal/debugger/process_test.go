@@ -0,0 +1,251 @@
+package debugger
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/rpc"
+	"net/rpc/jsonrpc"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// fakeDlvEnv makes the test binary behave as a minimal headless Delve.
+const fakeDlvEnv = "DLC_SIDECAR_FAKE_DLV"
+
+func TestMain(m *testing.M) {
+	if os.Getenv(fakeDlvEnv) == "1" {
+		os.Exit(fakeDlv(os.Args[1:]))
+	}
+	os.Exit(m.Run())
+}
+
+// fakeDlv serves the echo service on the --listen address until killed. A
+// target named "broken" fails the way a build error would.
+func fakeDlv(args []string) int {
+	if len(args) > 1 && args[1] == "broken" {
+		fmt.Println("build failed: syntax error")
+		return 1
+	}
+	var addr string
+	for _, arg := range args {
+		if v, ok := strings.CutPrefix(arg, "--listen="); ok {
+			addr = v
+		}
+	}
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		fmt.Println(err)
+		return 1
+	}
+	srv := rpc.NewServer()
+	srv.RegisterName("RPCServer", new(echoService))
+	fmt.Println("API server listening at:", addr)
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			return 1
+		}
+		go srv.ServeCodec(jsonrpc.NewServerCodec(conn))
+	}
+}
+
+func TestLaunchConfig_Args(t *testing.T) {
+	cfg := LaunchConfig{
+		Target:     "./cmd/server",
+		BuildFlags: "-race",
+		Tags:       []string{"integration", "linux"},
+		Args:       []string{"-config", "dev.yaml"},
+	}
+	want := []string{
+		"debug", "./cmd/server",
+		"--headless", "--listen=127.0.0.1:4000", "--api-version=2", "--accept-multiclient",
+		"--build-flags=-race -tags=integration,linux",
+		"--", "-config", "dev.yaml",
+	}
+	if got := cfg.args("127.0.0.1:4000"); !reflect.DeepEqual(got, want) {
+		t.Fatalf("args() =\n  %q\nwant\n  %q", got, want)
+	}
+}
+
+func TestLaunchConfig_TestArgs(t *testing.T) {
+	cfg := LaunchConfig{
+		Mode:      ModeTest,
+		Target:    "./pkg/store",
+		TestFlags: GoTestFlags("TestGet", "", 1),
+		Args:      []string{"-test.timeout", "30s"},
+	}
+	want := []string{
+		"test", "./pkg/store",
+		"--headless", "--listen=127.0.0.1:4000", "--api-version=2", "--accept-multiclient",
+		"--", "-test.v", "-test.run", "TestGet", "-test.count", "1", "-test.timeout", "30s",
+	}
+	if got := cfg.args("127.0.0.1:4000"); !reflect.DeepEqual(got, want) {
+		t.Fatalf("args() =\n  %q\nwant\n  %q", got, want)
+	}
+}
+
+func TestLaunchConfig_AttachArgs(t *testing.T) {
+	cfg := LaunchConfig{Mode: ModeAttach, Pid: 4242}
+	want := []string{
+		"attach", "4242",
+		"--headless", "--listen=127.0.0.1:4000", "--api-version=2", "--accept-multiclient",
+	}
+	if got := cfg.args("127.0.0.1:4000"); !reflect.DeepEqual(got, want) {
+		t.Fatalf("args() =\n  %q\nwant\n  %q", got, want)
+	}
+}
+
+func TestLaunchConfig_CoreArgs(t *testing.T) {
+	cfg := LaunchConfig{Mode: ModeCore, Target: "./bin/server", Core: "/tmp/core.1234"}
+	want := []string{
+		"core", "./bin/server", "/tmp/core.1234",
+		"--headless", "--listen=127.0.0.1:4000", "--api-version=2", "--accept-multiclient",
+	}
+	if got := cfg.args("127.0.0.1:4000"); !reflect.DeepEqual(got, want) {
+		t.Fatalf("args() =\n  %q\nwant\n  %q", got, want)
+	}
+}
+
+func TestLaunchConfig_ExecArgs(t *testing.T) {
+	cfg := LaunchConfig{Mode: ModeExec, Target: "./bin/server", Continue: true, Args: []string{"-v"}}
+	want := []string{
+		"exec", "./bin/server",
+		"--headless", "--listen=127.0.0.1:4000", "--api-version=2", "--accept-multiclient",
+		"--continue",
+		"--", "-v",
+	}
+	if got := cfg.args("127.0.0.1:4000"); !reflect.DeepEqual(got, want) {
+		t.Fatalf("args() =\n  %q\nwant\n  %q", got, want)
+	}
+}
+
+func TestGoTestFlags(t *testing.T) {
+	tests := []struct {
+		name    string
+		run     string
+		subtest string
+		count   int
+		want    []string
+	}{
+		{"run only", "TestServer", "", 0, []string{"-test.v", "-test.run", "TestServer"}},
+		{"subtest and count", "TestServer", "timeout/slow", 3, []string{"-test.v", "-test.run", "TestServer/timeout/slow", "-test.count", "3"}},
+		{"no pattern", "", "", 1, []string{"-test.v", "-test.count", "1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := GoTestFlags(tt.run, tt.subtest, tt.count); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("GoTestFlags() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFreePort(t *testing.T) {
+	port, err := FreePort()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if port <= 0 {
+		t.Fatalf("FreePort() = %d", port)
+	}
+}
+
+func TestLaunch(t *testing.T) {
+	proc, err := Launch(context.Background(), LaunchConfig{
+		Target:  "./app",
+		Env:     []string{fakeDlvEnv + "=1"},
+		DlvPath: os.Args[0],
+	})
+	if err != nil {
+		t.Fatalf("Launch: %v", err)
+	}
+
+	pool := NewPool(proc.Addr)
+	var reply EchoReply
+	if err := pool.Call("Echo", &EchoArgs{Msg: "launched"}, &reply); err != nil {
+		t.Fatalf("call launched server: %v", err)
+	}
+	pool.Close()
+
+	if err := proc.Stop(); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+	if !proc.Exited() {
+		t.Fatal("process should have exited after Stop")
+	}
+	if !strings.Contains(proc.Output(), "API server listening at") {
+		t.Fatalf("output not captured: %q", proc.Output())
+	}
+	// Stopping twice is a no-op.
+	if err := proc.Stop(); err != nil {
+		t.Fatalf("second Stop: %v", err)
+	}
+}
+
+func TestLaunch_ExitBeforeListening(t *testing.T) {
+	_, err := Launch(context.Background(), LaunchConfig{
+		Target:  "broken",
+		Env:     []string{fakeDlvEnv + "=1"},
+		DlvPath: os.Args[0],
+	})
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if !strings.Contains(err.Error(), "build failed") {
+		t.Fatalf("error should include process output, got %v", err)
+	}
+}
+
+func TestLaunch_ExecBuildInfo(t *testing.T) {
+	proc, err := Launch(context.Background(), LaunchConfig{
+		Mode:    ModeExec,
+		Target:  os.Args[0],
+		Env:     []string{fakeDlvEnv + "=1"},
+		DlvPath: os.Args[0],
+	})
+	if err != nil {
+		t.Fatalf("Launch: %v", err)
+	}
+	defer proc.Stop()
+	if proc.BuildInfo == nil || proc.BuildInfo.GoVersion == "" {
+		t.Fatalf("BuildInfo = %+v, want the test binary's", proc.BuildInfo)
+	}
+
+	_, err = Launch(context.Background(), LaunchConfig{
+		Mode:    ModeExec,
+		Target:  "process_test.go",
+		Dir:     ".",
+		DlvPath: "/definitely/not/dlv",
+	})
+	if err == nil || !strings.Contains(err.Error(), "read build info from process_test.go") {
+		t.Fatalf("Launch of a non-Go binary: err = %v", err)
+	}
+}
+
+func TestLaunchConfig_TargetPath(t *testing.T) {
+	for _, tt := range []struct {
+		cfg  LaunchConfig
+		want string
+	}{
+		{LaunchConfig{Target: "bin/app"}, "bin/app"},
+		{LaunchConfig{Target: "bin/app", Dir: "/srv"}, "/srv/bin/app"},
+		{LaunchConfig{Target: "/opt/app", Dir: "/srv"}, "/opt/app"},
+	} {
+		if got := tt.cfg.TargetPath(); got != tt.want {
+			t.Errorf("%+v.TargetPath() = %q, want %q", tt.cfg, got, tt.want)
+		}
+	}
+}
+
+func TestOutputBuffer_KeepsTail(t *testing.T) {
+	var b outputBuffer
+	b.Write([]byte(strings.Repeat("a", maxOutput)))
+	b.Write([]byte("tail"))
+	got := b.String()
+	if len(got) != maxOutput || !strings.HasSuffix(got, "tail") {
+		t.Fatalf("buffer length %d, suffix %q", len(got), got[len(got)-4:])
+	}
+}
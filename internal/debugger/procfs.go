@@ -0,0 +1,70 @@
+package debugger
+
+import (
+	"bytes"
+	"debug/buildinfo"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+)
+
+// procRoot is the procfs mount used for process discovery.
+var procRoot = "/proc"
+
+// GoProcess describes a running process whose executable is a Go binary.
+type GoProcess struct {
+	Pid       int      `json:"pid"`
+	Exe       string   `json:"exe"`
+	Cmdline   []string `json:"cmdline"`
+	GoVersion string   `json:"goVersion"`
+	Path      string   `json:"path,omitempty"`
+}
+
+// ListGoProcesses scans procfs for processes running Go binaries, skipping
+// the sidecar itself and processes it is not permitted to inspect.
+func ListGoProcesses() ([]GoProcess, error) {
+	entries, err := os.ReadDir(procRoot)
+	if err != nil {
+		return nil, fmt.Errorf("process discovery requires procfs: %w", err)
+	}
+
+	self := os.Getpid()
+	var procs []GoProcess
+	for _, e := range entries {
+		pid, err := strconv.Atoi(e.Name())
+		if err != nil || pid == self {
+			continue
+		}
+		dir := filepath.Join(procRoot, e.Name())
+		info, err := buildinfo.ReadFile(filepath.Join(dir, "exe"))
+		if err != nil {
+			continue
+		}
+		exe, _ := os.Readlink(filepath.Join(dir, "exe"))
+		procs = append(procs, GoProcess{
+			Pid:       pid,
+			Exe:       exe,
+			Cmdline:   readCmdline(filepath.Join(dir, "cmdline")),
+			GoVersion: info.GoVersion,
+			Path:      info.Path,
+		})
+	}
+
+	sort.Slice(procs, func(i, j int) bool { return procs[i].Pid < procs[j].Pid })
+	return procs, nil
+}
+
+// readCmdline splits a NUL-separated /proc/<pid>/cmdline file.
+func readCmdline(path string) []string {
+	data, err := os.ReadFile(path)
+	if err != nil || len(data) == 0 {
+		return nil
+	}
+	var args []string
+	for _, arg := range bytes.Split(bytes.TrimRight(data, "\x00"), []byte{0}) {
+		args = append(args, string(arg))
+	}
+	return args
+}
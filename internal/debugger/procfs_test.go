@@ -0,0 +1,61 @@
+package debugger
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestListGoProcesses(t *testing.T) {
+	root := t.TempDir()
+	self, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 100 runs a Go binary (this test), 200 runs a non-Go file.
+	goDir := filepath.Join(root, "100")
+	os.Mkdir(goDir, 0o755)
+	if err := os.Symlink(self, filepath.Join(goDir, "exe")); err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(filepath.Join(goDir, "cmdline"), []byte("server\x00-port\x008080\x00"), 0o644)
+
+	otherDir := filepath.Join(root, "200")
+	os.Mkdir(otherDir, 0o755)
+	script := filepath.Join(root, "script.sh")
+	os.WriteFile(script, []byte("#!/bin/sh\n"), 0o755)
+	os.Symlink(script, filepath.Join(otherDir, "exe"))
+
+	os.Mkdir(filepath.Join(root, "self"), 0o755)
+
+	old := procRoot
+	procRoot = root
+	defer func() { procRoot = old }()
+
+	procs, err := ListGoProcesses()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(procs) != 1 {
+		t.Fatalf("got %d processes, want 1: %+v", len(procs), procs)
+	}
+	p := procs[0]
+	if p.Pid != 100 || p.Exe != self || p.GoVersion == "" {
+		t.Fatalf("unexpected process: %+v", p)
+	}
+	if want := []string{"server", "-port", "8080"}; !reflect.DeepEqual(p.Cmdline, want) {
+		t.Fatalf("Cmdline = %q, want %q", p.Cmdline, want)
+	}
+}
+
+func TestListGoProcesses_NoProcfs(t *testing.T) {
+	old := procRoot
+	procRoot = filepath.Join(t.TempDir(), "missing")
+	defer func() { procRoot = old }()
+
+	if _, err := ListGoProcesses(); err == nil {
+		t.Fatal("expected error without procfs")
+	}
+}
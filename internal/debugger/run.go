@@ -0,0 +1,254 @@
+package debugger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// resumePollInterval is how often Halt checks whether a continue resuming
+// past a tracepoint has reached Delve.
+const resumePollInterval = 10 * time.Millisecond
+
+// ErrRunInFlight is returned when an execution command is requested while an
+// asynchronous one is still running.
+var ErrRunInFlight = errors.New("an execution command is already in flight")
+
+// Run tracks an execution command issued asynchronously via Pool.Start.
+type Run struct {
+	ID      int64
+	Command string
+	Started time.Time
+
+	done    chan struct{}
+	state   *DebuggerState
+	err     error
+	stopped time.Time
+	cancel  context.CancelFunc
+}
+
+// Done returns a channel that is closed once the command has returned.
+func (r *Run) Done() <-chan struct{} {
+	return r.done
+}
+
+// Result returns the state the target stopped in, or the error that ended the
+// command. It must only be called after Done is closed.
+func (r *Run) Result() (*DebuggerState, error) {
+	return r.state, r.err
+}
+
+// Stopped returns the time the command returned, or the zero time while running.
+func (r *Run) Stopped() time.Time {
+	select {
+	case <-r.done:
+		return r.stopped
+	default:
+		return time.Time{}
+	}
+}
+
+// Wait blocks until the command returns or ctx is done.
+func (r *Run) Wait(ctx context.Context) (*DebuggerState, error) {
+	select {
+	case <-r.done:
+		return r.Result()
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Start issues cmd without waiting for the target to stop and returns a handle
+// to the in-flight command. Only one command may be in flight at a time.
+// Registered OnStop callbacks run when the command returns.
+func (p *Pool) Start(cmd DebuggerCommand) (*Run, error) {
+	p.runMu.Lock()
+	if p.run != nil {
+		id := p.run.ID
+		p.runMu.Unlock()
+		return nil, fmt.Errorf("%w (run %d)", ErrRunInFlight, id)
+	}
+	p.nextRunID++
+	ctx, cancel := context.WithCancel(context.Background())
+	r := &Run{
+		ID:      p.nextRunID,
+		Command: cmd.Name,
+		Started: time.Now(),
+		done:    make(chan struct{}),
+		cancel:  cancel,
+	}
+	p.run = r
+	p.runMu.Unlock()
+
+	go p.execute(ctx, r, cmd)
+	return r, nil
+}
+
+// execute runs the command for r without a deadline and publishes the result.
+func (p *Pool) execute(ctx context.Context, r *Run, cmd DebuggerCommand) {
+	defer r.cancel()
+
+	r.state, r.err = p.traceCommand(ctx, cmd, func(out *CommandOut) error {
+		if err := p.call(ctx, &p.main, "Command", cmd, out, 0); err != nil {
+			return &RPCError{Method: "Command", Err: err}
+		}
+		return nil
+	})
+	r.stopped = time.Now()
+
+	p.runMu.Lock()
+	p.run = nil
+	p.lastRun = r
+	callbacks := append([]func(*Run){}, p.onStop...)
+	p.runMu.Unlock()
+
+	close(r.done)
+	for _, fn := range callbacks {
+		fn(r)
+	}
+}
+
+// CurrentRun returns the in-flight asynchronous command, or nil if none.
+func (p *Pool) CurrentRun() *Run {
+	p.runMu.Lock()
+	defer p.runMu.Unlock()
+	return p.run
+}
+
+// LastRun returns the in-flight command if there is one, otherwise the most
+// recently completed one, or nil if Start was never called.
+func (p *Pool) LastRun() *Run {
+	p.runMu.Lock()
+	defer p.runMu.Unlock()
+	if p.run != nil {
+		return p.run
+	}
+	return p.lastRun
+}
+
+// OnStop registers fn to be called whenever an asynchronous command returns,
+// whether the target stopped, exited or the call failed.
+func (p *Pool) OnStop(fn func(*Run)) {
+	p.runMu.Lock()
+	defer p.runMu.Unlock()
+	p.onStop = append(p.onStop, fn)
+}
+
+// OnTrace registers fn to be called for every tracepoint hit a continue
+// passes, before the target is continued again.
+func (p *Pool) OnTrace(fn func(TraceHit)) {
+	p.runMu.Lock()
+	defer p.runMu.Unlock()
+	p.onTrace = append(p.onTrace, fn)
+}
+
+// OnState registers fn to be called with every state an execution command
+// returns, before the command's caller sees it, including the stops a
+// continue passes at tracepoints. fn may use the pool, with ctx bounding the
+// calls it makes.
+func (p *Pool) OnState(fn func(context.Context, *DebuggerState)) {
+	p.runMu.Lock()
+	defer p.runMu.Unlock()
+	p.onState = append(p.onState, fn)
+}
+
+// traceCommand issues cmd through do. A continue that stops only at
+// tracepoints reports their hits to the OnTrace callbacks and is issued
+// again, until the target stops for another reason, exits or is halted.
+func (p *Pool) traceCommand(ctx context.Context, cmd DebuggerCommand, do func(*CommandOut) error) (*DebuggerState, error) {
+	p.runMu.Lock()
+	p.haltRequested = false
+	p.runMu.Unlock()
+	for {
+		var out CommandOut
+		err := do(&out)
+		p.runMu.Lock()
+		p.resuming = false
+		p.runMu.Unlock()
+		if err != nil {
+			return nil, err
+		}
+		st := &out.State
+		p.runMu.Lock()
+		observers := append([]func(context.Context, *DebuggerState){}, p.onState...)
+		p.runMu.Unlock()
+		for _, fn := range observers {
+			fn(ctx, st)
+		}
+		if cmd.Name != CmdContinue {
+			return st, nil
+		}
+
+		hits, again := traceHits(st)
+		p.runMu.Lock()
+		callbacks := append([]func(TraceHit){}, p.onTrace...)
+		halted := p.haltRequested
+		p.resuming = again && !halted
+		p.runMu.Unlock()
+		for _, h := range hits {
+			for _, fn := range callbacks {
+				fn(h)
+			}
+		}
+		if !again || halted {
+			return st, nil
+		}
+	}
+}
+
+// Halt stops the running target over the control connection, so it reaches
+// Delve even while a continue is blocked on the main connection. The blocked
+// command then returns with the halted state.
+func (p *Pool) Halt(ctx context.Context) (*DebuggerState, error) {
+	p.runMu.Lock()
+	p.haltRequested = true
+	resuming := p.resuming
+	p.runMu.Unlock()
+
+	st, err := p.halt(ctx)
+	if err != nil || !resuming {
+		return st, err
+	}
+
+	// A continue resuming past a tracepoint had already been committed to,
+	// so the halt may have reached Delve before it did and found the target
+	// stopped. Halt again once Delve reports it running, unless the continue
+	// returns first.
+	for {
+		p.runMu.Lock()
+		resuming = p.resuming
+		p.runMu.Unlock()
+		if !resuming {
+			return st, nil
+		}
+		cur, err := p.State(ctx, true)
+		if err != nil {
+			return nil, err
+		}
+		if cur.Running {
+			return p.halt(ctx)
+		}
+		select {
+		case <-time.After(resumePollInterval):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// halt sends a halt command over the control connection.
+func (p *Pool) halt(ctx context.Context) (*DebuggerState, error) {
+	var resp CommandOut
+	if err := p.invokeControl(ctx, "Command", DebuggerCommand{Name: CmdHalt}, &resp); err != nil {
+		return nil, err
+	}
+	return &resp.State, nil
+}
+
+// Detach ends the debug session over the control connection, killing the
+// target if kill is set. A headless Delve server exits after detaching.
+func (p *Pool) Detach(ctx context.Context, kill bool) error {
+	var resp DetachOut
+	return p.invokeControl(ctx, "Detach", DetachIn{Kill: kill}, &resp)
+}
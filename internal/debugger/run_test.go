@@ -0,0 +1,144 @@
+package debugger
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// commandService fakes Delve's Command method, blocking continue until release
+// is closed. A halt command closes release.
+type commandService struct {
+	release chan struct{}
+	once    sync.Once
+}
+
+func (c *commandService) GetVersion(args *GetVersionIn, out *GetVersionOut) error {
+	out.APIVersion = 2
+	return nil
+}
+
+func (c *commandService) IsMulticlient(args *IsMulticlientIn, out *IsMulticlientOut) error {
+	out.IsMulticlient = true
+	return nil
+}
+
+func (c *commandService) Command(cmd *DebuggerCommand, out *CommandOut) error {
+	switch cmd.Name {
+	case CmdContinue:
+		<-c.release
+	case CmdHalt:
+		c.once.Do(func() { close(c.release) })
+	}
+	out.State = DebuggerState{
+		CurrentThread: &Thread{
+			File:       "/src/main.go",
+			Line:       12,
+			Breakpoint: &Breakpoint{ID: 1, File: "/src/main.go", Line: 12},
+		},
+	}
+	return nil
+}
+
+func TestPool_StartReturnsImmediately(t *testing.T) {
+	svc := &commandService{release: make(chan struct{})}
+	pool := NewPool(serveRPC(t, svc))
+	defer pool.Close()
+
+	stopped := make(chan *Run, 1)
+	pool.OnStop(func(r *Run) { stopped <- r })
+
+	r, err := pool.Start(DebuggerCommand{Name: CmdContinue})
+	if err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	if pool.CurrentRun() != r {
+		t.Fatal("CurrentRun should return the in-flight run")
+	}
+
+	if _, err := pool.Start(DebuggerCommand{Name: CmdContinue}); !errors.Is(err, ErrRunInFlight) {
+		t.Fatalf("second Start: expected ErrRunInFlight, got %v", err)
+	}
+
+	close(svc.release)
+
+	select {
+	case got := <-stopped:
+		if got != r {
+			t.Fatalf("OnStop got run %d, want %d", got.ID, r.ID)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("OnStop was not called")
+	}
+
+	st, err := r.Result()
+	if err != nil {
+		t.Fatalf("Result: %v", err)
+	}
+	if st.CurrentThread.Breakpoint == nil || st.CurrentThread.Breakpoint.ID != 1 {
+		t.Fatalf("expected breakpoint 1 in stop state, got %+v", st.CurrentThread)
+	}
+	if pool.CurrentRun() != nil {
+		t.Fatal("CurrentRun should be nil after the run stops")
+	}
+	if pool.LastRun() != r {
+		t.Fatal("LastRun should return the completed run")
+	}
+}
+
+func TestRun_WaitTimeout(t *testing.T) {
+	svc := &commandService{release: make(chan struct{})}
+	pool := NewPool(serveRPC(t, svc))
+	defer pool.Close()
+	defer close(svc.release)
+
+	r, err := pool.Start(DebuggerCommand{Name: CmdContinue})
+	if err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := r.Wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+	if !r.Stopped().IsZero() {
+		t.Fatal("Stopped should be zero while running")
+	}
+}
+
+func TestPool_HaltWhileContinueInFlight(t *testing.T) {
+	svc := &commandService{release: make(chan struct{})}
+	pool := NewPool(serveRPC(t, svc))
+	defer pool.Close()
+
+	done := make(chan error, 1)
+	go func() {
+		var resp CommandOut
+		done <- pool.CallContext(context.Background(), "Command", DebuggerCommand{Name: CmdContinue}, &resp)
+	}()
+
+	// Give the continue time to block on the main connection.
+	time.Sleep(20 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if _, err := pool.Halt(ctx); err != nil {
+		t.Fatalf("Halt: %v", err)
+	}
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("continue returned error after halt: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("continue did not return after halt")
+	}
+
+	if pool.control.client == nil || pool.control.client == pool.main.client {
+		t.Fatal("halt should use a dedicated control connection")
+	}
+}
@@ -0,0 +1,100 @@
+package debugger
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SavedBreakpoint is a breakpoint as recorded in the breakpoint manifest and
+// breakpoint files: where it goes and how it is configured, without anything
+// Delve assigns.
+type SavedBreakpoint struct {
+	// Location is the location expression the breakpoint was set with, such
+	// as a function name, when it can be resolved again after a rebuild.
+	// Otherwise the breakpoint is restored at File and Line.
+	Location                 string   `json:"location,omitempty"`
+	File                     string   `json:"file"`
+	Line                     int      `json:"line"`
+	Name                     string   `json:"name,omitempty"`
+	Condition                string   `json:"condition,omitempty"`
+	HitCondition             string   `json:"hitCondition,omitempty"`
+	HitConditionPerGoroutine bool     `json:"hitConditionPerGoroutine,omitempty"`
+	Tracepoint               bool     `json:"tracepoint,omitempty"`
+	Expressions              []string `json:"expressions,omitempty"`
+	Args                     bool     `json:"args,omitempty"`
+	Locals                   bool     `json:"locals,omitempty"`
+	StackDepth               int      `json:"stackDepth,omitempty"`
+	Disabled                 bool     `json:"disabled,omitempty"`
+	// LogMessage is the template of a logpoint, whose tracepoint evaluates
+	// the expressions in its placeholders.
+	LogMessage string `json:"logMessage,omitempty"`
+}
+
+// String names where the breakpoint goes.
+func (b SavedBreakpoint) String() string {
+	if b.Location != "" {
+		return b.Location
+	}
+	return fmt.Sprintf("%s:%d", b.File, b.Line)
+}
+
+// SaveBreakpoint records bp as a SavedBreakpoint.
+func SaveBreakpoint(bp *Breakpoint) SavedBreakpoint {
+	saved := SavedBreakpoint{
+		File:                     bp.File,
+		Line:                     bp.Line,
+		Name:                     bp.Name,
+		Condition:                bp.Cond,
+		HitCondition:             bp.HitCond,
+		HitConditionPerGoroutine: bp.HitCondPerG,
+		Tracepoint:               bp.Tracepoint,
+		Expressions:              bp.Variables,
+		Args:                     bp.LoadArgs != nil,
+		Locals:                   bp.LoadLocals != nil,
+		StackDepth:               bp.Stacktrace,
+		Disabled:                 bp.Disabled,
+	}
+	if stableLocation(bp.ExprString) {
+		saved.Location = bp.ExprString
+	}
+	return saved
+}
+
+// stableLocation reports whether a location expression names the same code
+// after the target is restarted or rebuilt. Line offsets depend on where the
+// target was stopped, addresses change with every build and a regex may
+// match a different set of functions.
+func stableLocation(expr string) bool {
+	switch {
+	case expr == "":
+		return false
+	case strings.ContainsAny(expr[:1], "+-*"):
+		return false
+	case len(expr) > 1 && strings.HasPrefix(expr, "/") && strings.HasSuffix(expr, "/"):
+		return false
+	}
+	return true
+}
+
+// Breakpoint returns the breakpoint to create for b, without its location.
+func (b SavedBreakpoint) Breakpoint() *Breakpoint {
+	bp := &Breakpoint{
+		Name:        b.Name,
+		Cond:        b.Condition,
+		HitCond:     b.HitCondition,
+		HitCondPerG: b.HitConditionPerGoroutine,
+		Tracepoint:  b.Tracepoint,
+		Variables:   b.Expressions,
+		Stacktrace:  b.StackDepth,
+		Disabled:    b.Disabled,
+	}
+	if b.Args {
+		cfg := DefaultLoadConfig()
+		bp.LoadArgs = &cfg
+	}
+	if b.Locals {
+		cfg := DefaultLoadConfig()
+		bp.LoadLocals = &cfg
+	}
+	return bp
+}
@@ -0,0 +1,20 @@
+package debugger
+
+import "testing"
+
+func TestStableLocation(t *testing.T) {
+	for expr, want := range map[string]bool{
+		"":                   false,
+		"main.main":          true,
+		"main.go:42":         true,
+		"/src/main.go:42":    true,
+		"+3":                 false,
+		"-2":                 false,
+		"*0x4a1f20":          false,
+		`/^api\..*Handler$/`: false,
+	} {
+		if got := stableLocation(expr); got != want {
+			t.Errorf("stableLocation(%q) = %v, want %v", expr, got, want)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package debugger
+
+import (
+	"sync"
+	"time"
+)
+
+// DefaultTraceBufferSize is the number of trace hits a session retains.
+const DefaultTraceBufferSize = 1000
+
+// TraceHit is the data captured when the target reached a tracepoint.
+type TraceHit struct {
+	// Seq numbers hits in the order they were recorded, starting at 1.
+	Seq         int64
+	At          time.Time
+	Breakpoint  *Breakpoint
+	ThreadID    int
+	GoroutineID int64
+	File        string
+	Line        int
+	Function    string
+	// Info holds the expressions, arguments, locals and stack frames the
+	// tracepoint asked Delve to capture.
+	Info *BreakpointInfo
+}
+
+// traceHits returns the tracepoint hits in st and whether every thread that
+// stopped at a breakpoint stopped at a tracepoint, in which case the target
+// should be continued as Delve's own client does.
+func traceHits(st *DebuggerState) (hits []TraceHit, onlyTrace bool) {
+	if st.Exited {
+		return nil, false
+	}
+	now := time.Now()
+	stopped := false
+	onlyTrace = true
+	for _, th := range st.Threads {
+		bp := th.Breakpoint
+		if bp == nil {
+			continue
+		}
+		stopped = true
+		if !bp.Tracepoint && !bp.TraceReturn {
+			onlyTrace = false
+			continue
+		}
+		hit := TraceHit{
+			At:          now,
+			Breakpoint:  bp,
+			ThreadID:    th.ID,
+			GoroutineID: th.GoroutineID,
+			File:        th.File,
+			Line:        th.Line,
+			Info:        th.BreakpointInfo,
+		}
+		if th.Function != nil {
+			hit.Function = th.Function.Name
+		}
+		hits = append(hits, hit)
+	}
+	return hits, stopped && onlyTrace
+}
+
+// TraceFilter selects trace hits. Zero fields match everything.
+type TraceFilter struct {
+	BreakpointID int
+	GoroutineID  int64
+	// After skips hits with a Seq at or below it.
+	After int64
+	// Limit keeps only the most recent matching hits.
+	Limit int
+}
+
+// TraceBuffer retains the most recent trace hits of a session. It is safe
+// for concurrent use.
+type TraceBuffer struct {
+	mu      sync.Mutex
+	size    int
+	hits    []TraceHit
+	seq     int64
+	dropped int64
+}
+
+// NewTraceBuffer creates a buffer holding up to size hits, or
+// DefaultTraceBufferSize if size is not positive.
+func NewTraceBuffer(size int) *TraceBuffer {
+	if size <= 0 {
+		size = DefaultTraceBufferSize
+	}
+	return &TraceBuffer{size: size}
+}
+
+// Add records h, dropping the oldest hit when the buffer is full.
+func (b *TraceBuffer) Add(h TraceHit) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.seq++
+	h.Seq = b.seq
+	if len(b.hits) == b.size {
+		b.hits = append(b.hits[:0], b.hits[1:]...)
+		b.dropped++
+	}
+	b.hits = append(b.hits, h)
+}
+
+// Hits returns the retained hits matching f, oldest first.
+func (b *TraceBuffer) Hits(f TraceFilter) []TraceHit {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var out []TraceHit
+	for _, h := range b.hits {
+		if h.Seq <= f.After {
+			continue
+		}
+		if f.BreakpointID != 0 && (h.Breakpoint == nil || h.Breakpoint.ID != f.BreakpointID) {
+			continue
+		}
+		if f.GoroutineID != 0 && h.GoroutineID != f.GoroutineID {
+			continue
+		}
+		out = append(out, h)
+	}
+	if f.Limit > 0 && len(out) > f.Limit {
+		out = out[len(out)-f.Limit:]
+	}
+	return out
+}
+
+// Dropped returns how many hits were discarded because the buffer was full.
+func (b *TraceBuffer) Dropped() int64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.dropped
+}
+
+// Inherit takes over the hits retained by prev, with their sequence numbers,
+// for a session that replaces prev's. It is called before b records a hit.
+func (b *TraceBuffer) Inherit(prev *TraceBuffer) {
+	prev.mu.Lock()
+	hits, seq, dropped := append([]TraceHit(nil), prev.hits...), prev.seq, prev.dropped
+	prev.mu.Unlock()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if over := len(hits) - b.size; over > 0 {
+		hits, dropped = hits[over:], dropped+int64(over)
+	}
+	b.hits, b.seq, b.dropped = hits, seq, dropped
+}
+
+// Clear discards every retained hit. Sequence numbers keep increasing.
+func (b *TraceBuffer) Clear() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.hits = nil
+	b.dropped = 0
+}
@@ -0,0 +1,245 @@
+package debugger
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+// traceService answers each continue with the next scripted state.
+type traceService struct {
+	mu     sync.Mutex
+	states []DebuggerState
+	calls  int
+}
+
+func (s *traceService) GetVersion(args *GetVersionIn, out *GetVersionOut) error {
+	out.APIVersion = 2
+	return nil
+}
+
+func (s *traceService) IsMulticlient(args *IsMulticlientIn, out *IsMulticlientOut) error {
+	out.IsMulticlient = true
+	return nil
+}
+
+func (s *traceService) script(states ...DebuggerState) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.states = states
+}
+
+func (s *traceService) count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.calls
+}
+
+func (s *traceService) Command(cmd *DebuggerCommand, out *CommandOut) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls++
+	out.State = s.states[0]
+	if len(s.states) > 1 {
+		s.states = s.states[1:]
+	}
+	return nil
+}
+
+// stopAt returns a state with one thread per breakpoint, on goroutines
+// numbered from 1.
+func stopAt(bps ...*Breakpoint) DebuggerState {
+	var st DebuggerState
+	for i, bp := range bps {
+		st.Threads = append(st.Threads, &Thread{
+			ID:             i + 1,
+			GoroutineID:    int64(i + 1),
+			File:           bp.File,
+			Line:           bp.Line,
+			Breakpoint:     bp,
+			BreakpointInfo: &BreakpointInfo{Variables: []Variable{{Name: "n", Value: "1"}}},
+		})
+	}
+	if len(st.Threads) > 0 {
+		st.CurrentThread = st.Threads[0]
+	}
+	return st
+}
+
+func TestPool_ContinuePastTracepoints(t *testing.T) {
+	trace := &Breakpoint{ID: 1, File: "/src/main.go", Line: 10, Tracepoint: true}
+	stop := &Breakpoint{ID: 2, File: "/src/main.go", Line: 20}
+	svc := &traceService{}
+	svc.script(stopAt(trace), stopAt(trace, trace), stopAt(stop, trace))
+	pool := NewPool(serveRPC(t, svc))
+	defer pool.Close()
+
+	buf := NewTraceBuffer(0)
+	pool.OnTrace(buf.Add)
+	var states int
+	pool.OnState(func(ctx context.Context, st *DebuggerState) { states++ })
+
+	st, err := pool.Command(context.Background(), DebuggerCommand{Name: CmdContinue})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.CurrentThread.Breakpoint.ID != 2 {
+		t.Errorf("stopped at breakpoint %d, want 2", st.CurrentThread.Breakpoint.ID)
+	}
+	if n := svc.count(); n != 3 {
+		t.Errorf("continue issued %d times, want 3", n)
+	}
+	if states != 3 {
+		t.Errorf("OnState saw %d states, want 3", states)
+	}
+	hits := buf.Hits(TraceFilter{})
+	if len(hits) != 4 {
+		t.Fatalf("recorded %d hits, want 4", len(hits))
+	}
+	if h := hits[3]; h.Seq != 4 || h.GoroutineID != 2 || h.Info.Variables[0].Value != "1" {
+		t.Errorf("last hit = %+v", h)
+	}
+
+	// Other commands are never repeated.
+	svc.script(stopAt(trace))
+	if _, err := pool.Command(context.Background(), DebuggerCommand{Name: CmdNext}); err != nil {
+		t.Fatal(err)
+	}
+	if n := svc.count(); n != 4 {
+		t.Errorf("next issued %d times, want once", n-3)
+	}
+}
+
+func TestTraceBuffer(t *testing.T) {
+	b := NewTraceBuffer(3)
+	for i := 1; i <= 5; i++ {
+		b.Add(TraceHit{Breakpoint: &Breakpoint{ID: i % 2}, GoroutineID: int64(i)})
+	}
+	if b.Dropped() != 2 {
+		t.Errorf("dropped = %d, want 2", b.Dropped())
+	}
+
+	seqs := func(hits []TraceHit) []int64 {
+		var out []int64
+		for _, h := range hits {
+			out = append(out, h.Seq)
+		}
+		return out
+	}
+	for _, tc := range []struct {
+		filter TraceFilter
+		want   []int64
+	}{
+		{TraceFilter{}, []int64{3, 4, 5}},
+		{TraceFilter{BreakpointID: 1}, []int64{3, 5}},
+		{TraceFilter{GoroutineID: 4}, []int64{4}},
+		{TraceFilter{After: 3}, []int64{4, 5}},
+		{TraceFilter{Limit: 1}, []int64{5}},
+	} {
+		if got := seqs(b.Hits(tc.filter)); fmt.Sprint(got) != fmt.Sprint(tc.want) {
+			t.Errorf("Hits(%+v) = %v, want %v", tc.filter, got, tc.want)
+		}
+	}
+
+	b.Clear()
+	b.Add(TraceHit{})
+	if hits := b.Hits(TraceFilter{}); len(hits) != 1 || hits[0].Seq != 6 {
+		t.Errorf("after Clear = %+v, want one hit with seq 6", hits)
+	}
+
+	b.Add(TraceHit{})
+
+	next := NewTraceBuffer(1)
+	next.Inherit(b)
+	next.Add(TraceHit{})
+	if hits := next.Hits(TraceFilter{}); len(hits) != 1 || hits[0].Seq != 8 || next.Dropped() != 2 {
+		t.Errorf("inherited = %+v, dropped %d; want one hit with seq 8, two dropped", hits, next.Dropped())
+	}
+}
+
+// haltRaceService stops the first continue at a tracepoint and blocks later
+// ones until halted. Like Delve, it ignores a halt while nothing is running.
+type haltRaceService struct {
+	mu        sync.Mutex
+	trace     DebuggerState
+	continues int
+	running   chan struct{}
+	idleHalts chan struct{}
+}
+
+func (s *haltRaceService) GetVersion(args *GetVersionIn, out *GetVersionOut) error {
+	out.APIVersion = 2
+	return nil
+}
+
+func (s *haltRaceService) IsMulticlient(args *IsMulticlientIn, out *IsMulticlientOut) error {
+	out.IsMulticlient = true
+	return nil
+}
+
+func (s *haltRaceService) State(args *StateIn, out *StateOut) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out.State = &DebuggerState{Running: s.running != nil}
+	return nil
+}
+
+func (s *haltRaceService) Command(cmd *DebuggerCommand, out *CommandOut) error {
+	s.mu.Lock()
+	if cmd.Name == CmdHalt {
+		if s.running != nil {
+			close(s.running)
+			s.running = nil
+		} else {
+			s.idleHalts <- struct{}{}
+		}
+		s.mu.Unlock()
+		return nil
+	}
+	s.continues++
+	if s.continues == 1 {
+		out.State = s.trace
+		s.mu.Unlock()
+		return nil
+	}
+	running := make(chan struct{})
+	s.running = running
+	s.mu.Unlock()
+	<-running
+	return nil
+}
+
+func TestPool_HaltWhileResumingPastTracepoint(t *testing.T) {
+	trace := &Breakpoint{ID: 1, File: "/src/main.go", Line: 10, Tracepoint: true}
+	svc := &haltRaceService{trace: stopAt(trace), idleHalts: make(chan struct{}, 1)}
+	pool := NewPool(serveRPC(t, svc))
+	defer pool.Close()
+
+	// Halt between the tracepoint stop and the continue that resumes past
+	// it, so the first halt finds the target stopped.
+	halted := make(chan error, 1)
+	pool.OnTrace(func(TraceHit) {
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			_, err := pool.Halt(ctx)
+			halted <- err
+		}()
+		<-svc.idleHalts
+	})
+
+	r, err := pool.Start(DebuggerCommand{Name: CmdContinue})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := r.Wait(ctx); err != nil {
+		t.Fatalf("continue did not stop after halt: %v", err)
+	}
+	if err := <-halted; err != nil {
+		t.Fatalf("Halt: %v", err)
+	}
+}
@@ -1,5 +1,7 @@
 package debugger
 
+import "time"
+
 // EvalScope describes the goroutine and frame for evaluation.
 type EvalScope struct {
 	GoroutineID  int64 `json:"GoroutineID"`
@@ -9,11 +11,11 @@ type EvalScope struct {
 
 // LoadConfig describes how to load values from the target's memory.
 type LoadConfig struct {
-	FollowPointers     bool `json:"followPointers"`
-	MaxVariableRecurse int  `json:"maxVariableRecurse"`
-	MaxStringLen       int  `json:"maxStringLen"`
-	MaxArrayValues     int  `json:"maxArrayValues"`
-	MaxStructFields    int  `json:"maxStructFields"`
+	FollowPointers     bool `json:"FollowPointers"`
+	MaxVariableRecurse int  `json:"MaxVariableRecurse"`
+	MaxStringLen       int  `json:"MaxStringLen"`
+	MaxArrayValues     int  `json:"MaxArrayValues"`
+	MaxStructFields    int  `json:"MaxStructFields"`
 }
 
 // DefaultLoadConfig returns a sensible default LoadConfig.
@@ -27,25 +29,52 @@ func DefaultLoadConfig() LoadConfig {
 	}
 }
 
+// WatchType is the kind of memory access a watchpoint stops on.
+type WatchType uint8
+
+// Watchpoint access kinds; they may be combined.
+const (
+	WatchRead WatchType = 1 << iota
+	WatchWrite
+)
+
 // Breakpoint represents a Delve breakpoint.
 type Breakpoint struct {
-	ID           int               `json:"id"`
-	Name         string            `json:"name"`
-	Addr         uint64            `json:"addr"`
-	Addrs        []uint64          `json:"addrs"`
-	File         string            `json:"file"`
-	Line         int               `json:"line"`
-	FunctionName string            `json:"functionName,omitempty"`
-	Cond         string            `json:"Cond"`
-	Tracepoint   bool              `json:"continue"`
-	Goroutine    bool              `json:"goroutine"`
-	Stacktrace   int               `json:"stacktrace"`
-	Variables    []string          `json:"variables,omitempty"`
-	LoadArgs     *LoadConfig       `json:"LoadArgs,omitempty"`
-	LoadLocals   *LoadConfig       `json:"LoadLocals,omitempty"`
-	HitCount     map[string]uint64 `json:"hitCount"`
-	TotalHitCount uint64           `json:"totalHitCount"`
-	Disabled     bool              `json:"disabled"`
+	ID               int               `json:"id"`
+	Name             string            `json:"name"`
+	Addr             uint64            `json:"addr"`
+	Addrs            []uint64          `json:"addrs"`
+	AddrPid          []int             `json:"addrpid"`
+	File             string            `json:"file"`
+	Line             int               `json:"line"`
+	FunctionName     string            `json:"functionName,omitempty"`
+	ExprString       string            `json:"ExprString"`
+	Cond             string            `json:"Cond"`
+	HitCond          string            `json:"HitCond"`
+	HitCondPerG      bool              `json:"HitCondPerG,omitempty"`
+	Tracepoint       bool              `json:"continue"`
+	TraceReturn      bool              `json:"traceReturn,omitempty"`
+	Goroutine        bool              `json:"goroutine"`
+	Stacktrace       int               `json:"stacktrace"`
+	Variables        []string          `json:"variables,omitempty"`
+	LoadArgs         *LoadConfig       `json:"LoadArgs"`
+	LoadLocals       *LoadConfig       `json:"LoadLocals"`
+	WatchExpr        string            `json:"WatchExpr"`
+	WatchType        WatchType         `json:"WatchType"`
+	VerboseDescr     []string          `json:"VerboseDescr,omitempty"`
+	HitCount         map[string]uint64 `json:"hitCount"`
+	TotalHitCount    uint64            `json:"totalHitCount"`
+	Disabled         bool              `json:"disabled"`
+	RootFuncName     string            `json:"RootFuncName"`
+	TraceFollowCalls int               `json:"TraceFollowCalls"`
+	// UserData is client-side data that Delve never sends over the wire.
+	UserData interface{} `json:"-"`
+}
+
+// DiscardedBreakpoint is a breakpoint that could not be restored after a restart.
+type DiscardedBreakpoint struct {
+	Breakpoint *Breakpoint `json:"breakpoint"`
+	Reason     string      `json:"reason"`
 }
 
 // Function represents function information.
@@ -63,22 +92,42 @@ type Location struct {
 	File     string    `json:"file"`
 	Line     int       `json:"line"`
 	Function *Function `json:"function,omitempty"`
+	PCs      []uint64  `json:"pcs,omitempty"`
+	PCPids   []int     `json:"pcpids,omitempty"`
 }
 
+// VariableFlags describes properties of a variable.
+type VariableFlags uint16
+
+// Variable flags as reported by Delve.
+const (
+	VariableEscaped VariableFlags = 1 << iota
+	VariableShadowed
+	VariableConstant
+	VariableArgument
+	VariableReturnArgument
+	VariableFakeAddress
+	VariableCPtr
+	VariableCPURegister
+)
+
 // Variable describes a variable.
 type Variable struct {
-	Name     string     `json:"name"`
-	Addr     uint64     `json:"addr"`
-	OnlyAddr bool       `json:"onlyAddr"`
-	Type     string     `json:"type"`
-	RealType string     `json:"realType"`
-	Kind     int        `json:"kind"`
-	Value    string     `json:"value"`
-	Len      int64      `json:"len"`
-	Cap      int64      `json:"cap"`
-	Children []Variable `json:"children"`
-	Base     uint64     `json:"base"`
-	Unreadable string   `json:"unreadable"`
+	Name         string        `json:"name"`
+	Addr         uint64        `json:"addr"`
+	OnlyAddr     bool          `json:"onlyAddr"`
+	Type         string        `json:"type"`
+	RealType     string        `json:"realType"`
+	Flags        VariableFlags `json:"flags"`
+	Kind         int           `json:"kind"`
+	Value        string        `json:"value"`
+	Len          int64         `json:"len"`
+	Cap          int64         `json:"cap"`
+	Children     []Variable    `json:"children"`
+	Base         uint64        `json:"base"`
+	Unreadable   string        `json:"unreadable"`
+	LocationExpr string        `json:"LocationExpr"`
+	DeclLine     int64         `json:"DeclLine"`
 }
 
 // Thread represents a thread in the debugged process.
@@ -91,7 +140,8 @@ type Thread struct {
 	GoroutineID    int64           `json:"goroutineID"`
 	Breakpoint     *Breakpoint     `json:"breakPoint,omitempty"`
 	BreakpointInfo *BreakpointInfo `json:"breakPointInfo,omitempty"`
-	ReturnValues   []Variable      `json:"returnValues,omitempty"`
+	ReturnValues   []Variable      `json:"ReturnValues"`
+	CallReturn     bool            `json:"CallReturn"`
 }
 
 // BreakpointInfo contains information about the current breakpoint.
@@ -118,27 +168,97 @@ type Goroutine struct {
 	Labels         map[string]string `json:"labels,omitempty"`
 }
 
+// Ancestor is a goroutine that created, directly or indirectly, another one.
+type Ancestor struct {
+	ID         int64        `json:"ID"`
+	Stack      []Stackframe `json:"Stack"`
+	Unreadable string       `json:"Unreadable"`
+}
+
+// GoroutineField selects a goroutine property for filtering and grouping.
+type GoroutineField uint8
+
+// Goroutine fields understood by ListGoroutines filters and grouping.
+const (
+	GoroutineFieldNone GoroutineField = iota
+	GoroutineCurrentLoc
+	GoroutineUserLoc
+	GoroutineGoLoc
+	GoroutineStartLoc
+	GoroutineLabel
+	GoroutineRunning
+	GoroutineUser
+	GoroutineWaitingOnChannel
+)
+
+// ListGoroutinesFilter restricts the goroutines returned by ListGoroutines.
+type ListGoroutinesFilter struct {
+	Kind    GoroutineField `json:"Kind"`
+	Negated bool           `json:"Negated"`
+	Arg     string         `json:"Arg"`
+}
+
+// GoroutineGroupingOptions controls how ListGoroutines groups its results.
+type GoroutineGroupingOptions struct {
+	GroupBy         GoroutineField `json:"GroupBy"`
+	GroupByKey      string         `json:"GroupByKey"`
+	MaxGroupMembers int            `json:"MaxGroupMembers"`
+	MaxGroups       int            `json:"MaxGroups"`
+}
+
+// GoroutineGroup is one group of goroutines returned by ListGoroutines.
+type GoroutineGroup struct {
+	Name   string `json:"name"`
+	Offset int    `json:"offset"`
+	Count  int    `json:"count"`
+	Total  int    `json:"total"`
+}
+
+// StacktraceOptions selects optional information in a stack trace.
+type StacktraceOptions uint16
+
+// Stacktrace options; they may be combined.
+const (
+	StacktraceReadDefers StacktraceOptions = 1 << iota
+	StacktraceSimple
+	StacktraceG
+)
+
+// Defer describes a deferred call pending in a frame.
+type Defer struct {
+	DeferredLoc Location `json:"DeferredLoc"`
+	DeferLoc    Location `json:"DeferLoc"`
+	SP          uint64   `json:"SP"`
+	Unreadable  string   `json:"Unreadable"`
+}
+
 // Stackframe describes one frame in a stack trace.
 type Stackframe struct {
 	Location
-	Locals    []Variable `json:"Locals,omitempty"`
-	Arguments []Variable `json:"Arguments,omitempty"`
-	Bottom    bool       `json:"Bottom,omitempty"`
-	Err       string     `json:"Err,omitempty"`
+	Locals             []Variable `json:"Locals"`
+	Arguments          []Variable `json:"Arguments"`
+	FrameOffset        int64      `json:"FrameOffset"`
+	FramePointerOffset int64      `json:"FramePointerOffset"`
+	Defers             []Defer    `json:"Defers"`
+	Bottom             bool       `json:"Bottom,omitempty"`
+	Err                string     `json:"Err"`
 }
 
 // DebuggerState represents the current state of the debugger.
 type DebuggerState struct {
-	Pid               int          `json:"pid"`
-	TargetCommandLine string       `json:"targetCommandLine"`
-	Running           bool         `json:"Running"`
-	CurrentThread     *Thread      `json:"currentThread,omitempty"`
-	SelectedGoroutine *Goroutine   `json:"currentGoroutine,omitempty"`
-	Threads           []*Thread    `json:"Threads,omitempty"`
-	NextInProgress    bool         `json:"NextInProgress"`
-	Exited            bool         `json:"exited"`
-	ExitStatus        int          `json:"exitStatus"`
-	When              string       `json:"When"`
+	Pid               int           `json:"Pid"`
+	TargetCommandLine string        `json:"TargetCommandLine"`
+	Running           bool          `json:"Running"`
+	Recording         bool          `json:"Recording"`
+	CoreDumping       bool          `json:"CoreDumping"`
+	CurrentThread     *Thread       `json:"currentThread,omitempty"`
+	SelectedGoroutine *Goroutine    `json:"currentGoroutine,omitempty"`
+	Threads           []*Thread     `json:"Threads"`
+	NextInProgress    bool          `json:"NextInProgress"`
+	WatchOutOfScope   []*Breakpoint `json:"WatchOutOfScope"`
+	Exited            bool          `json:"exited"`
+	ExitStatus        int           `json:"exitStatus"`
+	When              string        `json:"When"`
 }
 
 // DebuggerCommand is a command to change the debugger's execution state.
@@ -148,23 +268,141 @@ type DebuggerCommand struct {
 	GoroutineID          int64       `json:"goroutineID,omitempty"`
 	ReturnInfoLoadConfig *LoadConfig `json:"ReturnInfoLoadConfig,omitempty"`
 	Expr                 string      `json:"expr,omitempty"`
+	WithEvents           bool        `json:"withEvents,omitempty"`
+	UnsafeCall           bool        `json:"unsafeCall,omitempty"`
 }
 
 // Command name constants matching Delve's API.
 const (
-	CmdContinue        = "continue"
-	CmdStep            = "step"
-	CmdNext            = "next"
-	CmdStepOut         = "stepOut"
-	CmdStepInstruction = "stepInstruction"
-	CmdHalt            = "halt"
+	CmdContinue                   = "continue"
+	CmdRewind                     = "rewind"
+	CmdDirectionCongruentContinue = "directionCongruentContinue"
+	CmdStep                       = "step"
+	CmdReverseStep                = "reverseStep"
+	CmdNext                       = "next"
+	CmdReverseNext                = "reverseNext"
+	CmdStepOut                    = "stepOut"
+	CmdReverseStepOut             = "reverseStepOut"
+	CmdStepInstruction            = "stepInstruction"
+	CmdReverseStepInstruction     = "reverseStepInstruction"
+	CmdCall                       = "call"
+	CmdSwitchThread               = "switchThread"
+	CmdSwitchGoroutine            = "switchGoroutine"
+	CmdHalt                       = "halt"
+)
+
+// Register is a CPU register and its value.
+type Register struct {
+	Name        string `json:"Name"`
+	Value       string `json:"Value"`
+	DwarfNumber int    `json:"DwarfNumber"`
+}
+
+// AssemblyFlavour selects the syntax used by Disassemble.
+type AssemblyFlavour int
+
+// Assembly syntaxes supported by Delve.
+const (
+	GNUFlavour AssemblyFlavour = iota
+	IntelFlavour
+	GoFlavour
 )
 
+// AsmInstruction is one disassembled instruction.
+type AsmInstruction struct {
+	Loc        Location  `json:"Loc"`
+	DestLoc    *Location `json:"DestLoc"`
+	Text       string    `json:"Text"`
+	Bytes      []byte    `json:"Bytes"`
+	Breakpoint bool      `json:"Breakpoint"`
+	AtPC       bool      `json:"AtPC"`
+}
+
+// Checkpoint is a saved position in a recording.
+type Checkpoint struct {
+	ID    int    `json:"ID"`
+	When  string `json:"When"`
+	Where string `json:"Where"`
+}
+
+// Image is a shared library or executable loaded by the target.
+type Image struct {
+	Path      string `json:"Path"`
+	Address   uint64 `json:"Address"`
+	LoadError string `json:"LoadError"`
+}
+
+// PackageBuildInfo describes a package compiled into the target.
+type PackageBuildInfo struct {
+	ImportPath    string   `json:"ImportPath"`
+	DirectoryPath string   `json:"DirectoryPath"`
+	Files         []string `json:"Files"`
+}
+
+// DumpState reports the progress of a core dump started with DumpStart.
+type DumpState struct {
+	Dumping      bool   `json:"Dumping"`
+	AllDone      bool   `json:"AllDone"`
+	ThreadsDone  int    `json:"ThreadsDone"`
+	ThreadsTotal int    `json:"ThreadsTotal"`
+	MemDone      uint64 `json:"MemDone"`
+	MemTotal     uint64 `json:"MemTotal"`
+	Err          string `json:"Err"`
+}
+
 // --- RPC request/response types ---
 
-type CreateBreakpointIn struct {
+type LastModifiedIn struct{}
+
+type LastModifiedOut struct {
+	Time time.Time `json:"Time"`
+}
+
+type DetachIn struct {
+	Kill bool `json:"Kill"`
+}
+
+type DetachOut struct{}
+
+type RestartIn struct {
+	Position     string    `json:"Position"`
+	ResetArgs    bool      `json:"ResetArgs"`
+	NewArgs      []string  `json:"NewArgs"`
+	Rerecord     bool      `json:"Rerecord"`
+	Rebuild      bool      `json:"Rebuild"`
+	NewRedirects [3]string `json:"NewRedirects"`
+}
+
+type RestartOut struct {
+	DiscardedBreakpoints []DiscardedBreakpoint `json:"DiscardedBreakpoints"`
+}
+
+type StateIn struct {
+	NonBlocking bool `json:"NonBlocking"`
+}
+
+type StateOut struct {
+	State *DebuggerState `json:"State"`
+}
+
+type CommandOut struct {
+	State DebuggerState `json:"State"`
+}
+
+type GetBreakpointIn struct {
+	Id   int    `json:"Id"`
+	Name string `json:"Name"`
+}
+
+type GetBreakpointOut struct {
 	Breakpoint Breakpoint `json:"Breakpoint"`
-	LocExpr    string     `json:"LocExpr,omitempty"`
+}
+
+type CreateBreakpointIn struct {
+	Breakpoint          Breakpoint  `json:"Breakpoint"`
+	LocExpr             string      `json:"LocExpr,omitempty"`
+	SubstitutePathRules [][2]string `json:"SubstitutePathRules,omitempty"`
+	Suspended           bool        `json:"Suspended,omitempty"`
 }
 
 type CreateBreakpointOut struct {
@@ -180,6 +418,21 @@ type ClearBreakpointOut struct {
 	Breakpoint *Breakpoint `json:"Breakpoint"`
 }
 
+type ToggleBreakpointIn struct {
+	Id   int    `json:"Id"`
+	Name string `json:"Name"`
+}
+
+type ToggleBreakpointOut struct {
+	Breakpoint *Breakpoint `json:"Breakpoint"`
+}
+
+type AmendBreakpointIn struct {
+	Breakpoint Breakpoint `json:"Breakpoint"`
+}
+
+type AmendBreakpointOut struct{}
+
 type ListBreakpointsIn struct {
 	All bool `json:"All"`
 }
@@ -188,29 +441,76 @@ type ListBreakpointsOut struct {
 	Breakpoints []*Breakpoint `json:"Breakpoints"`
 }
 
-type StateIn struct {
-	NonBlocking bool `json:"NonBlocking"`
+type CreateWatchpointIn struct {
+	Scope EvalScope `json:"Scope"`
+	Expr  string    `json:"Expr"`
+	Type  WatchType `json:"Type"`
 }
 
-type StateOut struct {
-	State *DebuggerState `json:"State"`
+type CreateWatchpointOut struct {
+	*Breakpoint
 }
 
-type CommandOut struct {
-	State DebuggerState `json:"State"`
-}
+type CancelNextIn struct{}
+
+type CancelNextOut struct{}
 
 type StacktraceIn struct {
-	Id    int64       `json:"Id"`
-	Depth int         `json:"Depth"`
-	Full  bool        `json:"Full"`
-	Cfg   *LoadConfig `json:"Cfg,omitempty"`
+	Id    int64             `json:"Id"`
+	Depth int               `json:"Depth"`
+	Full  bool              `json:"Full"`
+	Opts  StacktraceOptions `json:"Opts"`
+	Cfg   *LoadConfig       `json:"Cfg,omitempty"`
 }
 
 type StacktraceOut struct {
 	Locations []Stackframe `json:"Locations"`
 }
 
+type AncestorsIn struct {
+	GoroutineID  int64 `json:"GoroutineID"`
+	NumAncestors int   `json:"NumAncestors"`
+	Depth        int   `json:"Depth"`
+}
+
+type AncestorsOut struct {
+	Ancestors []Ancestor `json:"Ancestors"`
+}
+
+type ListThreadsIn struct{}
+
+type ListThreadsOut struct {
+	Threads []*Thread `json:"Threads"`
+}
+
+type GetThreadIn struct {
+	Id int `json:"Id"`
+}
+
+type GetThreadOut struct {
+	Thread *Thread `json:"Thread"`
+}
+
+type ListPackageVarsIn struct {
+	Filter string     `json:"Filter"`
+	Cfg    LoadConfig `json:"Cfg"`
+}
+
+type ListPackageVarsOut struct {
+	Variables []Variable `json:"Variables"`
+}
+
+type ListRegistersIn struct {
+	ThreadID  int        `json:"ThreadID"`
+	IncludeFp bool       `json:"IncludeFp"`
+	Scope     *EvalScope `json:"Scope,omitempty"`
+}
+
+type ListRegistersOut struct {
+	Registers string     `json:"Registers"`
+	Regs      []Register `json:"Regs"`
+}
+
 type ListLocalVarsIn struct {
 	Scope EvalScope  `json:"Scope"`
 	Cfg   LoadConfig `json:"Cfg"`
@@ -239,12 +539,220 @@ type EvalOut struct {
 	Variable *Variable `json:"Variable"`
 }
 
+type SetIn struct {
+	Scope  EvalScope `json:"Scope"`
+	Symbol string    `json:"Symbol"`
+	Value  string    `json:"Value"`
+}
+
+type SetOut struct{}
+
+type ListSourcesIn struct {
+	Filter string `json:"Filter"`
+}
+
+type ListSourcesOut struct {
+	Sources []string `json:"Sources"`
+}
+
+type ListFunctionsIn struct {
+	Filter      string `json:"Filter"`
+	FollowCalls int    `json:"FollowCalls"`
+}
+
+type ListFunctionsOut struct {
+	Funcs []string `json:"Funcs"`
+}
+
+type ListTypesIn struct {
+	Filter string `json:"Filter"`
+}
+
+type ListTypesOut struct {
+	Types []string `json:"Types"`
+}
+
 type ListGoroutinesIn struct {
-	Start int `json:"Start"`
-	Count int `json:"Count"`
+	Start   int                    `json:"Start"`
+	Count   int                    `json:"Count"`
+	Filters []ListGoroutinesFilter `json:"Filters,omitempty"`
+	GoroutineGroupingOptions
+	EvalScope *EvalScope `json:"EvalScope,omitempty"`
 }
 
 type ListGoroutinesOut struct {
-	Goroutines []*Goroutine `json:"Goroutines"`
-	Nextg      int          `json:"Nextg"`
+	Goroutines    []*Goroutine     `json:"Goroutines"`
+	Nextg         int              `json:"Nextg"`
+	Groups        []GoroutineGroup `json:"Groups"`
+	TooManyGroups bool             `json:"TooManyGroups"`
+}
+
+type AttachedToExistingProcessIn struct{}
+
+type AttachedToExistingProcessOut struct {
+	Answer bool `json:"Answer"`
+}
+
+type FindLocationIn struct {
+	Scope                     EvalScope   `json:"Scope"`
+	Loc                       string      `json:"Loc"`
+	IncludeNonExecutableLines bool        `json:"IncludeNonExecutableLines"`
+	SubstitutePathRules       [][2]string `json:"SubstitutePathRules,omitempty"`
+}
+
+type FindLocationOut struct {
+	Locations         []Location `json:"Locations"`
+	SubstituteLocExpr string     `json:"SubstituteLocExpr"`
+}
+
+type DisassembleIn struct {
+	Scope   EvalScope       `json:"Scope"`
+	StartPC uint64          `json:"StartPC"`
+	EndPC   uint64          `json:"EndPC"`
+	Flavour AssemblyFlavour `json:"Flavour"`
+}
+
+type DisassembleOut struct {
+	Disassemble []AsmInstruction `json:"Disassemble"`
+}
+
+type RecordedIn struct{}
+
+type RecordedOut struct {
+	Recorded       bool   `json:"Recorded"`
+	TraceDirectory string `json:"TraceDirectory"`
+}
+
+type CheckpointIn struct {
+	Where string `json:"Where"`
+}
+
+type CheckpointOut struct {
+	ID int `json:"ID"`
+}
+
+type ListCheckpointsIn struct{}
+
+type ListCheckpointsOut struct {
+	Checkpoints []Checkpoint `json:"Checkpoints"`
+}
+
+type ClearCheckpointIn struct {
+	ID int `json:"ID"`
+}
+
+type ClearCheckpointOut struct{}
+
+type FunctionReturnLocationsIn struct {
+	FnName string `json:"FnName"`
+}
+
+type FunctionReturnLocationsOut struct {
+	Addrs []uint64 `json:"Addrs"`
+}
+
+type ListDynamicLibrariesIn struct{}
+
+type ListDynamicLibrariesOut struct {
+	List []Image `json:"List"`
+}
+
+type ListPackagesBuildInfoIn struct {
+	IncludeFiles bool   `json:"IncludeFiles"`
+	Filter       string `json:"Filter"`
+}
+
+type ListPackagesBuildInfoOut struct {
+	List []PackageBuildInfo `json:"List"`
+}
+
+type ExamineMemoryIn struct {
+	Address uint64 `json:"Address"`
+	Length  int    `json:"Length"`
+}
+
+type ExamineMemoryOut struct {
+	Mem            []byte `json:"Mem"`
+	IsLittleEndian bool   `json:"IsLittleEndian"`
+}
+
+type StopRecordingIn struct{}
+
+type StopRecordingOut struct{}
+
+type DumpStartIn struct {
+	Destination string `json:"Destination"`
+}
+
+type DumpStartOut struct {
+	State DumpState `json:"State"`
+}
+
+type DumpWaitIn struct {
+	Wait int `json:"Wait"`
+}
+
+type DumpWaitOut struct {
+	State DumpState `json:"State"`
+}
+
+type DumpCancelIn struct{}
+
+type DumpCancelOut struct{}
+
+type BuildIDIn struct{}
+
+type BuildIDOut struct {
+	BuildID string `json:"BuildID"`
+}
+
+type ProcessPidIn struct{}
+
+type ProcessPidOut struct {
+	Pid int `json:"Pid"`
+}
+
+type FollowExecIn struct {
+	Enable bool   `json:"Enable"`
+	Regex  string `json:"Regex"`
+}
+
+type FollowExecOut struct{}
+
+type FollowExecEnabledIn struct{}
+
+type FollowExecEnabledOut struct {
+	Enabled bool `json:"Enabled"`
+}
+
+type DebugInfoDirectoriesIn struct {
+	Set  bool     `json:"Set"`
+	List []string `json:"List"`
+}
+
+type DebugInfoDirectoriesOut struct {
+	List []string `json:"List"`
+}
+
+type SetAPIVersionIn struct {
+	APIVersion int `json:"APIVersion"`
+}
+
+type SetAPIVersionOut struct{}
+
+type GetVersionIn struct{}
+
+type GetVersionOut struct {
+	DelveVersion            string `json:"DelveVersion"`
+	APIVersion              int    `json:"APIVersion"`
+	Backend                 string `json:"Backend"`
+	TargetGoVersion         string `json:"TargetGoVersion"`
+	MinSupportedVersionOfGo string `json:"MinSupportedVersionOfGo"`
+	MaxSupportedVersionOfGo string `json:"MaxSupportedVersionOfGo"`
+}
+
+type IsMulticlientIn struct{}
+
+type IsMulticlientOut struct {
+	IsMulticlient bool `json:"IsMulticlient"`
 }
@@ -0,0 +1,128 @@
+package debugger
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// TestTypes_RoundTrip decodes captured Delve payloads into the matching
+// types, rejecting fields we do not model, and checks that encoding the
+// result again reproduces every field of the payload.
+func TestTypes_RoundTrip(t *testing.T) {
+	tests := []struct {
+		file string
+		v    interface{}
+	}{
+		{"command_breakpoint.json", &CommandOut{}},
+		{"command_watch_out_of_scope.json", &CommandOut{}},
+		{"list_breakpoints.json", &ListBreakpointsOut{}},
+		{"stacktrace_defers.json", &StacktraceOut{}},
+		{"ancestors.json", &AncestorsOut{}},
+		{"list_goroutines_grouped.json", &ListGoroutinesOut{}},
+		{"list_registers.json", &ListRegistersOut{}},
+		{"find_location.json", &FindLocationOut{}},
+		{"disassemble.json", &DisassembleOut{}},
+		{"examine_memory.json", &ExamineMemoryOut{}},
+		{"restart.json", &RestartOut{}},
+		{"list_package_vars.json", &ListPackageVarsOut{}},
+		{"list_functions.json", &ListFunctionsOut{}},
+		{"list_sources.json", &ListSourcesOut{}},
+		{"list_types.json", &ListTypesOut{}},
+		{"create_watchpoint.json", &CreateWatchpointOut{}},
+		{"get_version.json", &GetVersionOut{}},
+		{"list_threads.json", &ListThreadsOut{}},
+		{"list_packages_build_info.json", &ListPackagesBuildInfoOut{}},
+		{"list_dynamic_libraries.json", &ListDynamicLibrariesOut{}},
+		{"list_checkpoints.json", &ListCheckpointsOut{}},
+		{"dump_wait.json", &DumpWaitOut{}},
+		{"last_modified.json", &LastModifiedOut{}},
+		{"in_create_breakpoint.json", &CreateBreakpointIn{}},
+		{"in_list_goroutines.json", &ListGoroutinesIn{}},
+		{"in_stacktrace.json", &StacktraceIn{}},
+		{"in_restart.json", &RestartIn{}},
+		{"in_create_watchpoint.json", &CreateWatchpointIn{}},
+		{"in_command.json", &DebuggerCommand{}},
+		{"in_disassemble.json", &DisassembleIn{}},
+		{"in_set.json", &SetIn{}},
+		{"in_find_location.json", &FindLocationIn{}},
+		{"in_list_registers.json", &ListRegistersIn{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			data, err := os.ReadFile(filepath.Join("testdata", "rpc", tt.file))
+			if err != nil {
+				t.Fatal(err)
+			}
+			dec := json.NewDecoder(bytes.NewReader(data))
+			dec.DisallowUnknownFields()
+			if err := dec.Decode(tt.v); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			encoded, err := json.Marshal(tt.v)
+			if err != nil {
+				t.Fatalf("encode: %v", err)
+			}
+
+			var want, got interface{}
+			if err := json.Unmarshal(data, &want); err != nil {
+				t.Fatal(err)
+			}
+			if err := json.Unmarshal(encoded, &got); err != nil {
+				t.Fatal(err)
+			}
+			checkSubset(t, "$", want, got)
+		})
+	}
+}
+
+// checkSubset reports every value in want that got lacks or differs on.
+// A null in want also matches a missing key, since Delve and our types
+// disagree on which empty fields are omitted.
+func checkSubset(t *testing.T, path string, want, got interface{}) {
+	t.Helper()
+	switch w := want.(type) {
+	case map[string]interface{}:
+		g, ok := got.(map[string]interface{})
+		if !ok {
+			t.Errorf("%s: got %v, want object", path, got)
+			return
+		}
+		for k, wv := range w {
+			gv, ok := g[k]
+			if !ok {
+				if wv != nil {
+					t.Errorf("%s.%s: missing after round trip", path, k)
+				}
+				continue
+			}
+			checkSubset(t, path+"."+k, wv, gv)
+		}
+	case []interface{}:
+		g, ok := got.([]interface{})
+		if !ok || len(g) != len(w) {
+			t.Errorf("%s: got %v, want %v", path, got, want)
+			return
+		}
+		for i := range w {
+			checkSubset(t, fmt.Sprintf("%s[%d]", path, i), w[i], g[i])
+		}
+	default:
+		if !reflect.DeepEqual(want, got) {
+			t.Errorf("%s: got %v, want %v", path, got, want)
+		}
+	}
+}
+
+func TestTypes_WatchType(t *testing.T) {
+	if WatchRead|WatchWrite != 3 {
+		t.Errorf("WatchRead|WatchWrite = %d, want 3", WatchRead|WatchWrite)
+	}
+	if StacktraceReadDefers != 1 || StacktraceSimple != 2 || StacktraceG != 4 {
+		t.Errorf("unexpected StacktraceOptions values")
+	}
+}
@@ -0,0 +1,101 @@
+package debugger
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// formatValue renders v on one line, roughly as Delve's terminal prints it:
+// strings quoted, structs as T {Field: value}, slices, arrays and maps with
+// their loaded elements and a count of the rest.
+func formatValue(v *Variable) string {
+	var b strings.Builder
+	writeValue(&b, v)
+	return b.String()
+}
+
+func writeValue(b *strings.Builder, v *Variable) {
+	kind := reflect.Kind(v.Kind)
+	switch {
+	case v.Unreadable != "":
+		b.WriteString("(unreadable " + v.Unreadable + ")")
+	case kind == reflect.String:
+		b.WriteString(strconv.Quote(v.Value))
+		if more := v.Len - int64(len(v.Value)); more > 0 {
+			fmt.Fprintf(b, "...+%d more", more)
+		}
+	case v.Value != "":
+		b.WriteString(v.Value)
+	case kind == reflect.Ptr:
+		switch {
+		case len(v.Children) == 0:
+			fmt.Fprintf(b, "(%s)(%#x)", v.Type, v.Base)
+		case v.Children[0].Addr == 0:
+			fmt.Fprintf(b, "%s nil", v.Type)
+		case v.Children[0].Kind == 0 || v.Children[0].OnlyAddr:
+			fmt.Fprintf(b, "(%s)(%#x)", v.Type, v.Children[0].Addr)
+		default:
+			b.WriteString("*")
+			writeValue(b, &v.Children[0])
+		}
+	case kind == reflect.Interface:
+		if len(v.Children) == 0 || (v.Children[0].Kind == 0 && v.Children[0].Addr == 0) {
+			fmt.Fprintf(b, "%s nil", v.Type)
+			return
+		}
+		writeValue(b, &v.Children[0])
+	case kind == reflect.Struct:
+		b.WriteString(v.Type + " {")
+		if len(v.Children) == 0 && v.Len > 0 {
+			b.WriteString("...")
+		}
+		for i := range v.Children {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			b.WriteString(v.Children[i].Name + ": ")
+			writeValue(b, &v.Children[i])
+		}
+		b.WriteString("}")
+	case kind == reflect.Slice && v.Base == 0:
+		fmt.Fprintf(b, "%s nil", v.Type)
+	case kind == reflect.Slice || kind == reflect.Array:
+		b.WriteString("[")
+		for i := range v.Children {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			writeValue(b, &v.Children[i])
+		}
+		writeMore(b, v.Len-int64(len(v.Children)), len(v.Children) > 0)
+		b.WriteString("]")
+	case kind == reflect.Map:
+		// Children alternate between keys and values.
+		b.WriteString("map[")
+		for i := 0; i+1 < len(v.Children); i += 2 {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			writeValue(b, &v.Children[i])
+			b.WriteString(": ")
+			writeValue(b, &v.Children[i+1])
+		}
+		writeMore(b, v.Len-int64(len(v.Children)/2), len(v.Children) > 0)
+		b.WriteString("]")
+	default:
+		fmt.Fprintf(b, "(%s)(%#x)", v.Type, v.Base)
+	}
+}
+
+// writeMore notes the n elements of a collection that were not loaded.
+func writeMore(b *strings.Builder, n int64, sep bool) {
+	if n <= 0 {
+		return
+	}
+	if sep {
+		b.WriteString(", ")
+	}
+	fmt.Fprintf(b, "...+%d more", n)
+}
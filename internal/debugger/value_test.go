@@ -0,0 +1,39 @@
+package debugger
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFormatValue(t *testing.T) {
+	str := func(s string) Variable {
+		return Variable{Type: "string", Kind: int(reflect.String), Value: s, Len: int64(len(s))}
+	}
+	num := func(s string) Variable { return Variable{Type: "int", Kind: int(reflect.Int), Value: s} }
+	tests := []struct {
+		v    Variable
+		want string
+	}{
+		{num("42"), "42"},
+		{str("a b"), `"a b"`},
+		{Variable{Type: "string", Kind: int(reflect.String), Value: "abc", Len: 10}, `"abc"...+7 more`},
+		{Variable{Type: "*main.T", Kind: int(reflect.Ptr), Children: []Variable{{Addr: 0xc0000a0000}}}, "(*main.T)(0xc0000a0000)"},
+		{Variable{Type: "*main.T", Kind: int(reflect.Ptr), Children: []Variable{{}}}, "*main.T nil"},
+		{Variable{Type: "int", Unreadable: "bad address"}, "(unreadable bad address)"},
+		{Variable{Type: "*main.User", Kind: int(reflect.Ptr), Children: []Variable{{
+			Type: "main.User", Kind: int(reflect.Struct), Addr: 0xc0000a0000,
+			Children: []Variable{{Name: "ID", Type: "int", Kind: int(reflect.Int), Value: "7"}, {Name: "Name", Type: "string", Kind: int(reflect.String), Value: "ann", Len: 3}},
+		}}}, `*main.User {ID: 7, Name: "ann"}`},
+		{Variable{Type: "main.Config", Kind: int(reflect.Struct), Len: 4}, "main.Config {...}"},
+		{Variable{Type: "[]int", Kind: int(reflect.Slice), Base: 0xc000010000, Len: 5, Children: []Variable{num("1"), num("2")}}, "[1, 2, ...+3 more]"},
+		{Variable{Type: "[]int", Kind: int(reflect.Slice)}, "[]int nil"},
+		{Variable{Type: "map[string]int", Kind: int(reflect.Map), Base: 0xc000010000, Len: 1, Children: []Variable{str("a"), num("1")}}, `map["a": 1]`},
+		{Variable{Type: "error", Kind: int(reflect.Interface), Children: []Variable{{}}}, "error nil"},
+		{Variable{Type: "interface {}", Kind: int(reflect.Interface), Children: []Variable{num("3")}}, "3"},
+	}
+	for _, tt := range tests {
+		if got := formatValue(&tt.v); got != tt.want {
+			t.Errorf("formatValue(%+v) = %q, want %q", tt.v, got, tt.want)
+		}
+	}
+}
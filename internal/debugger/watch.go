@@ -0,0 +1,235 @@
+package debugger
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// String returns read, write or read-write.
+func (t WatchType) String() string {
+	switch t {
+	case WatchRead:
+		return "read"
+	case WatchWrite:
+		return "write"
+	case WatchRead | WatchWrite:
+		return "read-write"
+	}
+	return fmt.Sprintf("WatchType(%d)", uint8(t))
+}
+
+// ParseWatchType parses read, write or read-write.
+func ParseWatchType(s string) (WatchType, error) {
+	switch s {
+	case "read":
+		return WatchRead, nil
+	case "write":
+		return WatchWrite, nil
+	case "read-write":
+		return WatchRead | WatchWrite, nil
+	}
+	return 0, fmt.Errorf("invalid watch mode %q: want read, write or read-write", s)
+}
+
+// Watch is a watchpoint and the value last seen in the memory it watches.
+type Watch struct {
+	ID    int
+	Expr  string
+	Scope EvalScope
+	Type  WatchType
+	// Addr and TypeName locate the watched memory, so it can be read again
+	// from wherever the target stops.
+	Addr     uint64
+	TypeName string
+	// Value is empty until the watched memory has been read.
+	Value string
+}
+
+// WatchHit is an access that stopped the target at a watchpoint.
+type WatchHit struct {
+	Watch Watch
+	// ThreadID, GoroutineID and the location are those of the code that
+	// accessed the memory.
+	ThreadID    int
+	GoroutineID int64
+	File        string
+	Line        int
+	Function    string
+	// Old is the value before the access, empty if it was never read, and
+	// New the value after it.
+	Old string
+	New string
+	// Err says why New could not be read.
+	Err string
+}
+
+// WatchSet tracks the watchpoints of a session. Observe, registered with
+// Pool.OnState, attaches what happened to them to the states execution
+// commands stop in. It is safe for concurrent use.
+type WatchSet struct {
+	pool    *Pool
+	mu      sync.Mutex
+	watches map[int]*Watch
+}
+
+// NewWatchSet creates a watch set for the watchpoints set through p.
+func NewWatchSet(p *Pool) *WatchSet {
+	return &WatchSet{pool: p, watches: make(map[int]*Watch)}
+}
+
+// Set creates a watchpoint on expr, evaluated in scope, and records the
+// watched value.
+func (s *WatchSet) Set(ctx context.Context, scope EvalScope, expr string, typ WatchType) (*Breakpoint, Watch, error) {
+	bp, err := s.pool.CreateWatchpoint(ctx, scope, expr, typ)
+	if err != nil {
+		return nil, Watch{}, err
+	}
+	w := Watch{ID: bp.ID, Expr: expr, Scope: scope, Type: typ, Addr: bp.Addr}
+	cfg := DefaultLoadConfig()
+	if v, err := s.pool.Eval(ctx, scope, expr, &cfg); err == nil {
+		w.TypeName, w.Value = v.Type, formatValue(v)
+		if v.Addr != 0 {
+			w.Addr = v.Addr
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.watches[w.ID] = &w
+	return bp, w, nil
+}
+
+// Get returns the watch with the given breakpoint ID.
+func (s *WatchSet) Get(id int) (Watch, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	w, ok := s.watches[id]
+	if !ok {
+		return Watch{}, false
+	}
+	return *w, true
+}
+
+// Remove forgets the watch with the given breakpoint ID.
+func (s *WatchSet) Remove(id int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.watches, id)
+}
+
+// Observe handles a state an execution command returned. Each thread stopped
+// at a watchpoint gets a *WatchHit with the value read again as its
+// breakpoint's UserData. Watchpoints whose stack variable went out of scope
+// are cleared and forgotten, and their last Watch becomes the UserData of
+// the breakpoint in st.WatchOutOfScope.
+func (s *WatchSet) Observe(ctx context.Context, st *DebuggerState) {
+	if st.Exited {
+		return
+	}
+	hits := make(map[int]*WatchHit)
+	threads := st.Threads
+	if st.CurrentThread != nil {
+		threads = append([]*Thread{st.CurrentThread}, threads...)
+	}
+	for _, th := range threads {
+		bp := th.Breakpoint
+		if bp == nil || bp.WatchExpr == "" {
+			continue
+		}
+		// The current thread is also listed in Threads; read it once.
+		if hits[th.ID] == nil {
+			hits[th.ID] = s.hit(ctx, th)
+		}
+		bp.UserData = hits[th.ID]
+	}
+
+	for _, bp := range st.WatchOutOfScope {
+		w, ok := s.Get(bp.ID)
+		if !ok {
+			w = Watch{ID: bp.ID, Expr: bp.WatchExpr, Type: bp.WatchType, Addr: bp.Addr}
+		}
+		// Delve clears such watchpoints itself; make sure none is left
+		// behind on the old stack memory.
+		_, _ = s.pool.ClearBreakpoint(ctx, bp.ID)
+		s.Remove(bp.ID)
+		bp.UserData = &w
+	}
+}
+
+// hit reads the value a thread stopped at a watchpoint left in the watched
+// memory and records it as the watch's value.
+func (s *WatchSet) hit(ctx context.Context, th *Thread) *WatchHit {
+	bp := th.Breakpoint
+	w, ok := s.Get(bp.ID)
+	if !ok {
+		// Set by another client or before the sidecar started.
+		w = Watch{ID: bp.ID, Expr: bp.WatchExpr, Type: bp.WatchType, Addr: bp.Addr}
+	}
+	h := &WatchHit{
+		Watch:       w,
+		ThreadID:    th.ID,
+		GoroutineID: th.GoroutineID,
+		File:        th.File,
+		Line:        th.Line,
+		Old:         w.Value,
+	}
+	if th.Function != nil {
+		h.Function = th.Function.Name
+	}
+
+	v, err := s.read(ctx, w, th.GoroutineID)
+	if err != nil {
+		h.Err = err.Error()
+		return h
+	}
+	h.New = formatValue(v)
+	h.Watch.Value = h.New
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if cur, ok := s.watches[w.ID]; ok {
+		cur.Value = h.New
+	}
+	return h
+}
+
+// read evaluates the watched memory by address on goroutine, since the
+// thread that accessed it is usually not in the scope the watchpoint was
+// set in, and falls back to the original expression and scope.
+func (s *WatchSet) read(ctx context.Context, w Watch, goroutine int64) (*Variable, error) {
+	cfg := DefaultLoadConfig()
+	if expr, ok := addrExpr(w.TypeName, w.Addr); ok {
+		if goroutine == 0 {
+			goroutine = -1
+		}
+		if v, err := s.pool.Eval(ctx, EvalScope{GoroutineID: goroutine}, expr, &cfg); err == nil {
+			return v, nil
+		}
+	}
+	return s.pool.Eval(ctx, w.Scope, w.Expr, &cfg)
+}
+
+// addrExpr returns an expression reading a typ value at addr, quoting the
+// package path of the type as Delve requires, e.g.
+// *(*"example.com/pkg".T)(0xc000010000).
+func addrExpr(typ string, addr uint64) (string, bool) {
+	if typ == "" || addr == 0 {
+		return "", false
+	}
+	if slash := strings.LastIndex(typ, "/"); slash >= 0 {
+		// Only a named type behind pointer and array prefixes can be quoted.
+		start := strings.IndexFunc(typ, func(r rune) bool {
+			return !strings.ContainsRune("*[]0123456789", r)
+		})
+		dot := strings.Index(typ[slash:], ".")
+		if start < 0 || dot < 0 || strings.ContainsAny(typ[start:slash], "[]( ") {
+			return "", false
+		}
+		end := slash + dot
+		typ = typ[:start] + strconv.Quote(typ[start:end]) + typ[end:]
+	}
+	return fmt.Sprintf("*(*%s)(%#x)", typ, addr), true
+}
@@ -0,0 +1,39 @@
+package debugger
+
+import "testing"
+
+func TestParseWatchType(t *testing.T) {
+	for _, typ := range []WatchType{WatchRead, WatchWrite, WatchRead | WatchWrite} {
+		got, err := ParseWatchType(typ.String())
+		if err != nil || got != typ {
+			t.Errorf("ParseWatchType(%q) = %v, %v", typ.String(), got, err)
+		}
+	}
+	if _, err := ParseWatchType("execute"); err == nil {
+		t.Error("ParseWatchType(execute) should fail")
+	}
+}
+
+func TestAddrExpr(t *testing.T) {
+	tests := []struct {
+		typ  string
+		want string
+		ok   bool
+	}{
+		{"int", "*(*int)(0xc000010000)", true},
+		{"main.Config", "*(*main.Config)(0xc000010000)", true},
+		{"*example.com/app/cache.Entry", `*(**"example.com/app/cache".Entry)(0xc000010000)`, true},
+		{"[4]example.com/app.ID", `*(*[4]"example.com/app".ID)(0xc000010000)`, true},
+		{"map[string]example.com/app.ID", "", false},
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := addrExpr(tt.typ, 0xc000010000)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("addrExpr(%q) = %q, %v, want %q, %v", tt.typ, got, ok, tt.want, tt.ok)
+		}
+	}
+	if _, ok := addrExpr("int", 0); ok {
+		t.Error("addrExpr without an address should fail")
+	}
+}
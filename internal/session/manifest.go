@@ -0,0 +1,165 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/kjbreil/dlc-sidecar/internal/debugger"
+)
+
+// DefaultManifestPath is where the sidecar keeps the breakpoint manifest,
+// relative to its working directory.
+const DefaultManifestPath = ".dlc-sidecar/breakpoints.json"
+
+// restoreTimeout bounds re-applying the manifest to a fresh Delve.
+const restoreTimeout = 30 * time.Second
+
+// Manifest is a project-local file recording each session's breakpoints, so
+// they survive restarts of Delve and of the sidecar. It is safe for
+// concurrent use.
+type Manifest struct {
+	path string
+	mu   sync.Mutex
+}
+
+// manifestFile is the manifest's on-disk form.
+type manifestFile struct {
+	Sessions map[string][]debugger.SavedBreakpoint `json:"sessions"`
+}
+
+// NewManifest returns a manifest stored at path. The file is created when
+// breakpoints are first saved.
+func NewManifest(path string) *Manifest {
+	return &Manifest{path: path}
+}
+
+// Path returns the manifest's file path.
+func (m *Manifest) Path() string {
+	return m.path
+}
+
+// Load returns the breakpoints saved for the named session. A missing file
+// holds no breakpoints.
+func (m *Manifest) Load(name string) ([]debugger.SavedBreakpoint, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	f, err := m.read()
+	if err != nil {
+		return nil, err
+	}
+	return f.Sessions[name], nil
+}
+
+// Save replaces the breakpoints saved for the named session, leaving other
+// sessions' entries as they are.
+func (m *Manifest) Save(name string, bps []debugger.SavedBreakpoint) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	f, err := m.read()
+	if err != nil {
+		return err
+	}
+	if len(bps) == 0 {
+		delete(f.Sessions, name)
+	} else {
+		f.Sessions[name] = bps
+	}
+
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(m.path), 0o755); err != nil {
+		return fmt.Errorf("save breakpoint manifest: %w", err)
+	}
+	// Write a sibling file and rename it over the manifest so a crash never
+	// leaves it half written.
+	tmp := m.path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("save breakpoint manifest: %w", err)
+	}
+	if err := os.Rename(tmp, m.path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("save breakpoint manifest: %w", err)
+	}
+	return nil
+}
+
+// read parses the manifest file. It must be called with m.mu held.
+func (m *Manifest) read() (*manifestFile, error) {
+	f := &manifestFile{}
+	data, err := os.ReadFile(m.path)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+	case err != nil:
+		return nil, fmt.Errorf("read breakpoint manifest: %w", err)
+	default:
+		if err := json.Unmarshal(data, f); err != nil {
+			return nil, fmt.Errorf("read breakpoint manifest %s: %w", m.path, err)
+		}
+	}
+	if f.Sessions == nil {
+		f.Sessions = make(map[string][]debugger.SavedBreakpoint)
+	}
+	return f, nil
+}
+
+// RestoreReport describes re-applying a session's saved breakpoints to a
+// fresh Delve instance.
+type RestoreReport struct {
+	At time.Time
+	// Restored holds the breakpoints created from the manifest.
+	Restored []*debugger.Breakpoint
+	// Failed holds the entries that could not be resolved or created. They
+	// stay in the manifest until the session's breakpoints next change.
+	Failed []RestoreFailure
+	// Err is set when the manifest could not be read.
+	Err error
+}
+
+// RestoreFailure is a manifest entry that could not be re-applied.
+type RestoreFailure struct {
+	Breakpoint debugger.SavedBreakpoint
+	Err        error
+}
+
+// restore creates the saved breakpoints with apply.
+func restore(ctx context.Context, apply func(context.Context, debugger.SavedBreakpoint) (*debugger.Breakpoint, error), saved []debugger.SavedBreakpoint) RestoreReport {
+	report := RestoreReport{At: time.Now()}
+	for _, sb := range saved {
+		bp, err := apply(ctx, sb)
+		if err != nil {
+			report.Failed = append(report.Failed, RestoreFailure{Breakpoint: sb, Err: err})
+			continue
+		}
+		report.Restored = append(report.Restored, bp)
+	}
+	return report
+}
+
+// applyBreakpoint creates one saved breakpoint, resolving its location
+// expression again if it has one.
+func applyBreakpoint(ctx context.Context, pool *debugger.Pool, sb debugger.SavedBreakpoint) (*debugger.Breakpoint, error) {
+	bp := sb.Breakpoint()
+	if sb.Location == "" {
+		bp.File, bp.Line = sb.File, sb.Line
+		return pool.CreateBreakpoint(ctx, bp)
+	}
+
+	locs, err := pool.FindLocation(ctx, debugger.EvalScope{GoroutineID: -1}, sb.Location, false)
+	if err != nil {
+		return nil, err
+	}
+	if len(locs) != 1 {
+		return nil, fmt.Errorf("location %s matched %d places, want 1", sb.Location, len(locs))
+	}
+	bp.Addr, bp.Addrs, bp.AddrPid = locs[0].PC, locs[0].PCs, locs[0].PCPids
+	return pool.CreateBreakpointExpr(ctx, bp, sb.Location)
+}
@@ -0,0 +1,164 @@
+package session
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kjbreil/dlc-sidecar/internal/debugger"
+	"github.com/kjbreil/dlc-sidecar/internal/debugger/debuggertest"
+)
+
+func TestManifest_SaveLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".dlc-sidecar", "breakpoints.json")
+	m := NewManifest(path)
+
+	if got, err := m.Load("default"); err != nil || got != nil {
+		t.Fatalf("Load before saving = %v, %v; want nothing", got, err)
+	}
+
+	a := []debugger.SavedBreakpoint{{File: "/src/main.go", Line: 12, Condition: "n > 1"}}
+	b := []debugger.SavedBreakpoint{{Location: "main.run", File: "/src/run.go", Line: 3, Tracepoint: true, Expressions: []string{"x"}}}
+	if err := m.Save("default", a); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Save("api", b); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := NewManifest(path).Load("api"); err != nil || len(got) != 1 || got[0].Location != "main.run" || got[0].Expressions[0] != "x" {
+		t.Fatalf("Load(api) = %+v, %v", got, err)
+	}
+
+	if err := m.Save("default", nil); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := m.Load("default"); got != nil {
+		t.Errorf("Load(default) after clearing = %+v", got)
+	}
+	if got, _ := m.Load("api"); len(got) != 1 {
+		t.Errorf("clearing default dropped api: %+v", got)
+	}
+
+	if err := os.WriteFile(path, []byte("{"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Load("api"); err == nil || !strings.Contains(err.Error(), "read breakpoint manifest") {
+		t.Errorf("Load of a corrupt manifest: err = %v", err)
+	}
+}
+
+func TestSession_RestoreBreakpoints(t *testing.T) {
+	delve := debuggertest.NewServer(t)
+	delve.SetLocation("main.run", debugger.Location{PC: 0x4a1f20, PCs: []uint64{0x4a1f20}, File: "/src/run.go", Line: 3})
+	manifest := NewManifest(filepath.Join(t.TempDir(), "breakpoints.json"))
+
+	m := NewManager(Limits{})
+	defer m.CloseAll()
+	m.PersistBreakpoints(manifest)
+	sess, err := m.Open("default", delve.Addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var reports []RestoreReport
+	sess.OnRestore(func(r RestoreReport) { reports = append(reports, r) })
+
+	ctx := context.Background()
+	if _, err := sess.Pool.CreateBreakpoint(ctx, &debugger.Breakpoint{File: "/src/main.go", Line: 12, Cond: "n > 1", Disabled: true}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sess.Pool.CreateBreakpointExpr(ctx, &debugger.Breakpoint{Addr: 0x4a1f20, Tracepoint: true, Variables: []string{"x"}}, "main.run"); err != nil {
+		t.Fatal(err)
+	}
+	if err := sess.SaveBreakpoints(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if len(reports) != 0 {
+		t.Fatalf("restored on the first connect to an empty manifest: %+v", reports)
+	}
+
+	// A dropped connection to the same Delve restores nothing.
+	delve.Disconnect()
+	if _, err := sess.Pool.ListBreakpoints(ctx, false); err != nil {
+		t.Fatal(err)
+	}
+	if len(reports) != 0 || len(delve.Breakpoints()) != 2 {
+		t.Fatalf("reconnect to the same Delve: reports %+v, breakpoints %+v", reports, delve.Breakpoints())
+	}
+
+	// A fresh Delve gets the saved breakpoints back.
+	delve.Reset()
+	if _, err := sess.Pool.State(ctx, false); err != nil {
+		t.Fatal(err)
+	}
+	if len(reports) != 1 || len(reports[0].Restored) != 2 || len(reports[0].Failed) != 0 {
+		t.Fatalf("reports = %+v", reports)
+	}
+	bps := delve.Breakpoints()
+	if bps[0].File != "/src/main.go" || bps[0].Line != 12 || bps[0].Cond != "n > 1" || !bps[0].Disabled {
+		t.Errorf("restored breakpoint = %+v", bps[0])
+	}
+	if bps[1].ExprString != "main.run" || !bps[1].Tracepoint || bps[1].Variables[0] != "x" || bps[1].Line != 3 {
+		t.Errorf("restored tracepoint = %+v", bps[1])
+	}
+
+	// Entries that no longer resolve are reported and kept in the manifest.
+	delve.SetLocation("main.run")
+	delve.Reset()
+	if _, err := sess.Pool.State(ctx, false); err != nil {
+		t.Fatal(err)
+	}
+	r := sess.LastRestore()
+	if len(r.Restored) != 1 || len(r.Failed) != 1 || r.Failed[0].Breakpoint.Location != "main.run" {
+		t.Fatalf("LastRestore() = %+v", r)
+	}
+	if saved, _ := manifest.Load("default"); len(saved) != 2 {
+		t.Errorf("manifest after a failed restore = %+v", saved)
+	}
+}
+
+func TestSession_Logpoints(t *testing.T) {
+	delve := debuggertest.NewServer(t)
+	manifest := NewManifest(filepath.Join(t.TempDir(), "breakpoints.json"))
+
+	m := NewManager(Limits{})
+	defer m.CloseAll()
+	m.PersistBreakpoints(manifest)
+	sess, err := m.Open("default", delve.Addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	if _, err := sess.ApplyBreakpoint(ctx, debugger.SavedBreakpoint{File: "/src/main.go", Line: 12, LogMessage: "n={n"}); err == nil {
+		t.Error("expected an error for an invalid log message")
+	}
+	bp, err := sess.ApplyBreakpoint(ctx, debugger.SavedBreakpoint{File: "/src/main.go", Line: 12, LogMessage: "n={n} m={m}"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bp.Tracepoint || len(bp.Variables) != 2 || bp.Variables[1] != "m" {
+		t.Errorf("logpoint breakpoint = %+v", bp)
+	}
+	if err := sess.SaveBreakpoints(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if saved, _ := manifest.Load("default"); len(saved) != 1 || saved[0].LogMessage != "n={n} m={m}" {
+		t.Fatalf("manifest = %+v", saved)
+	}
+
+	// Restoring registers the template again.
+	sess.Logs.RemoveTemplate(bp.ID)
+	delve.Reset()
+	if _, err := sess.Pool.State(ctx, false); err != nil {
+		t.Fatal(err)
+	}
+	bps := delve.Breakpoints()
+	if len(bps) != 1 {
+		t.Fatalf("restored = %+v", bps)
+	}
+	if tmpl, ok := sess.Logs.Template(bps[0].ID); !ok || tmpl.Message != "n={n} m={m}" {
+		t.Errorf("template of restored logpoint = %v, %v", tmpl, ok)
+	}
+}
@@ -0,0 +1,641 @@
+// Package session manages named debug sessions, each backed by its own
+// connection pool to a Delve instance.
+package session
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/kjbreil/dlc-sidecar/internal/debugger"
+)
+
+// DefaultName is the name of the session created at startup.
+const DefaultName = "default"
+
+var (
+	// ErrNotFound is returned when a named session does not exist.
+	ErrNotFound = errors.New("session not found")
+	// ErrExists is returned when opening a session under a name already in use.
+	ErrExists = errors.New("session already exists")
+	// ErrLimit is returned when opening a session would exceed MaxSessions.
+	ErrLimit = errors.New("session limit reached")
+	// ErrNoneSelected is returned when no session name is given and none is selected.
+	ErrNoneSelected = errors.New("no session selected; use open_session or select_session")
+)
+
+// Limits bounds the resources held by a Manager.
+type Limits struct {
+	// MaxSessions caps the number of open sessions; zero means unlimited.
+	MaxSessions int
+	// IdleTimeout is the default time a session opened with Open may go
+	// unused before the reaper closes it; zero disables reaping. Sessions
+	// that own a Delve process are only reaped after SetIdleTimeout, since
+	// closing them stops the program the user started.
+	IdleTimeout time.Duration
+}
+
+// ExitPolicy says what happens to a session's target when the session ends.
+type ExitPolicy string
+
+const (
+	// ExitClose ends a session as closing it does: launched programs are
+	// killed, attached processes are detached and left running, and a Delve
+	// started elsewhere is only disconnected from.
+	ExitClose ExitPolicy = ""
+	// ExitContinue detaches from the target and lets it run.
+	ExitContinue ExitPolicy = "continue"
+	// ExitKill detaches from the target and kills it.
+	ExitKill ExitPolicy = "kill"
+	// ExitHalt halts the target and disconnects, leaving it stopped under
+	// Delve for another client. A Delve started by the sidecar cannot outlive
+	// it and is stopped as on close.
+	ExitHalt ExitPolicy = "halt"
+)
+
+// ParseExitPolicy parses continue, kill or halt; the empty string is
+// ExitClose.
+func ParseExitPolicy(s string) (ExitPolicy, error) {
+	switch p := ExitPolicy(s); p {
+	case ExitClose, ExitContinue, ExitKill, ExitHalt:
+		return p, nil
+	}
+	return "", fmt.Errorf("invalid exit policy %q: want continue, kill or halt", s)
+}
+
+// detachTimeout bounds the Detach call made when closing a launched session.
+const detachTimeout = 5 * time.Second
+
+// Session is a named debug session backed by a connection pool.
+type Session struct {
+	Name    string
+	Pool    *debugger.Pool
+	Created time.Time
+	// Process is the Delve process started for this session, or nil when the
+	// session connects to a Delve instance started elsewhere.
+	Process *debugger.Process
+	// Monitor watches the connection to Delve, or is nil when the manager
+	// does not monitor health.
+	Monitor *debugger.Monitor
+	// Traces holds the tracepoint hits recorded while the target ran.
+	Traces *debugger.TraceBuffer
+	// Logs holds the templates of the session's logpoints and the lines
+	// they printed.
+	Logs *debugger.LogBuffer
+	// Watches tracks the watchpoints set through the sidecar and reports
+	// what triggered them in the states execution commands return.
+	Watches *debugger.WatchSet
+
+	mu          sync.Mutex
+	lastUsed    time.Time
+	idleTimeout time.Duration
+	killOnClose bool
+	manifest    *Manifest
+	restored    *RestoreReport
+	onRestore   []func(RestoreReport)
+}
+
+// LastUsed returns the time the session was last resolved by a tool call.
+func (s *Session) LastUsed() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.lastUsed
+}
+
+// IdleTimeout returns how long the session may sit unused before it is reaped.
+func (s *Session) IdleTimeout() time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.idleTimeout
+}
+
+// SetIdleTimeout overrides the manager's default idle timeout for this
+// session. Zero exempts the session from reaping.
+func (s *Session) SetIdleTimeout(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.idleTimeout = d
+}
+
+// KillOnClose reports whether closing a session that owns its Delve process
+// kills the target, rather than detaching and leaving it running.
+func (s *Session) KillOnClose() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.killOnClose
+}
+
+// SetKillOnClose sets whether closing the session kills the target. It
+// defaults to true for launched programs and false for attached processes.
+func (s *Session) SetKillOnClose(kill bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.killOnClose = kill
+}
+
+// PostMortem reports whether the session examines a core dump, in which case
+// the target cannot be executed. Only sessions opened with open_core are
+// known to be post-mortem: Delve reports no core target in its handshake,
+// so a dlv core started elsewhere looks like a live session.
+func (s *Session) PostMortem() bool {
+	return s.Process != nil && s.Process.Config.Mode == debugger.ModeCore
+}
+
+// Manifest returns the manifest the session's breakpoints are saved to, or
+// nil if they are not persisted.
+func (s *Session) Manifest() *Manifest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.manifest
+}
+
+// SavedBreakpoints returns the breakpoints currently set in Delve in the
+// form kept by the manifest.
+func (s *Session) SavedBreakpoints(ctx context.Context) ([]debugger.SavedBreakpoint, error) {
+	bps, err := s.Pool.ListBreakpoints(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+	var saved []debugger.SavedBreakpoint
+	for _, bp := range bps {
+		// Delve's internal breakpoints have negative IDs, and watchpoints
+		// only make sense for the goroutine that set them.
+		if bp.ID <= 0 || bp.WatchExpr != "" {
+			continue
+		}
+		sb := debugger.SaveBreakpoint(bp)
+		if t, ok := s.Logs.Template(bp.ID); ok {
+			sb.LogMessage = t.Message
+		}
+		saved = append(saved, sb)
+	}
+	return saved, nil
+}
+
+// SaveBreakpoints records the breakpoints currently set in Delve in the
+// manifest. It does nothing if breakpoints are not persisted.
+func (s *Session) SaveBreakpoints(ctx context.Context) error {
+	m := s.Manifest()
+	if m == nil {
+		return nil
+	}
+	saved, err := s.SavedBreakpoints(ctx)
+	if err != nil {
+		return err
+	}
+	return m.Save(s.Name, saved)
+}
+
+// ApplyBreakpoint creates sb in Delve, resolving its location expression if
+// it has one. A breakpoint with a log message becomes a logpoint.
+func (s *Session) ApplyBreakpoint(ctx context.Context, sb debugger.SavedBreakpoint) (*debugger.Breakpoint, error) {
+	if sb.LogMessage == "" {
+		return applyBreakpoint(ctx, s.Pool, sb)
+	}
+	t, err := debugger.ParseLogTemplate(sb.LogMessage)
+	if err != nil {
+		return nil, err
+	}
+	sb.Tracepoint, sb.Expressions = true, t.Expressions()
+	bp, err := applyBreakpoint(ctx, s.Pool, sb)
+	if err != nil {
+		return nil, err
+	}
+	s.Logs.SetTemplate(bp.ID, t)
+	return bp, nil
+}
+
+// recordTrace files a tracepoint hit as a logpoint line, or else as a trace
+// hit.
+func (s *Session) recordTrace(h debugger.TraceHit) {
+	if !s.Logs.Record(h) {
+		s.Traces.Add(h)
+	}
+}
+
+// LastRestore returns the report of the most recent restore of the saved
+// breakpoints, or nil if none has happened.
+func (s *Session) LastRestore() *RestoreReport {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.restored
+}
+
+// OnRestore registers fn to be called whenever saved breakpoints are
+// re-applied to a fresh Delve instance.
+func (s *Session) OnRestore(fn func(RestoreReport)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.onRestore = append(s.onRestore, fn)
+}
+
+// restoreBreakpoints re-applies the saved breakpoints when the pool connects
+// to a Delve instance that has none of its own, such as one restarted since
+// the breakpoints were set. A Delve that still holds breakpoints, because
+// only the connection dropped or another client set them, is left alone.
+func (s *Session) restoreBreakpoints() {
+	m := s.Manifest()
+	if m == nil {
+		return
+	}
+	if s.PostMortem() {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), restoreTimeout)
+	defer cancel()
+
+	saved, err := m.Load(s.Name)
+	if err != nil {
+		s.finishRestore(RestoreReport{At: time.Now(), Err: err})
+		return
+	}
+	if len(saved) == 0 {
+		return
+	}
+	bps, err := s.Pool.ListBreakpoints(ctx, false)
+	if err != nil {
+		s.finishRestore(RestoreReport{At: time.Now(), Err: err})
+		return
+	}
+	for _, bp := range bps {
+		if bp.ID > 0 {
+			return
+		}
+	}
+	s.finishRestore(restore(ctx, s.ApplyBreakpoint, saved))
+}
+
+// finishRestore records report and passes it to the OnRestore callbacks.
+func (s *Session) finishRestore(report RestoreReport) {
+	s.mu.Lock()
+	s.restored = &report
+	callbacks := append([]func(RestoreReport){}, s.onRestore...)
+	s.mu.Unlock()
+
+	for _, fn := range callbacks {
+		fn(report)
+	}
+}
+
+func (s *Session) touch(now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lastUsed = now
+}
+
+// idle reports whether the session has exceeded its idle timeout at now.
+// Sessions with an execution command in flight are never idle.
+func (s *Session) idle(now time.Time) bool {
+	s.mu.Lock()
+	timeout, last := s.idleTimeout, s.lastUsed
+	s.mu.Unlock()
+	if timeout <= 0 || s.Pool.CurrentRun() != nil {
+		return false
+	}
+	return now.Sub(last) > timeout
+}
+
+// close releases the session's resources. A Delve process owned by the
+// session is detached, killing the target if KillOnClose is set and leaving
+// it running otherwise, and then stopped.
+func (s *Session) close() error {
+	if s.Monitor != nil {
+		s.Monitor.Stop()
+	}
+	if s.Process != nil && !s.Process.Exited() {
+		ctx, cancel := context.WithTimeout(context.Background(), detachTimeout)
+		s.haltRunning(ctx)
+		s.Pool.Detach(ctx, s.KillOnClose())
+		cancel()
+	}
+	return s.release()
+}
+
+// haltRunning halts the target if it is running, since Delve only detaches
+// from a stopped one. Delve is asked whether the target runs, because a
+// blocking command such as next is not tracked as a run.
+func (s *Session) haltRunning(ctx context.Context) error {
+	running := s.Pool.CurrentRun() != nil
+	if st, err := s.Pool.State(ctx, true); err == nil && st.Running {
+		running = true
+	}
+	if !running {
+		return nil
+	}
+	_, err := s.Pool.Halt(ctx)
+	return err
+}
+
+// end finishes debugging according to policy and releases the session's
+// resources, halting a running target first.
+func (s *Session) end(policy ExitPolicy) error {
+	if policy == ExitClose {
+		return s.close()
+	}
+	if s.Monitor != nil {
+		s.Monitor.Stop()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), detachTimeout)
+	defer cancel()
+	var err error
+	if herr := s.haltRunning(ctx); herr != nil {
+		err = fmt.Errorf("halt: %w", herr)
+	}
+	if policy != ExitHalt {
+		if derr := s.Pool.Detach(ctx, policy == ExitKill); derr != nil {
+			err = errors.Join(err, fmt.Errorf("detach: %w", derr))
+		}
+	}
+	return errors.Join(err, s.release())
+}
+
+// release closes the session's connections and stops its Delve process, if
+// it owns one.
+func (s *Session) release() error {
+	err := s.Pool.Close()
+	if s.Process != nil {
+		if err2 := s.Process.Stop(); err == nil {
+			err = err2
+		}
+	}
+	return err
+}
+
+// Manager holds debug sessions keyed by name and tracks which one is selected.
+type Manager struct {
+	limits Limits
+
+	mu       sync.Mutex
+	sessions map[string]*Session
+	current  string
+	onOpen   []func(*Session)
+	health   *debugger.MonitorConfig
+	manifest *Manifest
+	stop     chan struct{}
+}
+
+// NewManager creates an empty session manager with the given limits.
+func NewManager(limits Limits) *Manager {
+	return &Manager{
+		limits:   limits,
+		sessions: make(map[string]*Session),
+	}
+}
+
+// OnOpen registers fn to be called for every session opened from now on. It
+// is also called immediately for sessions that are already open.
+func (m *Manager) OnOpen(fn func(*Session)) {
+	m.mu.Lock()
+	m.onOpen = append(m.onOpen, fn)
+	m.mu.Unlock()
+
+	for _, sess := range m.List() {
+		fn(sess)
+	}
+}
+
+// MonitorHealth gives every session opened from now on a health monitor
+// with the given settings. Monitors start after the OnOpen callbacks run, so
+// callbacks can subscribe to their first transition.
+func (m *Manager) MonitorHealth(cfg debugger.MonitorConfig) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.health = &cfg
+}
+
+// PersistBreakpoints saves the breakpoints of every session in m, under the
+// session's name, and re-applies them whenever a session connects to a fresh
+// Delve instance. It also applies to sessions that are already open.
+func (m *Manager) PersistBreakpoints(manifest *Manifest) {
+	m.mu.Lock()
+	m.manifest = manifest
+	m.mu.Unlock()
+
+	for _, sess := range m.List() {
+		sess.mu.Lock()
+		sess.manifest = manifest
+		sess.mu.Unlock()
+	}
+}
+
+// Open creates a session named name connected to addr. The first session
+// opened becomes the selected one.
+func (m *Manager) Open(name, addr string) (*Session, error) {
+	return m.open(name, addr, nil)
+}
+
+// OpenProcess creates a session named name connected to a Delve process
+// started by the sidecar. The session takes ownership of proc and stops it
+// when closed; if OpenProcess fails the caller still owns proc.
+func (m *Manager) OpenProcess(name string, proc *debugger.Process) (*Session, error) {
+	return m.open(name, proc.Addr, proc)
+}
+
+func (m *Manager) open(name, addr string, proc *debugger.Process) (*Session, error) {
+	if name == "" {
+		return nil, errors.New("session name must not be empty")
+	}
+
+	m.mu.Lock()
+	if _, ok := m.sessions[name]; ok {
+		m.mu.Unlock()
+		return nil, fmt.Errorf("%w: %s", ErrExists, name)
+	}
+	if m.limits.MaxSessions > 0 && len(m.sessions) >= m.limits.MaxSessions {
+		m.mu.Unlock()
+		return nil, fmt.Errorf("%w (%d)", ErrLimit, m.limits.MaxSessions)
+	}
+
+	now := time.Now()
+	idle := m.limits.IdleTimeout
+	if proc != nil {
+		idle = 0
+	}
+	sess := &Session{
+		Name:        name,
+		Pool:        debugger.NewPool(addr),
+		Process:     proc,
+		Created:     now,
+		lastUsed:    now,
+		idleTimeout: idle,
+		killOnClose: proc != nil && proc.Config.Mode != debugger.ModeAttach,
+		Traces:      debugger.NewTraceBuffer(debugger.DefaultTraceBufferSize),
+		Logs:        debugger.NewLogBuffer(debugger.DefaultLogBufferSize),
+		manifest:    m.manifest,
+	}
+	sess.Watches = debugger.NewWatchSet(sess.Pool)
+	sess.Pool.OnTrace(sess.recordTrace)
+	sess.Pool.OnState(sess.Watches.Observe)
+	sess.Pool.OnConnect(sess.restoreBreakpoints)
+	if m.health != nil {
+		sess.Monitor = debugger.NewMonitor(sess.Pool, *m.health)
+	}
+	m.sessions[name] = sess
+	if m.current == "" {
+		m.current = name
+	}
+	callbacks := append([]func(*Session){}, m.onOpen...)
+	m.mu.Unlock()
+
+	for _, fn := range callbacks {
+		fn(sess)
+	}
+	if sess.Monitor != nil {
+		sess.Monitor.Start()
+	}
+	return sess, nil
+}
+
+// Get returns the named session, or the selected one if name is empty, and
+// marks it as used.
+func (m *Manager) Get(name string) (*Session, error) {
+	m.mu.Lock()
+	if name == "" {
+		name = m.current
+	}
+	if name == "" {
+		m.mu.Unlock()
+		return nil, ErrNoneSelected
+	}
+	sess, ok := m.sessions[name]
+	m.mu.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+	sess.touch(time.Now())
+	return sess, nil
+}
+
+// Has reports whether a session with the given name is open. Unlike Get, it
+// does not count as a use of the session.
+func (m *Manager) Has(name string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, ok := m.sessions[name]
+	return ok
+}
+
+// Select makes the named session the default for tool calls without a
+// session argument.
+func (m *Manager) Select(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.sessions[name]; !ok {
+		return fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+	m.current = name
+	return nil
+}
+
+// Current returns the name of the selected session, or "" if none.
+func (m *Manager) Current() string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.current
+}
+
+// List returns all open sessions sorted by name.
+func (m *Manager) List() []*Session {
+	m.mu.Lock()
+	list := make([]*Session, 0, len(m.sessions))
+	for _, sess := range m.sessions {
+		list = append(list, sess)
+	}
+	m.mu.Unlock()
+
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// Close closes and removes the named session. If it was selected, no session
+// is selected afterwards.
+func (m *Manager) Close(name string) error {
+	return m.End(name, ExitClose)
+}
+
+// End ends the named session's debugging according to policy and removes
+// it. The session is removed even if ending it fails.
+func (m *Manager) End(name string, policy ExitPolicy) error {
+	m.mu.Lock()
+	sess, ok := m.sessions[name]
+	if !ok {
+		m.mu.Unlock()
+		return fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+	delete(m.sessions, name)
+	if m.current == name {
+		m.current = ""
+	}
+	m.mu.Unlock()
+
+	return sess.end(policy)
+}
+
+// CloseAll stops the reaper and closes every session.
+func (m *Manager) CloseAll() error {
+	return m.EndAll(ExitClose)
+}
+
+// EndAll stops the reaper and ends every session according to policy.
+func (m *Manager) EndAll(policy ExitPolicy) error {
+	m.mu.Lock()
+	if m.stop != nil {
+		close(m.stop)
+		m.stop = nil
+	}
+	sessions := m.sessions
+	m.sessions = make(map[string]*Session)
+	m.current = ""
+	m.mu.Unlock()
+
+	var errs []error
+	for _, sess := range sessions {
+		if err := sess.end(policy); err != nil {
+			errs = append(errs, fmt.Errorf("close session %s: %w", sess.Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Reap closes every session that has been idle longer than its timeout at now
+// and returns their names.
+func (m *Manager) Reap(now time.Time) []string {
+	var reaped []string
+	for _, sess := range m.List() {
+		if !sess.idle(now) {
+			continue
+		}
+		if err := m.Close(sess.Name); err == nil {
+			reaped = append(reaped, sess.Name)
+		}
+	}
+	return reaped
+}
+
+// StartReaper checks for idle sessions every interval until CloseAll is called.
+func (m *Manager) StartReaper(interval time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.stop != nil {
+		return
+	}
+	stop := make(chan struct{})
+	m.stop = stop
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case now := <-ticker.C:
+				m.Reap(now)
+			}
+		}
+	}()
+}
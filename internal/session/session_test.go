@@ -0,0 +1,314 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/kjbreil/dlc-sidecar/internal/debugger"
+	"github.com/kjbreil/dlc-sidecar/internal/debugger/debuggertest"
+)
+
+func TestMain(m *testing.M) {
+	debuggertest.MainDlv()
+	os.Exit(m.Run())
+}
+
+// launch starts a fake Delve relaying to delve and opens it as a session.
+func launch(t *testing.T, m *Manager, delve *debuggertest.Server, name string) *Session {
+	t.Helper()
+	delve.InstallDlv(t)
+	proc, err := debugger.Launch(context.Background(), debugger.LaunchConfig{Target: "./app"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sess, err := m.OpenProcess(name, proc)
+	if err != nil {
+		proc.Stop()
+		t.Fatal(err)
+	}
+	return sess
+}
+
+// waitRunning waits until delve runs a continue.
+func waitRunning(t *testing.T, delve *debuggertest.Server) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !delve.Running() {
+		if time.Now().After(deadline) {
+			t.Fatal("continue never started")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestManager_OpenSelectsFirst(t *testing.T) {
+	m := NewManager(Limits{})
+	defer m.CloseAll()
+
+	if _, err := m.Open("client", "localhost:2345"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Open("server", "localhost:2346"); err != nil {
+		t.Fatal(err)
+	}
+	if got := m.Current(); got != "client" {
+		t.Fatalf("Current() = %q, want %q", got, "client")
+	}
+
+	sess, err := m.Get("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sess.Name != "client" {
+		t.Fatalf("Get(\"\") = %q, want %q", sess.Name, "client")
+	}
+
+	if err := m.Select("server"); err != nil {
+		t.Fatal(err)
+	}
+	sess, err = m.Get("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sess.Pool.Addr() != "localhost:2346" {
+		t.Fatalf("selected session addr = %q, want %q", sess.Pool.Addr(), "localhost:2346")
+	}
+}
+
+func TestManager_OpenErrors(t *testing.T) {
+	m := NewManager(Limits{MaxSessions: 1})
+	defer m.CloseAll()
+
+	if _, err := m.Open("", "localhost:2345"); err == nil {
+		t.Fatal("expected error for empty name")
+	}
+	if _, err := m.Open("a", "localhost:2345"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Open("a", "localhost:2345"); !errors.Is(err, ErrExists) {
+		t.Fatalf("expected ErrExists, got %v", err)
+	}
+	if _, err := m.Open("b", "localhost:2345"); !errors.Is(err, ErrLimit) {
+		t.Fatalf("expected ErrLimit, got %v", err)
+	}
+}
+
+func TestManager_CloseClearsSelection(t *testing.T) {
+	m := NewManager(Limits{})
+	defer m.CloseAll()
+
+	if _, err := m.Open("a", "localhost:2345"); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Close("a"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Get(""); !errors.Is(err, ErrNoneSelected) {
+		t.Fatalf("expected ErrNoneSelected, got %v", err)
+	}
+	if _, err := m.Get("a"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+	if err := m.Close("a"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("second close: expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestManager_Reap(t *testing.T) {
+	m := NewManager(Limits{IdleTimeout: time.Minute})
+	defer m.CloseAll()
+
+	idle, err := m.Open("idle", "localhost:2345")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pinned, err := m.Open("pinned", "localhost:2346")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pinned.SetIdleTimeout(0)
+
+	reaped := m.Reap(idle.LastUsed().Add(30 * time.Second))
+	if len(reaped) != 0 {
+		t.Fatalf("reaped %v before timeout", reaped)
+	}
+
+	reaped = m.Reap(idle.LastUsed().Add(2 * time.Minute))
+	if len(reaped) != 1 || reaped[0] != "idle" {
+		t.Fatalf("Reap() = %v, want [idle]", reaped)
+	}
+	if _, err := m.Get("pinned"); err != nil {
+		t.Fatalf("pinned session was reaped: %v", err)
+	}
+}
+
+func TestManager_OnOpenSeesExistingSessions(t *testing.T) {
+	m := NewManager(Limits{})
+	defer m.CloseAll()
+
+	if _, err := m.Open("early", "localhost:2345"); err != nil {
+		t.Fatal(err)
+	}
+
+	var seen []string
+	m.OnOpen(func(s *Session) { seen = append(seen, s.Name) })
+
+	if _, err := m.Open("late", "localhost:2346"); err != nil {
+		t.Fatal(err)
+	}
+	if len(seen) != 2 || seen[0] != "early" || seen[1] != "late" {
+		t.Fatalf("OnOpen saw %v, want [early late]", seen)
+	}
+}
+
+func TestManager_MonitorHealth(t *testing.T) {
+	m := NewManager(Limits{})
+	defer m.CloseAll()
+
+	plain, err := m.Open("plain", "127.0.0.1:1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plain.Monitor != nil {
+		t.Fatal("sessions opened before MonitorHealth should not be monitored")
+	}
+
+	m.MonitorHealth(debugger.MonitorConfig{Interval: time.Hour, MinBackoff: time.Hour})
+	var subscribed bool
+	m.OnOpen(func(sess *Session) {
+		if sess.Monitor != nil {
+			sess.Monitor.OnChange(func(debugger.Transition) {})
+			subscribed = true
+		}
+	})
+	sess, err := m.Open("watched", "127.0.0.1:1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sess.Monitor == nil || !subscribed {
+		t.Fatal("expected a monitor, visible to OnOpen callbacks")
+	}
+
+	// Delve is unreachable, so the monitor's first ping fails.
+	deadline := time.Now().Add(5 * time.Second)
+	for sess.Monitor.Health().State != debugger.ConnDisconnected {
+		if time.Now().After(deadline) {
+			t.Fatalf("health = %+v, want disconnected", sess.Monitor.Health())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if err := m.Close("watched"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestParseExitPolicy(t *testing.T) {
+	for in, want := range map[string]ExitPolicy{"": ExitClose, "continue": ExitContinue, "kill": ExitKill, "halt": ExitHalt} {
+		if got, err := ParseExitPolicy(in); err != nil || got != want {
+			t.Errorf("ParseExitPolicy(%q) = %q, %v", in, got, err)
+		}
+	}
+	if _, err := ParseExitPolicy("stop"); err == nil {
+		t.Error("expected an error for an unknown policy")
+	}
+}
+
+func TestManager_EndAll(t *testing.T) {
+	for _, tc := range []struct {
+		policy       ExitPolicy
+		detach, kill bool
+	}{
+		{ExitClose, false, false},
+		{ExitContinue, true, false},
+		{ExitKill, true, true},
+		{ExitHalt, false, false},
+	} {
+		delve := debuggertest.NewServer(t)
+		m := NewManager(Limits{})
+		if _, err := m.Open(DefaultName, delve.Addr); err != nil {
+			t.Fatal(err)
+		}
+		if err := m.EndAll(tc.policy); err != nil {
+			t.Fatalf("EndAll(%q): %v", tc.policy, err)
+		}
+		if detach, kill := delve.Detached(); detach != tc.detach || kill != tc.kill {
+			t.Errorf("EndAll(%q): detached = %v, kill = %v, want %v, %v", tc.policy, detach, kill, tc.detach, tc.kill)
+		}
+		if len(m.List()) != 0 {
+			t.Errorf("EndAll(%q) left sessions open", tc.policy)
+		}
+	}
+}
+
+func TestManager_EndHaltsBlockingCommand(t *testing.T) {
+	delve := debuggertest.NewServer(t)
+	m := NewManager(Limits{})
+	sess, err := m.Open(DefaultName, delve.Addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := sess.Pool.Command(context.Background(), debugger.DebuggerCommand{Name: debugger.CmdContinue})
+		done <- err
+	}()
+	waitRunning(t, delve)
+
+	if err := m.EndAll(ExitHalt); err != nil {
+		t.Fatalf("EndAll: %v", err)
+	}
+	calls := delve.Calls("Command")
+	if last := calls[len(calls)-1].Args.(debugger.DebuggerCommand); last.Name != debugger.CmdHalt {
+		t.Errorf("last command = %s, want halt", last.Name)
+	}
+	if err := <-done; err != nil {
+		t.Errorf("blocking continue: %v", err)
+	}
+}
+
+func TestManager_CloseHaltsRunningTarget(t *testing.T) {
+	delve := debuggertest.NewServer(t)
+	m := NewManager(Limits{})
+	sess := launch(t, m, delve, DefaultName)
+	if _, err := sess.Pool.Start(debugger.DebuggerCommand{Name: debugger.CmdContinue}); err != nil {
+		t.Fatal(err)
+	}
+	waitRunning(t, delve)
+
+	if err := m.Close(DefaultName); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if detached, kill := delve.Detached(); !detached || !kill {
+		t.Errorf("detached = %v, kill = %v, want the launched target killed", detached, kill)
+	}
+	calls := delve.Calls("Command")
+	if last := calls[len(calls)-1].Args.(debugger.DebuggerCommand); last.Name != debugger.CmdHalt {
+		t.Errorf("last command = %s, want halt", last.Name)
+	}
+	if !sess.Process.Exited() {
+		t.Error("Delve still running after Close")
+	}
+}
+
+func TestManager_ProcessSessionsNotReapedByDefault(t *testing.T) {
+	delve := debuggertest.NewServer(t)
+	m := NewManager(Limits{IdleTimeout: time.Minute})
+	defer m.CloseAll()
+	ext, err := m.Open("external", delve.Addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	launched := launch(t, m, delve, "launched")
+	if ext.IdleTimeout() != time.Minute || launched.IdleTimeout() != 0 {
+		t.Fatalf("idle timeouts = %s, %s; want %s for open_session only", ext.IdleTimeout(), launched.IdleTimeout(), time.Minute)
+	}
+
+	reaped := m.Reap(time.Now().Add(time.Hour))
+	if len(reaped) != 1 || reaped[0] != "external" {
+		t.Fatalf("Reap() = %v, want [external]", reaped)
+	}
+}
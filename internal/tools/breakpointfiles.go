@@ -0,0 +1,224 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/kjbreil/dlc-sidecar/internal/bpformat"
+	"github.com/kjbreil/dlc-sidecar/internal/debugger"
+	"github.com/kjbreil/dlc-sidecar/internal/session"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+func registerBreakpointFiles(s *server.MCPServer, sessions *session.Manager) {
+	// import_breakpoints
+	addSessionTool(s, sessions, mcp.NewTool("import_breakpoints",
+		mcp.WithDescription("Set breakpoints read from a dlv --init script (break, trace, cond, on, toggle and clear commands) "+
+			"or the breakpoints array of a VS Code launch.json. Entries that cannot be set and lines that are not "+
+			"breakpoint commands are reported"),
+		mcp.WithString("path",
+			mcp.Required(),
+			mcp.Description("Path to the script or launch.json"),
+		),
+		mcp.WithString("format",
+			mcp.Description("dlv or vscode (default: vscode for .json files, otherwise dlv)"),
+			mcp.Enum(string(bpformat.FormatDlv), string(bpformat.FormatVSCode)),
+		),
+		withWorkspaceFolder(),
+	), makeImportBreakpoints())
+
+	// export_breakpoints
+	addSessionTool(s, sessions, mcp.NewTool("export_breakpoints",
+		mcp.WithDescription("Write the session's breakpoints as a dlv --init script or into the breakpoints array of a "+
+			"VS Code launch.json, and return the content. Settings the format cannot express are reported as warnings"),
+		mcp.WithString("path",
+			mcp.Description("File to write; an existing launch.json keeps its configurations but loses its comments "+
+				"(default: only return the content)"),
+		),
+		mcp.WithString("format",
+			mcp.Description("dlv or vscode (default: from the path's extension)"),
+			mcp.Enum(string(bpformat.FormatDlv), string(bpformat.FormatVSCode)),
+		),
+		withWorkspaceFolder(),
+	), makeExportBreakpoints())
+}
+
+// withWorkspaceFolder adds the parameter ${workspaceFolder} resolves to.
+func withWorkspaceFolder() mcp.ToolOption {
+	return mcp.WithString("workspaceFolder",
+		mcp.Description("Folder ${workspaceFolder} and relative paths in launch.json refer to "+
+			"(default: the parent of the .vscode directory, or the sidecar's working directory)"),
+	)
+}
+
+// fileFormat returns the format the request names, or the one path implies.
+func fileFormat(request mcp.CallToolRequest, path string) (bpformat.Format, error) {
+	if f := request.GetString("format", ""); f != "" {
+		return bpformat.ParseFormat(f)
+	}
+	if path == "" {
+		return "", errors.New("give format or path")
+	}
+	return bpformat.DetectFormat(path), nil
+}
+
+func makeImportBreakpoints() sessionHandler {
+	return func(ctx context.Context, request mcp.CallToolRequest, sess *session.Session) (*mcp.CallToolResult, error) {
+		path, err := request.RequireString("path")
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("path parameter error: %v", err)), nil
+		}
+		format, err := fileFormat(request, path)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("format parameter error: %v", err)), nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		var imp *bpformat.Import
+		if format == bpformat.FormatVSCode {
+			workspace := request.GetString("workspaceFolder", bpformat.DefaultWorkspace(path))
+			imp, err = bpformat.ParseLaunchJSON(data, workspace)
+		} else {
+			imp, err = bpformat.ParseInitScript(bytes.NewReader(data))
+		}
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		if len(imp.Entries) == 0 {
+			return mcp.NewToolResultError(fmt.Sprintf("no breakpoints found in %s (%d line(s) skipped)", path, len(imp.Skipped))), nil
+		}
+
+		created := make([]map[string]interface{}, 0, len(imp.Entries))
+		var failed []map[string]interface{}
+		for _, e := range imp.Entries {
+			bp, err := importBreakpoint(ctx, sess, e.Breakpoint)
+			if err != nil {
+				failed = append(failed, map[string]interface{}{
+					"source":   e.Source,
+					"location": e.Breakpoint.String(),
+					"error":    err.Error(),
+				})
+				continue
+			}
+			created = append(created, bp)
+		}
+
+		skipped := make([]map[string]interface{}, 0, len(imp.Skipped))
+		for _, sk := range imp.Skipped {
+			skipped = append(skipped, map[string]interface{}{
+				"source": sk.Source,
+				"text":   sk.Text,
+				"reason": sk.Reason,
+			})
+		}
+
+		msg := fmt.Sprintf("Imported %d breakpoint(s) from %s", len(created), path)
+		if len(failed) > 0 {
+			msg += fmt.Sprintf("; %d failed", len(failed))
+		}
+		if len(skipped) > 0 {
+			msg += fmt.Sprintf("; %d skipped", len(skipped))
+		}
+		result := map[string]interface{}{
+			"success":     len(failed) == 0,
+			"format":      string(format),
+			"breakpoints": created,
+			"message":     msg,
+		}
+		if len(failed) > 0 {
+			result["failed"] = failed
+		}
+		if len(skipped) > 0 {
+			result["skipped"] = skipped
+		}
+		return breakpointsChanged(ctx, sess, result)
+	}
+}
+
+// importBreakpoint validates an imported breakpoint as set_breakpoint would
+// and sets it.
+func importBreakpoint(ctx context.Context, sess *session.Session, sb debugger.SavedBreakpoint) (map[string]interface{}, error) {
+	if sb.Condition != "" {
+		if err := checkExpr(sb.Condition); err != nil {
+			return nil, err
+		}
+	}
+	for _, expr := range sb.Expressions {
+		if err := checkExpr(expr); err != nil {
+			return nil, err
+		}
+	}
+	var err error
+	if sb.HitCondition, err = parseHitCondition(sb.HitCondition); err != nil {
+		return nil, err
+	}
+	bp, err := sess.ApplyBreakpoint(ctx, sb)
+	if err != nil {
+		return nil, err
+	}
+	return breakpointInfo(bp), nil
+}
+
+func makeExportBreakpoints() sessionHandler {
+	return func(ctx context.Context, request mcp.CallToolRequest, sess *session.Session) (*mcp.CallToolResult, error) {
+		path := request.GetString("path", "")
+		format, err := fileFormat(request, path)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("format parameter error: %v", err)), nil
+		}
+		saved, err := sess.SavedBreakpoints(ctx)
+		if err != nil {
+			return rpcError(err), nil
+		}
+
+		var content []byte
+		var warnings []string
+		if format == bpformat.FormatVSCode {
+			var existing []byte
+			if path != "" {
+				existing, err = os.ReadFile(path)
+				if err != nil && !errors.Is(err, fs.ErrNotExist) {
+					return mcp.NewToolResultError(err.Error()), nil
+				}
+			}
+			workspace := request.GetString("workspaceFolder", bpformat.DefaultWorkspace(path))
+			if content, warnings, err = bpformat.UpdateLaunchJSON(existing, saved, workspace); err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+		} else {
+			content, warnings = bpformat.FormatInitScript(saved)
+		}
+
+		result := map[string]interface{}{
+			"success": true,
+			"format":  string(format),
+			"count":   len(saved),
+			"content": string(content),
+			"message": fmt.Sprintf("Exported %d breakpoint(s) as %s", len(saved), format),
+		}
+		if path != "" {
+			if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			if err := os.WriteFile(path, content, 0o644); err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			result["path"] = path
+			result["message"] = fmt.Sprintf("Exported %d breakpoint(s) to %s", len(saved), path)
+		}
+		if len(warnings) > 0 {
+			result["warnings"] = warnings
+			result["message"] = fmt.Sprintf("%s; %d setting(s) could not be exported", result["message"], len(warnings))
+		}
+		return jsonResult(result)
+	}
+}
@@ -0,0 +1,86 @@
+package tools
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kjbreil/dlc-sidecar/internal/debugger"
+)
+
+func TestImportBreakpoints(t *testing.T) {
+	h := newHarness(t)
+	h.delve.SetLocation("/src/main.go:12", debugger.Location{PC: 0x4a1000, File: "/src/main.go", Line: 12})
+	h.delve.SetLocation("main.run", debugger.Location{PC: 0x4a2000, File: "/src/run.go", Line: 3})
+	h.delve.SetLocation("main.bad", debugger.Location{PC: 0x4a3000, File: "/src/bad.go", Line: 1})
+
+	path := filepath.Join(t.TempDir(), "breakpoints.dlv")
+	script := "break /src/main.go:12\ncond 1 n > 1\ncond -hitcount 1 3\ntrace main.run\non 2 print x\n" +
+		"break main.gone\nbreak main.bad\ncond 4 a ==\nstep\n"
+	if err := os.WriteFile(path, []byte(script), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	out := h.callJSON("import_breakpoints", map[string]interface{}{"path": path})
+	if out["success"] != false || out["format"] != "dlv" || out["message"] != "Imported 2 breakpoint(s) from "+path+"; 2 failed; 1 skipped" {
+		t.Errorf("result = %v", out)
+	}
+	bps := h.delve.Breakpoints()
+	if len(bps) != 2 || bps[0].Cond != "n > 1" || bps[0].HitCond != "== 3" || bps[0].ExprString != "/src/main.go:12" {
+		t.Fatalf("breakpoints = %+v", bps)
+	}
+	if !bps[1].Tracepoint || bps[1].Variables[0] != "x" || bps[1].Line != 3 {
+		t.Errorf("tracepoint = %+v", bps[1])
+	}
+
+	failed := out["failed"].([]interface{})
+	if f := failed[0].(map[string]interface{}); f["source"] != "line 6" || !strings.Contains(f["error"].(string), `location "main.gone" not found`) {
+		t.Errorf("failed[0] = %v", f)
+	}
+	if f := failed[1].(map[string]interface{}); f["location"] != "main.bad" || !strings.Contains(f["error"].(string), "invalid expression") {
+		t.Errorf("failed[1] = %v", f)
+	}
+	if s := out["skipped"].([]interface{})[0].(map[string]interface{}); s["text"] != "step" || s["source"] != "line 9" {
+		t.Errorf("skipped = %v", s)
+	}
+
+	h.callError("import_breakpoints", map[string]interface{}{"path": path, "format": "gdb"}, "invalid format")
+	h.callError("import_breakpoints", map[string]interface{}{"path": filepath.Join(t.TempDir(), "missing")}, "no such file")
+	empty := filepath.Join(t.TempDir(), "launch.json")
+	if err := os.WriteFile(empty, []byte(`{"configurations": []}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	h.callError("import_breakpoints", map[string]interface{}{"path": empty}, "no breakpoints found")
+}
+
+func TestExportBreakpoints(t *testing.T) {
+	h := newHarness(t)
+	h.callJSON("set_breakpoint", map[string]interface{}{"file": "/src/main.go", "line": 12, "condition": "n > 1"})
+	h.callJSON("set_tracepoint", map[string]interface{}{"file": "/src/run.go", "line": 3, "expressions": []interface{}{"x"}, "locals": true})
+
+	out := h.callJSON("export_breakpoints", map[string]interface{}{"format": "dlv"})
+	want := "break /src/main.go:12\ncond 1 n > 1\ntrace /src/run.go:3\non 2 print x\n"
+	if !strings.HasSuffix(out["content"].(string), want) || out["count"] != float64(2) || out["path"] != nil {
+		t.Errorf("dlv export = %v", out)
+	}
+	if w := out["warnings"].([]interface{}); len(w) != 1 || !strings.Contains(w[0].(string), "args and locals") {
+		t.Errorf("warnings = %v", w)
+	}
+
+	workspace := t.TempDir()
+	path := filepath.Join(workspace, ".vscode", "launch.json")
+	out = h.callJSON("export_breakpoints", map[string]interface{}{"path": path, "workspaceFolder": "/src"})
+	if out["format"] != "vscode" || out["path"] != path {
+		t.Errorf("vscode export = %v", out)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"path": "${workspaceFolder}/main.go"`) || !strings.Contains(string(data), `"logMessage": "x={x}"`) {
+		t.Errorf("launch.json:\n%s", data)
+	}
+
+	h.callError("export_breakpoints", nil, "give format or path")
+}
@@ -4,43 +4,160 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"go/parser"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/kjbreil/dlc-sidecar/internal/debugger"
+	"github.com/kjbreil/dlc-sidecar/internal/session"
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
 )
 
-func registerBreakpoints(s *server.MCPServer, pool *debugger.Pool) {
+func registerBreakpoints(s *server.MCPServer, sessions *session.Manager) {
 	// set_breakpoint
-	s.AddTool(mcp.NewTool("set_breakpoint",
-		mcp.WithDescription("Set a breakpoint in the Delve debugger at the specified file and line"),
-		mcp.WithString("file",
-			mcp.Required(),
-			mcp.Description("Absolute path to the source file"),
+	addSessionTool(s, sessions, mcp.NewTool("set_breakpoint",
+		mcp.WithDescription("Set a breakpoint in the Delve debugger at a file and line or at a location expression"),
+		withBreakpointLocation(),
+		mcp.WithString("condition",
+			mcp.Description("Go expression; the breakpoint only stops when it evaluates to true, e.g. \"i == 42 && err != nil\""),
 		),
-		mcp.WithNumber("line",
-			mcp.Required(),
-			mcp.Description("Line number where the breakpoint should be set"),
+		mcp.WithString("hitCondition",
+			mcp.Description("Stop only when the hit count satisfies this: an operator (==, !=, >, >=, <, <=, %) and a number, "+
+				"e.g. \"> 5\", \"== 10\" or \"% 3\" for every third hit. A bare number means =="),
+		),
+		mcp.WithBoolean("hitConditionPerGoroutine",
+			mcp.Description("Count hits separately for each goroutine when checking hitCondition (default: false)"),
 		),
-	), makeSetBreakpoint(pool))
+	), makeSetBreakpoint())
 
 	// clear_breakpoint
-	s.AddTool(mcp.NewTool("clear_breakpoint",
-		mcp.WithDescription("Clear a breakpoint by its ID"),
+	addSessionTool(s, sessions, mcp.NewTool("clear_breakpoint",
+		mcp.WithDescription("Clear a breakpoint or watchpoint by its ID"),
 		mcp.WithNumber("id",
 			mcp.Required(),
-			mcp.Description("ID of the breakpoint to clear"),
+			mcp.Description("ID of the breakpoint or watchpoint to clear"),
 		),
-	), makeClearBreakpoint(pool))
+	), makeClearBreakpoint())
 
 	// list_breakpoints
-	s.AddTool(mcp.NewTool("list_breakpoints",
-		mcp.WithDescription("List all breakpoints currently set in the debugger"),
-	), makeListBreakpoints(pool))
+	addSessionTool(s, sessions, mcp.NewTool("list_breakpoints",
+		mcp.WithDescription("List all breakpoints and watchpoints currently set in the debugger"),
+	), makeListBreakpoints())
+
+	// toggle_breakpoint
+	addSessionTool(s, sessions, mcp.NewTool("toggle_breakpoint",
+		mcp.WithDescription("Enable or disable a breakpoint without clearing it, keeping its conditions and hit counts"),
+		mcp.WithNumber("id",
+			mcp.Required(),
+			mcp.Description("ID of the breakpoint to toggle"),
+		),
+		mcp.WithBoolean("enabled",
+			mcp.Description("State to put the breakpoint in; it is left alone if already there (default: flip the current state)"),
+		),
+	), makeToggleBreakpoint())
+
+	// amend_breakpoint
+	addSessionTool(s, sessions, mcp.NewTool("amend_breakpoint",
+		mcp.WithDescription("Change an existing breakpoint in place, keeping its ID and hit counts. Only the parameters given are changed"),
+		mcp.WithNumber("id",
+			mcp.Required(),
+			mcp.Description("ID of the breakpoint to amend"),
+		),
+		mcp.WithString("condition",
+			mcp.Description("New Go condition expression; an empty string removes the condition"),
+		),
+		mcp.WithString("hitCondition",
+			mcp.Description("New hit condition such as \"> 5\" or \"% 3\"; an empty string removes it"),
+		),
+		mcp.WithBoolean("hitConditionPerGoroutine",
+			mcp.Description("Count hits separately for each goroutine when checking hitCondition"),
+		),
+		mcp.WithBoolean("tracepoint",
+			mcp.Description("Make the breakpoint a tracepoint that records data without stopping, or a stopping breakpoint again"),
+		),
+		mcp.WithArray("expressions",
+			mcp.Description("Expressions to evaluate and record on each hit, replacing the current ones"),
+			mcp.WithStringItems(),
+		),
+		mcp.WithBoolean("args",
+			mcp.Description("Record the function arguments on each hit"),
+		),
+		mcp.WithBoolean("locals",
+			mcp.Description("Record the local variables on each hit"),
+		),
+		mcp.WithNumber("stackDepth",
+			mcp.Description("Number of stack frames to record on each hit"),
+		),
+	), makeAmendBreakpoint())
+
+	sessions.OnOpen(func(sess *session.Session) {
+		sess.OnRestore(func(r session.RestoreReport) {
+			level, msg := restoreMessage(sess, r)
+			s.SendNotificationToAllClients(logNotification, map[string]interface{}{
+				"level":  level,
+				"logger": "dlc-sidecar",
+				"data":   msg,
+			})
+		})
+	})
 }
 
-func makeSetBreakpoint(pool *debugger.Pool) server.ToolHandlerFunc {
-	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+func makeSetBreakpoint() sessionHandler {
+	return func(ctx context.Context, request mcp.CallToolRequest, sess *session.Session) (*mcp.CallToolResult, error) {
+		want := &debugger.Breakpoint{
+			HitCondPerG: request.GetBool("hitConditionPerGoroutine", false),
+		}
+		if want.Cond = request.GetString("condition", ""); want.Cond != "" {
+			if err := checkExpr(want.Cond); err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("condition parameter error: %v", err)), nil
+			}
+		}
+		var err error
+		if want.HitCond, err = parseHitCondition(request.GetString("hitCondition", "")); err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("hitCondition parameter error: %v", err)), nil
+		}
+
+		return setBreakpoints(ctx, request, sess, want, "Breakpoint", nil)
+	}
+}
+
+// withBreakpointLocation adds the parameters naming where a breakpoint goes:
+// a file and line, or a location expression.
+func withBreakpointLocation() mcp.ToolOption {
+	return func(t *mcp.Tool) {
+		mcp.WithString("file",
+			mcp.Description("Absolute path to the source file; required unless location is set"),
+		)(t)
+		mcp.WithNumber("line",
+			mcp.Description("Line number in file; required unless location is set"),
+		)(t)
+		mcp.WithString("location",
+			mcp.Description("Delve location expression instead of file and line: a function (main.main, pkg.(*Server).Handle), "+
+				"file:line, a line offset from the current position (+3, -2), an address (*0x4a1f20) or a regex "+
+				"(/^api\\..*Handler$/), which sets one breakpoint per matching function"),
+		)(t)
+	}
+}
+
+// setBreakpoints creates breakpoints like want where the request says:
+// at file and line, or at every location a location expression resolves to.
+// kind names them in messages. created, if not nil, is called with each
+// breakpoint created and the info describing it.
+func setBreakpoints(ctx context.Context, request mcp.CallToolRequest, sess *session.Session, want *debugger.Breakpoint, kind string,
+	created func(*debugger.Breakpoint, map[string]interface{})) (*mcp.CallToolResult, error) {
+	describe := func(bp *debugger.Breakpoint) map[string]interface{} {
+		info := breakpointInfo(bp)
+		if created != nil {
+			created(bp, info)
+		}
+		return info
+	}
+
+	location := request.GetString("location", "")
+	if location == "" {
 		file, err := request.RequireString("file")
 		if err != nil {
 			return mcp.NewToolResultError(fmt.Sprintf("file parameter error: %v", err)), nil
@@ -49,60 +166,407 @@ func makeSetBreakpoint(pool *debugger.Pool) server.ToolHandlerFunc {
 		if err != nil {
 			return mcp.NewToolResultError(fmt.Sprintf("line parameter error: %v", err)), nil
 		}
+		want.File, want.Line = file, line
 
-		req := debugger.CreateBreakpointIn{
-			Breakpoint: debugger.Breakpoint{
-				File: file,
-				Line: line,
-			},
-		}
-		var resp debugger.CreateBreakpointOut
-		if err := pool.Call("CreateBreakpoint", req, &resp); err != nil {
-			return mcp.NewToolResultError(fmt.Sprintf("RPC call failed: %v", err)), nil
+		bp, err := sess.Pool.CreateBreakpoint(ctx, want)
+		if err != nil {
+			return rpcError(err), nil
 		}
-
-		return jsonResult(map[string]interface{}{
+		return breakpointsChanged(ctx, sess, map[string]interface{}{
 			"success":    true,
-			"breakpoint": resp.Breakpoint,
-			"message":    fmt.Sprintf("Breakpoint set at %s:%d (ID: %d)", file, line, resp.Breakpoint.ID),
+			"breakpoint": describe(bp),
+			"message":    fmt.Sprintf("%s set at %s:%d (ID: %d)", kind, file, line, bp.ID),
 		})
 	}
+	args := request.GetArguments()
+	if _, ok := args["file"]; ok {
+		return mcp.NewToolResultError("location parameter error: give either location or file and line"), nil
+	}
+	if _, ok := args["line"]; ok {
+		return mcp.NewToolResultError("location parameter error: give either location or file and line"), nil
+	}
+
+	locs, err := sess.Pool.FindLocation(ctx, debugger.EvalScope{GoroutineID: -1}, location, false)
+	if err != nil {
+		return rpcError(err), nil
+	}
+	if len(locs) == 0 {
+		return mcp.NewToolResultError(fmt.Sprintf("location %s matched no code", location)), nil
+	}
+
+	var set []map[string]interface{}
+	var failed []map[string]interface{}
+	for _, loc := range locs {
+		bp := *want
+		bp.Addr, bp.Addrs, bp.AddrPid = loc.PC, loc.PCs, loc.PCPids
+		// A single match keeps its expression so Delve can resolve it again
+		// after a rebuild; a regex would resolve to every match.
+		expr := ""
+		if len(locs) == 1 {
+			expr = location
+		}
+		got, err := sess.Pool.CreateBreakpointExpr(ctx, &bp, expr)
+		if err != nil {
+			info := locationInfo(loc)
+			info["error"] = err.Error()
+			failed = append(failed, info)
+			continue
+		}
+		set = append(set, describe(got))
+	}
+	if len(set) == 0 {
+		return mcp.NewToolResultError(fmt.Sprintf("no %s could be set at %s: %v", strings.ToLower(kind), location, failed[0]["error"])), nil
+	}
+
+	result := map[string]interface{}{
+		"success":     true,
+		"location":    location,
+		"breakpoints": set,
+	}
+	if len(set) == 1 {
+		bp := set[0]
+		result["breakpoint"] = bp
+		result["message"] = fmt.Sprintf("%s set at %s (%s:%d) (ID: %d)", kind, location, bp["file"], bp["line"], bp["id"])
+	} else {
+		result["message"] = fmt.Sprintf("%d %ss set for %s", len(set), strings.ToLower(kind), location)
+	}
+	if len(failed) > 0 {
+		result["failed"] = failed
+		result["message"] = fmt.Sprintf("%s; %d location(s) failed", result["message"], len(failed))
+	}
+	return breakpointsChanged(ctx, sess, result)
+}
+
+// locationInfo describes a resolved source location.
+func locationInfo(loc debugger.Location) map[string]interface{} {
+	info := map[string]interface{}{
+		"file": loc.File,
+		"line": loc.Line,
+		"addr": fmt.Sprintf("%#x", loc.PC),
+	}
+	if loc.Function != nil {
+		info["function"] = loc.Function.Name
+	}
+	return info
 }
 
-func makeClearBreakpoint(pool *debugger.Pool) server.ToolHandlerFunc {
-	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+func makeClearBreakpoint() sessionHandler {
+	return func(ctx context.Context, request mcp.CallToolRequest, sess *session.Session) (*mcp.CallToolResult, error) {
 		id, err := request.RequireInt("id")
 		if err != nil {
 			return mcp.NewToolResultError(fmt.Sprintf("id parameter error: %v", err)), nil
 		}
 
-		req := debugger.ClearBreakpointIn{Id: id}
-		var resp debugger.ClearBreakpointOut
-		if err := pool.Call("ClearBreakpoint", req, &resp); err != nil {
-			return mcp.NewToolResultError(fmt.Sprintf("RPC call failed: %v", err)), nil
+		if _, err := sess.Pool.ClearBreakpoint(ctx, id); err != nil {
+			return rpcError(err), nil
 		}
+		sess.Watches.Remove(id)
+		sess.Logs.RemoveTemplate(id)
 
-		return jsonResult(map[string]interface{}{
+		return breakpointsChanged(ctx, sess, map[string]interface{}{
 			"success": true,
 			"message": fmt.Sprintf("Breakpoint %d cleared", id),
 		})
 	}
 }
 
-func makeListBreakpoints(pool *debugger.Pool) server.ToolHandlerFunc {
-	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-		req := debugger.ListBreakpointsIn{All: true}
-		var resp debugger.ListBreakpointsOut
-		if err := pool.Call("ListBreakpoints", req, &resp); err != nil {
-			return mcp.NewToolResultError(fmt.Sprintf("RPC call failed: %v", err)), nil
+func makeListBreakpoints() sessionHandler {
+	return func(ctx context.Context, request mcp.CallToolRequest, sess *session.Session) (*mcp.CallToolResult, error) {
+		bps, err := sess.Pool.ListBreakpoints(ctx, true)
+		if err != nil {
+			return rpcError(err), nil
+		}
+
+		list := make([]map[string]interface{}, 0, len(bps))
+		for _, bp := range bps {
+			info := breakpointInfo(bp)
+			if w, ok := sess.Watches.Get(bp.ID); ok {
+				addWatchInfo(info, w)
+			}
+			if t, ok := sess.Logs.Template(bp.ID); ok {
+				info["logMessage"] = t.Message
+			}
+			list = append(list, info)
+		}
+		result := map[string]interface{}{
+			"breakpoints": list,
+		}
+		if m := sess.Manifest(); m != nil {
+			result["manifest"] = m.Path()
+		}
+		if r := sess.LastRestore(); r != nil {
+			result["restore"] = restoreInfo(*r)
+		}
+		return jsonResult(result)
+	}
+}
+
+func makeToggleBreakpoint() sessionHandler {
+	return func(ctx context.Context, request mcp.CallToolRequest, sess *session.Session) (*mcp.CallToolResult, error) {
+		id, err := request.RequireInt("id")
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("id parameter error: %v", err)), nil
+		}
+
+		bp, err := sess.Pool.GetBreakpoint(ctx, id)
+		if err != nil {
+			return rpcError(err), nil
+		}
+		if enabled, err := request.RequireBool("enabled"); err != nil || enabled == bp.Disabled {
+			if bp, err = sess.Pool.ToggleBreakpoint(ctx, id); err != nil {
+				return rpcError(err), nil
+			}
+		}
+
+		state := "enabled"
+		if bp.Disabled {
+			state = "disabled"
+		}
+		return breakpointsChanged(ctx, sess, map[string]interface{}{
+			"success":    true,
+			"breakpoint": breakpointInfo(bp),
+			"message":    fmt.Sprintf("Breakpoint %d %s", id, state),
+		})
+	}
+}
+
+func makeAmendBreakpoint() sessionHandler {
+	return func(ctx context.Context, request mcp.CallToolRequest, sess *session.Session) (*mcp.CallToolResult, error) {
+		id, err := request.RequireInt("id")
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("id parameter error: %v", err)), nil
+		}
+
+		bp, err := sess.Pool.GetBreakpoint(ctx, id)
+		if err != nil {
+			return rpcError(err), nil
+		}
+
+		args := request.GetArguments()
+		var changed []string
+		if _, ok := args["condition"]; ok {
+			bp.Cond = request.GetString("condition", "")
+			if bp.Cond != "" {
+				if err := checkExpr(bp.Cond); err != nil {
+					return mcp.NewToolResultError(fmt.Sprintf("condition parameter error: %v", err)), nil
+				}
+			}
+			changed = append(changed, "condition")
+		}
+		if _, ok := args["hitCondition"]; ok {
+			if bp.HitCond, err = parseHitCondition(request.GetString("hitCondition", "")); err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("hitCondition parameter error: %v", err)), nil
+			}
+			changed = append(changed, "hitCondition")
+		}
+		if v, err := request.RequireBool("hitConditionPerGoroutine"); err == nil {
+			bp.HitCondPerG = v
+			changed = append(changed, "hitConditionPerGoroutine")
+		}
+		if v, err := request.RequireBool("tracepoint"); err == nil {
+			bp.Tracepoint = v
+			changed = append(changed, "tracepoint")
+		}
+		if _, ok := args["expressions"]; ok {
+			bp.Variables = request.GetStringSlice("expressions", nil)
+			for _, expr := range bp.Variables {
+				if err := checkExpr(expr); err != nil {
+					return mcp.NewToolResultError(fmt.Sprintf("expressions parameter error: %v", err)), nil
+				}
+			}
+			changed = append(changed, "expressions")
+		}
+		if v, err := request.RequireBool("args"); err == nil {
+			bp.LoadArgs = nil
+			if v {
+				cfg := debugger.DefaultLoadConfig()
+				bp.LoadArgs = &cfg
+			}
+			changed = append(changed, "args")
+		}
+		if v, err := request.RequireBool("locals"); err == nil {
+			bp.LoadLocals = nil
+			if v {
+				cfg := debugger.DefaultLoadConfig()
+				bp.LoadLocals = &cfg
+			}
+			changed = append(changed, "locals")
+		}
+		if v, err := request.RequireInt("stackDepth"); err == nil {
+			if v < 0 {
+				return mcp.NewToolResultError("stackDepth parameter error: must not be negative"), nil
+			}
+			bp.Stacktrace = v
+			changed = append(changed, "stackDepth")
+		}
+		if len(changed) == 0 {
+			return mcp.NewToolResultError("nothing to amend; give at least one of condition, hitCondition, " +
+				"hitConditionPerGoroutine, tracepoint, expressions, args, locals or stackDepth"), nil
+		}
+		if _, ok := sess.Logs.Template(id); ok {
+			// A logpoint's expressions are those of its message.
+			for _, field := range changed {
+				if field == "tracepoint" || field == "expressions" {
+					return mcp.NewToolResultError(fmt.Sprintf("breakpoint %d is a logpoint and its %s cannot be amended; "+
+						"clear it and set a new logpoint to change its message", id, field)), nil
+				}
+			}
 		}
 
-		return jsonResult(map[string]interface{}{
-			"breakpoints": resp.Breakpoints,
+		if err := sess.Pool.AmendBreakpoint(ctx, bp); err != nil {
+			return rpcError(err), nil
+		}
+		if bp, err = sess.Pool.GetBreakpoint(ctx, id); err != nil {
+			return rpcError(err), nil
+		}
+
+		return breakpointsChanged(ctx, sess, map[string]interface{}{
+			"success":    true,
+			"breakpoint": breakpointInfo(bp),
+			"changed":    changed,
+			"message":    fmt.Sprintf("Breakpoint %d amended: %s", id, strings.Join(changed, ", ")),
 		})
 	}
 }
 
+// breakpointsChanged saves the session's breakpoints to the manifest after
+// a tool changed them and returns result. Failing to save is reported as a
+// warning, since the change itself took effect.
+func breakpointsChanged(ctx context.Context, sess *session.Session, result map[string]interface{}) (*mcp.CallToolResult, error) {
+	if err := sess.SaveBreakpoints(ctx); err != nil {
+		result["warning"] = fmt.Sprintf("breakpoints not saved to %s: %v", sess.Manifest().Path(), err)
+	}
+	return jsonResult(result)
+}
+
+// restoreInfo describes re-applying saved breakpoints to a fresh Delve.
+func restoreInfo(r session.RestoreReport) map[string]interface{} {
+	info := map[string]interface{}{
+		"at":       r.At.Format(time.RFC3339),
+		"restored": len(r.Restored),
+	}
+	if r.Err != nil {
+		info["error"] = r.Err.Error()
+	}
+	if len(r.Failed) > 0 {
+		failed := make([]map[string]interface{}, 0, len(r.Failed))
+		for _, f := range r.Failed {
+			failed = append(failed, map[string]interface{}{
+				"location": f.Breakpoint.String(),
+				"error":    f.Err.Error(),
+			})
+		}
+		info["failed"] = failed
+	}
+	return info
+}
+
+// restoreMessage returns the log level and message for a restore of saved
+// breakpoints.
+func restoreMessage(sess *session.Session, r session.RestoreReport) (mcp.LoggingLevel, string) {
+	if r.Err != nil {
+		return mcp.LoggingLevelWarning, fmt.Sprintf("session %s: could not restore saved breakpoints: %v", sess.Name, r.Err)
+	}
+	msg := fmt.Sprintf("session %s: restored %d saved breakpoint(s) on a fresh Delve", sess.Name, len(r.Restored))
+	if len(r.Failed) == 0 {
+		return mcp.LoggingLevelInfo, msg
+	}
+	var failed []string
+	for _, f := range r.Failed {
+		failed = append(failed, fmt.Sprintf("%s (%v)", f.Breakpoint, f.Err))
+	}
+	return mcp.LoggingLevelWarning, fmt.Sprintf("%s; %d failed: %s", msg, len(r.Failed), strings.Join(failed, "; "))
+}
+
+// breakpointInfo describes a breakpoint, including its conditions and how
+// often it has been hit.
+func breakpointInfo(bp *debugger.Breakpoint) map[string]interface{} {
+	info := map[string]interface{}{
+		"id":       bp.ID,
+		"file":     bp.File,
+		"line":     bp.Line,
+		"hitCount": bp.TotalHitCount,
+	}
+	if bp.Addr != 0 {
+		info["addr"] = fmt.Sprintf("%#x", bp.Addr)
+	}
+	if bp.ExprString != "" {
+		info["location"] = bp.ExprString
+	}
+	if bp.Name != "" {
+		info["name"] = bp.Name
+	}
+	if bp.FunctionName != "" {
+		info["function"] = bp.FunctionName
+	}
+	if bp.Cond != "" {
+		info["condition"] = bp.Cond
+	}
+	if bp.HitCond != "" {
+		info["hitCondition"] = bp.HitCond
+		if bp.HitCondPerG {
+			info["hitConditionPerGoroutine"] = true
+		}
+	}
+	if len(bp.HitCount) > 0 {
+		info["hitCountByGoroutine"] = bp.HitCount
+	}
+	if bp.Tracepoint {
+		info["tracepoint"] = true
+		if len(bp.Variables) > 0 {
+			info["expressions"] = bp.Variables
+		}
+		if bp.Stacktrace > 0 {
+			info["stackDepth"] = bp.Stacktrace
+		}
+	}
+	if bp.WatchExpr != "" {
+		info["watchExpr"] = bp.WatchExpr
+		info["watchType"] = bp.WatchType.String()
+	}
+	if bp.Disabled {
+		info["disabled"] = true
+	}
+	return info
+}
+
+// checkExpr checks that expr, such as a breakpoint condition, is a Go
+// expression. Delve only evaluates it once the breakpoint is reached, so
+// syntax errors would otherwise go unnoticed until then.
+func checkExpr(expr string) error {
+	if _, err := parser.ParseExpr(expr); err != nil {
+		return fmt.Errorf("invalid expression %q: %v", expr, err)
+	}
+	return nil
+}
+
+var hitConditionRE = regexp.MustCompile(`^(==|!=|>=|<=|>|<|%)?\s*(\d+)$`)
+
+// parseHitCondition checks a hit condition in the forms Delve accepts, an
+// operator followed by a number or a bare number meaning ==, and returns it
+// as "op number".
+func parseHitCondition(cond string) (string, error) {
+	if cond == "" {
+		return "", nil
+	}
+	m := hitConditionRE.FindStringSubmatch(strings.TrimSpace(cond))
+	if m == nil {
+		return "", fmt.Errorf("invalid hit condition %q: want an operator (==, !=, >, >=, <, <=, %%) and a number, e.g. \"> 5\"", cond)
+	}
+	op := m[1]
+	if op == "" {
+		op = "=="
+	}
+	n, err := strconv.Atoi(m[2])
+	if err != nil {
+		return "", fmt.Errorf("invalid hit condition %q: %v", cond, err)
+	}
+	if op == "%" && n == 0 {
+		return "", fmt.Errorf("invalid hit condition %q: modulo by zero", cond)
+	}
+	return fmt.Sprintf("%s %d", op, n), nil
+}
+
 // jsonResult marshals v to JSON and returns it as a tool result.
 func jsonResult(v interface{}) (*mcp.CallToolResult, error) {
 	data, err := json.Marshal(v)
@@ -0,0 +1,312 @@
+package tools
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kjbreil/dlc-sidecar/internal/debugger"
+	"github.com/kjbreil/dlc-sidecar/internal/session"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestSetBreakpoint(t *testing.T) {
+	h := newHarness(t)
+	out := h.callJSON("set_breakpoint", map[string]interface{}{"file": "/src/main.go", "line": 12})
+
+	if out["success"] != true {
+		t.Errorf("success = %v", out["success"])
+	}
+	bp := out["breakpoint"].(map[string]interface{})
+	if bp["id"] != float64(1) || bp["file"] != "/src/main.go" || bp["line"] != float64(12) {
+		t.Errorf("breakpoint = %v", bp)
+	}
+	if out["message"] != "Breakpoint set at /src/main.go:12 (ID: 1)" {
+		t.Errorf("message = %v", out["message"])
+	}
+
+	got := h.delve.Breakpoints()
+	if len(got) != 1 || got[0].File != "/src/main.go" || got[0].Line != 12 {
+		t.Errorf("delve breakpoints = %+v", got)
+	}
+}
+
+func TestSetBreakpoint_Arguments(t *testing.T) {
+	h := newHarness(t)
+	h.callError("set_breakpoint", map[string]interface{}{"line": 12}, "file parameter error")
+	h.callError("set_breakpoint", map[string]interface{}{"file": "/src/main.go"}, "line parameter error")
+	h.callError("set_breakpoint", map[string]interface{}{"file": "/src/main.go", "line": 1, "session": "nope"}, "nope")
+
+	h.delve.Fail("CreateBreakpoint", errors.New("could not find statement"))
+	h.callError("set_breakpoint", map[string]interface{}{"file": "/src/main.go", "line": 1}, "RPC call failed: CreateBreakpoint: could not find statement")
+}
+
+func TestClearBreakpoint(t *testing.T) {
+	h := newHarness(t)
+	h.callJSON("set_breakpoint", map[string]interface{}{"file": "/src/main.go", "line": 12})
+
+	out := h.callJSON("clear_breakpoint", map[string]interface{}{"id": 1})
+	if out["message"] != "Breakpoint 1 cleared" {
+		t.Errorf("message = %v", out["message"])
+	}
+	if len(h.delve.Breakpoints()) != 0 {
+		t.Error("breakpoint was not cleared")
+	}
+	h.callError("clear_breakpoint", map[string]interface{}{"id": 1}, "no breakpoint with id 1")
+	h.callError("clear_breakpoint", nil, "id parameter error")
+}
+
+func TestListBreakpoints(t *testing.T) {
+	h := newHarness(t)
+	out := h.callJSON("list_breakpoints", nil)
+	if bps, ok := out["breakpoints"].([]interface{}); !ok || len(bps) != 0 {
+		t.Errorf("breakpoints = %v, want an empty list", out["breakpoints"])
+	}
+
+	h.callJSON("set_breakpoint", map[string]interface{}{"file": "/src/a.go", "line": 1})
+	h.callJSON("set_breakpoint", map[string]interface{}{"file": "/src/b.go", "line": 2})
+	out = h.callJSON("list_breakpoints", nil)
+	bps := out["breakpoints"].([]interface{})
+	if len(bps) != 2 || bps[1].(map[string]interface{})["file"] != "/src/b.go" {
+		t.Errorf("breakpoints = %v", bps)
+	}
+
+	calls := h.delve.Calls("ListBreakpoints")
+	if !calls[len(calls)-1].Args.(debugger.ListBreakpointsIn).All {
+		t.Error("list_breakpoints should include Delve's internal breakpoints")
+	}
+}
+
+func TestSetBreakpoint_Conditions(t *testing.T) {
+	h := newHarness(t)
+	out := h.callJSON("set_breakpoint", map[string]interface{}{
+		"file":                     "/src/main.go",
+		"line":                     12,
+		"condition":                "i == 42 && err != nil",
+		"hitCondition":             ">5",
+		"hitConditionPerGoroutine": true,
+	})
+	bp := out["breakpoint"].(map[string]interface{})
+	if bp["condition"] != "i == 42 && err != nil" || bp["hitCondition"] != "> 5" || bp["hitConditionPerGoroutine"] != true {
+		t.Errorf("breakpoint = %v", bp)
+	}
+	got := h.delve.Breakpoints()[0]
+	if got.Cond != "i == 42 && err != nil" || got.HitCond != "> 5" || !got.HitCondPerG {
+		t.Errorf("delve breakpoint = %+v", got)
+	}
+
+	h.callJSON("set_breakpoint", map[string]interface{}{"file": "/src/main.go", "line": 20, "hitCondition": "10"})
+	if got := h.delve.Breakpoints()[1].HitCond; got != "== 10" {
+		t.Errorf("bare hit count = %q, want == 10", got)
+	}
+}
+
+func TestSetBreakpoint_InvalidConditions(t *testing.T) {
+	h := newHarness(t)
+	args := func(key, value string) map[string]interface{} {
+		return map[string]interface{}{"file": "/src/main.go", "line": 12, key: value}
+	}
+	h.callError("set_breakpoint", args("condition", "i == "), "condition parameter error: invalid expression")
+	h.callError("set_breakpoint", args("hitCondition", "=> 5"), "hitCondition parameter error: invalid hit condition")
+	h.callError("set_breakpoint", args("hitCondition", "> x"), "invalid hit condition")
+	h.callError("set_breakpoint", args("hitCondition", "% 0"), "modulo by zero")
+	if len(h.delve.Calls("CreateBreakpoint")) != 0 {
+		t.Error("invalid conditions reached Delve")
+	}
+}
+
+func TestListBreakpoints_HitCounts(t *testing.T) {
+	h := newHarness(t)
+	h.callJSON("set_breakpoint", map[string]interface{}{"file": "/src/main.go", "line": 12, "condition": "n > 1", "hitCondition": "% 3"})
+	h.delve.HitBreakpoint(1)
+	h.delve.HitBreakpoint(1)
+
+	out := h.callJSON("list_breakpoints", nil)
+	bp := out["breakpoints"].([]interface{})[0].(map[string]interface{})
+	if bp["condition"] != "n > 1" || bp["hitCondition"] != "% 3" || bp["hitCount"] != float64(2) {
+		t.Errorf("breakpoint = %v", bp)
+	}
+	if byG := bp["hitCountByGoroutine"].(map[string]interface{}); byG["1"] != float64(2) {
+		t.Errorf("hitCountByGoroutine = %v", byG)
+	}
+}
+
+func TestSetBreakpoint_Location(t *testing.T) {
+	h := newHarness(t)
+	h.delve.SetLocation("main.main", debugger.Location{
+		PC: 0x4a1f20, PCs: []uint64{0x4a1f20}, File: "/src/main.go", Line: 10, Function: &debugger.Function{Name: "main.main"},
+	})
+
+	out := h.callJSON("set_breakpoint", map[string]interface{}{"location": "main.main", "condition": "x > 1"})
+	bp := out["breakpoint"].(map[string]interface{})
+	if bp["file"] != "/src/main.go" || bp["line"] != float64(10) || bp["addr"] != "0x4a1f20" || bp["function"] != "main.main" {
+		t.Errorf("breakpoint = %v", bp)
+	}
+	if out["message"] != "Breakpoint set at main.main (/src/main.go:10) (ID: 1)" {
+		t.Errorf("message = %v", out["message"])
+	}
+	calls := h.delve.Calls("CreateBreakpoint")
+	in := calls[0].Args.(debugger.CreateBreakpointIn)
+	if in.LocExpr != "main.main" || in.Breakpoint.Addr != 0x4a1f20 || in.Breakpoint.Cond != "x > 1" {
+		t.Errorf("CreateBreakpoint request = %+v", in)
+	}
+	if scope := h.delve.Calls("FindLocation")[0].Args.(debugger.FindLocationIn).Scope; scope.GoroutineID != -1 {
+		t.Errorf("FindLocation scope = %+v, want the current goroutine", scope)
+	}
+}
+
+func TestSetBreakpoint_LocationRegex(t *testing.T) {
+	h := newHarness(t)
+	var locs []debugger.Location
+	for i, fn := range []string{"api.ListHandler", "api.GetHandler", "api.PutHandler"} {
+		pc := uint64(0x500000 + 0x100*i)
+		locs = append(locs, debugger.Location{PC: pc, PCs: []uint64{pc}, File: "/src/api/api.go", Line: 10 * (i + 1), Function: &debugger.Function{Name: fn}})
+	}
+	h.delve.SetLocation(`/^api\..*Handler$/`, locs...)
+	h.delve.SetLocation("api.GetHandler", locs[1])
+	h.callJSON("set_breakpoint", map[string]interface{}{"location": "api.GetHandler"})
+
+	out := h.callJSON("set_breakpoint", map[string]interface{}{"location": `/^api\..*Handler$/`})
+	created := out["breakpoints"].([]interface{})
+	if len(created) != 2 || out["breakpoint"] != nil {
+		t.Fatalf("breakpoints = %v", created)
+	}
+	if first := created[0].(map[string]interface{}); first["function"] != "api.ListHandler" || first["line"] != float64(10) {
+		t.Errorf("first = %v", first)
+	}
+	failed := out["failed"].([]interface{})
+	if f := failed[0].(map[string]interface{}); f["function"] != "api.GetHandler" || f["error"] == nil {
+		t.Errorf("failed = %v", failed)
+	}
+	if out["message"] != `2 breakpoints set for /^api\..*Handler$/; 1 location(s) failed` {
+		t.Errorf("message = %v", out["message"])
+	}
+	for _, c := range h.delve.Calls("CreateBreakpoint")[1:] {
+		if in := c.Args.(debugger.CreateBreakpointIn); in.LocExpr != "" {
+			t.Errorf("regex match created with expression %q", in.LocExpr)
+		}
+	}
+}
+
+func TestSetBreakpoint_LocationErrors(t *testing.T) {
+	h := newHarness(t)
+	h.callError("set_breakpoint", map[string]interface{}{"location": "nope.nope"}, `RPC call failed: FindLocation: location "nope.nope" not found`)
+	h.callError("set_breakpoint", map[string]interface{}{"location": "main.main", "file": "/src/main.go"}, "give either location or file and line")
+	h.callError("set_breakpoint", nil, "file parameter error")
+
+	loc := debugger.Location{PC: 0x4a1f20, File: "/src/main.go", Line: 10}
+	h.delve.SetLocation("+3", loc)
+	h.delve.SetLocation("main.go:10", loc)
+	h.callJSON("set_breakpoint", map[string]interface{}{"location": "+3"})
+	h.callError("set_breakpoint", map[string]interface{}{"location": "main.go:10"}, "no breakpoint could be set at main.go:10: CreateBreakpoint: Breakpoint exists")
+}
+
+func TestToggleBreakpoint(t *testing.T) {
+	h := newHarness(t)
+	h.callJSON("set_breakpoint", map[string]interface{}{"file": "/src/main.go", "line": 12})
+
+	out := h.callJSON("toggle_breakpoint", map[string]interface{}{"id": 1})
+	if out["message"] != "Breakpoint 1 disabled" || out["breakpoint"].(map[string]interface{})["disabled"] != true {
+		t.Errorf("toggle = %v", out)
+	}
+	if !h.delve.Breakpoints()[0].Disabled {
+		t.Error("breakpoint not disabled in Delve")
+	}
+
+	h.callJSON("toggle_breakpoint", map[string]interface{}{"id": 1, "enabled": false})
+	if n := len(h.delve.Calls("ToggleBreakpoint")); n != 1 {
+		t.Errorf("ToggleBreakpoint called %d times, want no call for a breakpoint already disabled", n)
+	}
+	out = h.callJSON("toggle_breakpoint", map[string]interface{}{"id": 1, "enabled": true})
+	if out["message"] != "Breakpoint 1 enabled" || h.delve.Breakpoints()[0].Disabled {
+		t.Errorf("enable = %v", out)
+	}
+
+	h.callError("toggle_breakpoint", map[string]interface{}{"id": 7}, "no breakpoint with id 7")
+}
+
+func TestAmendBreakpoint(t *testing.T) {
+	h := newHarness(t)
+	h.callJSON("set_breakpoint", map[string]interface{}{"file": "/src/main.go", "line": 12, "condition": "n > 1"})
+	h.delve.HitBreakpoint(1)
+
+	out := h.callJSON("amend_breakpoint", map[string]interface{}{
+		"id":           1,
+		"hitCondition": "% 3",
+		"tracepoint":   true,
+		"expressions":  []interface{}{"n"},
+		"stackDepth":   4,
+	})
+	bp := out["breakpoint"].(map[string]interface{})
+	if bp["id"] != float64(1) || bp["hitCount"] != float64(1) || bp["condition"] != "n > 1" || bp["hitCondition"] != "% 3" || bp["tracepoint"] != true {
+		t.Errorf("amended breakpoint = %v", bp)
+	}
+	if out["message"] != "Breakpoint 1 amended: hitCondition, tracepoint, expressions, stackDepth" {
+		t.Errorf("message = %v", out["message"])
+	}
+	got := h.delve.Breakpoints()[0]
+	if got.Cond != "n > 1" || !got.Tracepoint || got.Stacktrace != 4 || len(got.Variables) != 1 || got.TotalHitCount != 1 {
+		t.Errorf("delve breakpoint = %+v", got)
+	}
+
+	h.callJSON("amend_breakpoint", map[string]interface{}{"id": 1, "condition": "", "tracepoint": false})
+	if got := h.delve.Breakpoints()[0]; got.Cond != "" || got.Tracepoint || got.HitCond != "% 3" {
+		t.Errorf("after clearing = %+v", got)
+	}
+}
+
+func TestAmendBreakpoint_Errors(t *testing.T) {
+	h := newHarness(t)
+	h.callJSON("set_breakpoint", map[string]interface{}{"file": "/src/main.go", "line": 12})
+	h.callError("amend_breakpoint", map[string]interface{}{"id": 1}, "nothing to amend")
+	h.callError("amend_breakpoint", map[string]interface{}{"id": 1, "condition": "a =="}, "condition parameter error")
+	h.callError("amend_breakpoint", map[string]interface{}{"id": 1, "hitCondition": "often"}, "hitCondition parameter error")
+	h.callError("amend_breakpoint", map[string]interface{}{"id": 2, "condition": "a"}, "no breakpoint with id 2")
+	if len(h.delve.Calls("AmendBreakpoint")) != 0 {
+		t.Error("invalid amendments reached Delve")
+	}
+}
+
+func TestBreakpointManifest(t *testing.T) {
+	h := newHarness(t)
+	manifest := session.NewManifest(filepath.Join(t.TempDir(), "breakpoints.json"))
+	h.sessions.PersistBreakpoints(manifest)
+	h.delve.SetLocation("main.run", debugger.Location{PC: 0x4a1f20, PCs: []uint64{0x4a1f20}, File: "/src/run.go", Line: 3})
+
+	h.callJSON("set_breakpoint", map[string]interface{}{"file": "/src/main.go", "line": 12, "condition": "n > 1"})
+	h.callJSON("set_tracepoint", map[string]interface{}{"location": "main.run", "expressions": []interface{}{"x"}})
+	h.callJSON("set_breakpoint", map[string]interface{}{"file": "/src/main.go", "line": 20})
+	h.callJSON("clear_breakpoint", map[string]interface{}{"id": 3})
+	h.callJSON("toggle_breakpoint", map[string]interface{}{"id": 1})
+
+	saved, err := manifest.Load(session.DefaultName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(saved) != 2 || saved[0].Condition != "n > 1" || !saved[0].Disabled || saved[1].Location != "main.run" || !saved[1].Tracepoint {
+		t.Fatalf("saved = %+v", saved)
+	}
+
+	// Delve restarts; main.run no longer resolves.
+	h.delve.SetLocation("main.run")
+	h.delve.Reset()
+	out := h.callJSON("list_breakpoints", nil)
+	if bps := out["breakpoints"].([]interface{}); len(bps) != 1 || bps[0].(map[string]interface{})["condition"] != "n > 1" {
+		t.Errorf("breakpoints after restore = %v", bps)
+	}
+	if out["manifest"] != manifest.Path() {
+		t.Errorf("manifest = %v", out["manifest"])
+	}
+	restore := out["restore"].(map[string]interface{})
+	failed := restore["failed"].([]interface{})
+	if restore["restored"] != float64(1) || len(failed) != 1 || failed[0].(map[string]interface{})["location"] != "main.run" {
+		t.Errorf("restore = %v", restore)
+	}
+
+	n := h.notification(logNotification)
+	if f := n.Params.AdditionalFields; f["level"] != mcp.LoggingLevelWarning ||
+		!strings.Contains(f["data"].(string), "restored 1 saved breakpoint(s) on a fresh Delve; 1 failed: main.run") {
+		t.Errorf("log notification = %v", f)
+	}
+}
@@ -3,71 +3,247 @@ package tools
 import (
 	"context"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/kjbreil/dlc-sidecar/internal/debugger"
+	"github.com/kjbreil/dlc-sidecar/internal/session"
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
 )
 
-func registerExecution(s *server.MCPServer, pool *debugger.Pool) {
+// stopNotification is the MCP notification method sent when an asynchronous
+// command returns.
+const stopNotification = "notifications/debugger/stopped"
+
+func registerExecution(s *server.MCPServer, sessions *session.Manager) {
 	// continue
-	s.AddTool(mcp.NewTool("continue",
+	addSessionTool(s, sessions, mcp.NewTool("continue",
 		mcp.WithDescription("Continue execution until the next breakpoint or program exit"),
-	), makeCommand(pool, debugger.CmdContinue))
+		mcp.WithBoolean("async",
+			mcp.Description("Return immediately with a run handle instead of waiting for the target to stop; "+
+				"a notification is sent when it stops (default: false)"),
+		),
+	), liveOnly(makeContinue()))
 
 	// next (step over)
-	s.AddTool(mcp.NewTool("next",
+	addSessionTool(s, sessions, mcp.NewTool("next",
 		mcp.WithDescription("Step to the next source line, stepping over function calls"),
-	), makeCommand(pool, debugger.CmdNext))
+	), liveOnly(makeCommand(debugger.CmdNext)))
 
 	// step (step into)
-	s.AddTool(mcp.NewTool("step",
+	addSessionTool(s, sessions, mcp.NewTool("step",
 		mcp.WithDescription("Step to the next source line, stepping into function calls"),
-	), makeCommand(pool, debugger.CmdStep))
+	), liveOnly(makeCommand(debugger.CmdStep)))
 
 	// step_out
-	s.AddTool(mcp.NewTool("step_out",
+	addSessionTool(s, sessions, mcp.NewTool("step_out",
 		mcp.WithDescription("Step out of the current function, continuing to the return address"),
-	), makeCommand(pool, debugger.CmdStepOut))
+	), liveOnly(makeCommand(debugger.CmdStepOut)))
 
 	// step_instruction
-	s.AddTool(mcp.NewTool("step_instruction",
+	addSessionTool(s, sessions, mcp.NewTool("step_instruction",
 		mcp.WithDescription("Step exactly one CPU instruction"),
-	), makeCommand(pool, debugger.CmdStepInstruction))
+	), liveOnly(makeCommand(debugger.CmdStepInstruction)))
 
 	// halt
-	s.AddTool(mcp.NewTool("halt",
+	addSessionTool(s, sessions, mcp.NewTool("halt",
 		mcp.WithDescription("Halt the running program"),
-	), makeCommand(pool, debugger.CmdHalt))
+	), liveOnly(makeHalt()))
+
+	// wait_for_stop
+	addSessionTool(s, sessions, mcp.NewTool("wait_for_stop",
+		mcp.WithDescription("Wait for an asynchronous continue to stop and return where the target stopped"),
+		mcp.WithNumber("timeout",
+			mcp.Description("Maximum number of seconds to wait (default: 30)"),
+		),
+	), makeWaitForStop())
+
+	sessions.OnOpen(func(sess *session.Session) {
+		sess.Pool.OnStop(func(r *debugger.Run) {
+			summary := runSummary(sess, r)
+			summary["session"] = sess.Name
+			s.SendNotificationToAllClients(stopNotification, summary)
+		})
+	})
+}
+
+// liveOnly rejects calls on post-mortem sessions, where the target is a core
+// dump that cannot be executed.
+func liveOnly(h sessionHandler) sessionHandler {
+	return func(ctx context.Context, request mcp.CallToolRequest, sess *session.Session) (*mcp.CallToolResult, error) {
+		if sess.PostMortem() {
+			return mcp.NewToolResultError(fmt.Sprintf("post-mortem session: %s is a core dump and cannot be executed; "+
+				"stack, goroutine and variable tools are still available", sess.Name)), nil
+		}
+		return h(ctx, request, sess)
+	}
 }
 
-func makeCommand(pool *debugger.Pool, cmd string) server.ToolHandlerFunc {
-	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-		command := debugger.DebuggerCommand{Name: cmd}
-		var resp debugger.CommandOut
-		if err := pool.Call("Command", command, &resp); err != nil {
-			return mcp.NewToolResultError(fmt.Sprintf("RPC call failed: %v", err)), nil
+func makeCommand(cmd string) sessionHandler {
+	return func(ctx context.Context, request mcp.CallToolRequest, sess *session.Session) (*mcp.CallToolResult, error) {
+		if r := sess.Pool.CurrentRun(); r != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("%s is already running (run %d); use wait_for_stop or halt first", r.Command, r.ID)), nil
+		}
+
+		st, err := sess.Pool.Command(ctx, debugger.DebuggerCommand{Name: cmd})
+		if err != nil {
+			return rpcError(err), nil
+		}
+
+		result := stateSummary(cmd, st)
+		addTestOutcome(result, sess, st)
+		return jsonResult(result)
+	}
+}
+
+func makeHalt() sessionHandler {
+	return func(ctx context.Context, request mcp.CallToolRequest, sess *session.Session) (*mcp.CallToolResult, error) {
+		// Look up the run first: halting it completes it.
+		r := sess.Pool.CurrentRun()
+		st, err := sess.Pool.Halt(ctx)
+		if err != nil {
+			return rpcError(err), nil
+		}
+
+		result := stateSummary(debugger.CmdHalt, st)
+		if r != nil {
+			result["runID"] = r.ID
+		}
+		return jsonResult(result)
+	}
+}
+
+func makeContinue() sessionHandler {
+	blocking := makeCommand(debugger.CmdContinue)
+	return func(ctx context.Context, request mcp.CallToolRequest, sess *session.Session) (*mcp.CallToolResult, error) {
+		async := false
+		if v, err := request.RequireBool("async"); err == nil {
+			async = v
+		}
+		if !async {
+			return blocking(ctx, request, sess)
+		}
+
+		r, err := sess.Pool.Start(debugger.DebuggerCommand{Name: debugger.CmdContinue})
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("continue failed: %v", err)), nil
 		}
 
-		result := map[string]interface{}{
-			"command": cmd,
+		return jsonResult(map[string]interface{}{
+			"command": debugger.CmdContinue,
+			"runID":   r.ID,
+			"status":  "running",
+			"message": fmt.Sprintf("Target running (run %d); call wait_for_stop or watch for %s", r.ID, stopNotification),
+		})
+	}
+}
+
+func makeWaitForStop() sessionHandler {
+	return func(ctx context.Context, request mcp.CallToolRequest, sess *session.Session) (*mcp.CallToolResult, error) {
+		timeout := 30
+		if v, err := request.RequireInt("timeout"); err == nil {
+			timeout = v
 		}
-		st := resp.State
-		if st.Exited {
-			result["exited"] = true
-			result["exitStatus"] = st.ExitStatus
+
+		r := sess.Pool.LastRun()
+		if r == nil {
+			return mcp.NewToolResultError("no asynchronous command has been started; use continue with async=true"), nil
 		}
-		if st.CurrentThread != nil {
-			result["file"] = st.CurrentThread.File
-			result["line"] = st.CurrentThread.Line
-			if st.CurrentThread.Function != nil {
-				result["function"] = st.CurrentThread.Function.Name
+
+		waitCtx, cancel := context.WithTimeout(ctx, time.Duration(timeout)*time.Second)
+		defer cancel()
+		select {
+		case <-r.Done():
+		case <-waitCtx.Done():
+			if ctx.Err() != nil {
+				return rpcError(ctx.Err()), nil
 			}
+			return jsonResult(map[string]interface{}{
+				"command": r.Command,
+				"runID":   r.ID,
+				"status":  "running",
+				"message": fmt.Sprintf("Target still running after %ds", timeout),
+			})
 		}
-		if st.SelectedGoroutine != nil {
-			result["goroutineID"] = st.SelectedGoroutine.ID
+
+		return jsonResult(runSummary(sess, r))
+	}
+}
+
+// runSummary describes a completed asynchronous command: where the target
+// stopped, whether it exited, or the error that ended the call.
+func runSummary(sess *session.Session, r *debugger.Run) map[string]interface{} {
+	st, err := r.Result()
+	if err != nil {
+		return map[string]interface{}{
+			"command": r.Command,
+			"runID":   r.ID,
+			"status":  "error",
+			"error":   err.Error(),
 		}
+	}
+	result := stateSummary(r.Command, st)
+	result["runID"] = r.ID
+	addTestOutcome(result, sess, st)
+	return result
+}
 
-		return jsonResult(result)
+// addTestOutcome reports whether the test passed, with its output, once the
+// test binary of a debug_test session has exited.
+func addTestOutcome(result map[string]interface{}, sess *session.Session, st *debugger.DebuggerState) {
+	if !st.Exited || sess.Process == nil || sess.Process.Config.Mode != debugger.ModeTest {
+		return
+	}
+	output := sess.Process.Output()
+	var failures []string
+	for _, line := range strings.Split(output, "\n") {
+		if strings.Contains(line, "--- FAIL:") {
+			failures = append(failures, strings.TrimSpace(line))
+		}
+	}
+	outcome := "pass"
+	if st.ExitStatus != 0 {
+		outcome = "fail"
+	}
+	result["test"] = map[string]interface{}{
+		"outcome":  outcome,
+		"passed":   st.ExitStatus == 0,
+		"failures": failures,
+		"output":   output,
+	}
+}
+
+// stateSummary reports where the target is after an execution command,
+// including the breakpoint or watchpoint that fired if any.
+func stateSummary(cmd string, st *debugger.DebuggerState) map[string]interface{} {
+	result := map[string]interface{}{
+		"command": cmd,
+		"status":  "stopped",
+	}
+	if st.Exited {
+		result["status"] = "exited"
+		result["exited"] = true
+		result["exitStatus"] = st.ExitStatus
+	}
+	if st.CurrentThread != nil {
+		result["file"] = st.CurrentThread.File
+		result["line"] = st.CurrentThread.Line
+		if st.CurrentThread.Function != nil {
+			result["function"] = st.CurrentThread.Function.Name
+		}
+		if bp := st.CurrentThread.Breakpoint; bp != nil {
+			result["breakpoint"] = map[string]interface{}{
+				"id":   bp.ID,
+				"name": bp.Name,
+				"file": bp.File,
+				"line": bp.Line,
+			}
+		}
+	}
+	if st.SelectedGoroutine != nil {
+		result["goroutineID"] = st.SelectedGoroutine.ID
 	}
+	addWatchEvents(result, st)
+	return result
 }
@@ -0,0 +1,126 @@
+package tools
+
+import (
+	"testing"
+
+	"github.com/kjbreil/dlc-sidecar/internal/debugger"
+)
+
+func TestContinue_StopsAtBreakpoint(t *testing.T) {
+	h := newHarness(t)
+	h.callJSON("set_breakpoint", map[string]interface{}{"file": "/src/main.go", "line": 12})
+	h.delve.HitBreakpoint(1)
+
+	out := h.callJSON("continue", nil)
+	if out["command"] != "continue" || out["status"] != "stopped" || out["line"] != float64(12) {
+		t.Errorf("result = %v", out)
+	}
+	bp := out["breakpoint"].(map[string]interface{})
+	if bp["id"] != float64(1) || bp["file"] != "/src/main.go" {
+		t.Errorf("breakpoint = %v", bp)
+	}
+	if out["goroutineID"] != float64(1) {
+		t.Errorf("goroutineID = %v", out["goroutineID"])
+	}
+}
+
+func TestContinue_Exited(t *testing.T) {
+	h := newHarness(t)
+	h.delve.QueueStop(debugger.DebuggerState{Exited: true, ExitStatus: 2})
+
+	out := h.callJSON("continue", nil)
+	if out["status"] != "exited" || out["exitStatus"] != float64(2) {
+		t.Errorf("result = %v", out)
+	}
+}
+
+func TestStepCommands(t *testing.T) {
+	tests := map[string]string{
+		"next":             debugger.CmdNext,
+		"step":             debugger.CmdStep,
+		"step_out":         debugger.CmdStepOut,
+		"step_instruction": debugger.CmdStepInstruction,
+	}
+	for tool, cmd := range tests {
+		t.Run(tool, func(t *testing.T) {
+			h := newHarness(t)
+			h.delve.QueueStop(debugger.DebuggerState{
+				CurrentThread: &debugger.Thread{File: "/src/main.go", Line: 13, Function: &debugger.Function{Name: "main.main"}},
+			})
+
+			out := h.callJSON(tool, nil)
+			if out["command"] != cmd || out["line"] != float64(13) || out["function"] != "main.main" {
+				t.Errorf("result = %v", out)
+			}
+			if got := h.delve.Calls("Command")[0].Args.(debugger.DebuggerCommand).Name; got != cmd {
+				t.Errorf("sent %q, want %q", got, cmd)
+			}
+		})
+	}
+}
+
+func TestContinue_AsyncNotifiesAndWaits(t *testing.T) {
+	h := newHarness(t)
+	h.callJSON("set_breakpoint", map[string]interface{}{"file": "/src/main.go", "line": 12})
+
+	out := h.callJSON("continue", map[string]interface{}{"async": true})
+	if out["status"] != "running" || out["runID"] != float64(1) {
+		t.Fatalf("result = %v", out)
+	}
+	h.waitFor(h.delve.Running)
+
+	h.callError("next", nil, "continue is already running (run 1)")
+	out = h.callJSON("wait_for_stop", map[string]interface{}{"timeout": 0})
+	if out["status"] != "running" {
+		t.Errorf("wait_for_stop before the stop = %v", out)
+	}
+
+	h.delve.HitBreakpoint(1)
+	n := h.notification(stopNotification)
+	if n.Params.AdditionalFields["session"] != "default" || n.Params.AdditionalFields["line"] != 12 {
+		t.Errorf("notification = %v", n.Params.AdditionalFields)
+	}
+
+	out = h.callJSON("wait_for_stop", nil)
+	if out["status"] != "stopped" || out["runID"] != float64(1) || out["line"] != float64(12) {
+		t.Errorf("wait_for_stop = %v", out)
+	}
+}
+
+func TestHalt_InterruptsAsyncContinue(t *testing.T) {
+	h := newHarness(t)
+	h.callJSON("continue", map[string]interface{}{"async": true})
+	h.waitFor(h.delve.Running)
+
+	out := h.callJSON("halt", nil)
+	if out["command"] != "halt" || out["runID"] != float64(1) {
+		t.Errorf("halt = %v", out)
+	}
+	out = h.callJSON("wait_for_stop", nil)
+	if out["status"] != "stopped" {
+		t.Errorf("wait_for_stop = %v", out)
+	}
+}
+
+func TestWaitForStop_WithoutRun(t *testing.T) {
+	h := newHarness(t)
+	h.callError("wait_for_stop", nil, "no asynchronous command has been started")
+}
+
+func TestExecution_PostMortem(t *testing.T) {
+	h := newHarness(t)
+	sess, err := h.sessions.Get("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Pose as a session opened with open_core; there is no Delve process to
+	// stop when the session closes.
+	sess.Process = &debugger.Process{Addr: h.delve.Addr, Config: debugger.LaunchConfig{Mode: debugger.ModeCore}}
+	t.Cleanup(func() { sess.Process = nil })
+	for _, tool := range []string{"continue", "next", "step", "step_out", "step_instruction", "halt"} {
+		h.callError(tool, nil, "post-mortem session")
+	}
+	if calls := h.delve.Calls("Command"); len(calls) != 0 {
+		t.Errorf("post-mortem session sent %d commands", len(calls))
+	}
+}
@@ -0,0 +1,217 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kjbreil/dlc-sidecar/internal/debugger/debuggertest"
+	"github.com/kjbreil/dlc-sidecar/internal/session"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+func TestMain(m *testing.M) {
+	debuggertest.MainDlv()
+	os.Exit(m.Run())
+}
+
+// harness drives the registered tools through the MCP server's message
+// handler, as a client would, against a fake Delve.
+type harness struct {
+	t        *testing.T
+	delve    *debuggertest.Server
+	sessions *session.Manager
+	server   *server.MCPServer
+	client   *testClient
+	nextID   int64
+}
+
+// newHarness registers every tool on a fresh MCP server whose default
+// session talks to a fake Delve.
+func newHarness(t *testing.T) *harness {
+	t.Helper()
+	h := &harness{
+		t:        t,
+		delve:    debuggertest.NewServer(t),
+		sessions: session.NewManager(session.Limits{MaxSessions: 4}),
+		server:   server.NewMCPServer("dlc-sidecar-test", "test"),
+		client:   &testClient{notifications: make(chan mcp.JSONRPCNotification, 16)},
+	}
+	t.Cleanup(func() { h.sessions.CloseAll() })
+
+	if _, err := h.sessions.Open(session.DefaultName, h.delve.Addr); err != nil {
+		t.Fatal(err)
+	}
+	Register(h.server, h.sessions)
+
+	if err := h.server.RegisterSession(context.Background(), h.client); err != nil {
+		t.Fatal(err)
+	}
+	h.send("initialize", map[string]interface{}{
+		"protocolVersion": mcp.LATEST_PROTOCOL_VERSION,
+		"clientInfo":      map[string]interface{}{"name": "harness", "version": "test"},
+		"capabilities":    map[string]interface{}{},
+	})
+	h.client.Initialize()
+	return h
+}
+
+// launch starts a session named name with the launch tool, under a dlv
+// that relays to the harness's fake Delve.
+func (h *harness) launch(name string) *session.Session {
+	h.t.Helper()
+	h.delve.InstallDlv(h.t)
+	h.callJSON("launch", map[string]interface{}{"package": "./cmd/app", "name": name})
+	sess, err := h.sessions.Get(name)
+	if err != nil {
+		h.t.Fatal(err)
+	}
+	return sess
+}
+
+// send delivers a JSON-RPC request to the server and returns its reply.
+func (h *harness) send(method string, params interface{}) mcp.JSONRPCMessage {
+	h.t.Helper()
+	msg, err := json.Marshal(map[string]interface{}{
+		"jsonrpc": mcp.JSONRPC_VERSION,
+		"id":      atomic.AddInt64(&h.nextID, 1),
+		"method":  method,
+		"params":  params,
+	})
+	if err != nil {
+		h.t.Fatal(err)
+	}
+	ctx := h.server.WithContext(context.Background(), h.client)
+	return h.server.HandleMessage(ctx, msg)
+}
+
+// call invokes a tool and returns its result; protocol errors, such as an
+// unknown tool, fail the test.
+func (h *harness) call(name string, args map[string]interface{}) *mcp.CallToolResult {
+	h.t.Helper()
+	reply := h.send("tools/call", map[string]interface{}{
+		"name":      name,
+		"arguments": args,
+	})
+	resp, ok := reply.(mcp.JSONRPCResponse)
+	if !ok {
+		h.t.Fatalf("%s: unexpected reply %#v", name, reply)
+	}
+	result, ok := resp.Result.(mcp.CallToolResult)
+	if !ok {
+		h.t.Fatalf("%s: unexpected result %#v", name, resp.Result)
+	}
+	return &result
+}
+
+// callJSON invokes a tool that must succeed and decodes its JSON result.
+func (h *harness) callJSON(name string, args map[string]interface{}) map[string]interface{} {
+	h.t.Helper()
+	result := h.call(name, args)
+	text := resultText(result)
+	if result.IsError {
+		h.t.Fatalf("%s: unexpected error: %s", name, text)
+	}
+	var out map[string]interface{}
+	if err := json.Unmarshal([]byte(text), &out); err != nil {
+		h.t.Fatalf("%s: result is not JSON: %v\n%s", name, err, text)
+	}
+	return out
+}
+
+// callError invokes a tool that must fail and returns its error message,
+// checking that it mentions want.
+func (h *harness) callError(name string, args map[string]interface{}, want string) string {
+	h.t.Helper()
+	result := h.call(name, args)
+	text := resultText(result)
+	if !result.IsError {
+		h.t.Fatalf("%s: expected an error, got %s", name, text)
+	}
+	if !strings.Contains(text, want) {
+		h.t.Errorf("%s: error %q does not mention %q", name, text, want)
+	}
+	return text
+}
+
+// notification waits for the next notification with the given method.
+func (h *harness) notification(method string) mcp.JSONRPCNotification {
+	h.t.Helper()
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case n := <-h.client.notifications:
+			if n.Method == method {
+				return n
+			}
+		case <-timeout:
+			h.t.Fatalf("no %s notification", method)
+		}
+	}
+}
+
+// waitFor polls cond until it holds or the test times out.
+func (h *harness) waitFor(cond func() bool) {
+	h.t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			h.t.Fatal("condition not met")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func resultText(result *mcp.CallToolResult) string {
+	var parts []string
+	for _, c := range result.Content {
+		if text, ok := c.(mcp.TextContent); ok {
+			parts = append(parts, text.Text)
+		}
+	}
+	return strings.Join(parts, "\n")
+}
+
+// testClient is the harness's MCP client session; it collects the
+// notifications the server sends.
+type testClient struct {
+	initialized   atomic.Bool
+	notifications chan mcp.JSONRPCNotification
+}
+
+func (c *testClient) Initialize()       { c.initialized.Store(true) }
+func (c *testClient) Initialized() bool { return c.initialized.Load() }
+func (c *testClient) SessionID() string { return "harness" }
+func (c *testClient) NotificationChannel() chan<- mcp.JSONRPCNotification {
+	return c.notifications
+}
+
+func TestHarness_ListsEveryTool(t *testing.T) {
+	h := newHarness(t)
+	reply := h.send("tools/list", map[string]interface{}{})
+	resp, ok := reply.(mcp.JSONRPCResponse)
+	if !ok {
+		t.Fatalf("unexpected reply %#v", reply)
+	}
+	list, ok := resp.Result.(mcp.ListToolsResult)
+	if !ok {
+		t.Fatalf("unexpected result %#v", resp.Result)
+	}
+	names := make(map[string]mcp.Tool)
+	for _, tool := range list.Tools {
+		names[tool.Name] = tool
+	}
+	for _, name := range []string{"set_breakpoint", "continue", "halt", "eval", "stacktrace", "open_session", "launch", "restart", "detach", "kill", "set_tracepoint", "get_trace_hits", "toggle_breakpoint", "amend_breakpoint", "import_breakpoints", "export_breakpoints", "set_watchpoint", "set_logpoint", "get_logpoint_output"} {
+		if _, ok := names[name]; !ok {
+			t.Errorf("tool %s is not registered", name)
+		}
+	}
+	if _, ok := names["eval"].InputSchema.Properties["session"]; !ok {
+		t.Error("session tools should accept a session argument")
+	}
+}
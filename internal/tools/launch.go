@@ -0,0 +1,371 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/kjbreil/dlc-sidecar/internal/debugger"
+	"github.com/kjbreil/dlc-sidecar/internal/session"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+func registerLaunch(s *server.MCPServer, sessions *session.Manager) {
+	// launch
+	s.AddTool(mcp.NewTool("launch",
+		mcp.WithDescription("Build a Go package and start it under a headless Delve server managed by the sidecar, "+
+			"opening a new session connected to it"),
+		mcp.WithString("package",
+			mcp.Required(),
+			mcp.Description("Package path to build and debug (e.g. ./cmd/server)"),
+		),
+		mcp.WithString("name",
+			mcp.Description("Session name (default: last element of the package path)"),
+		),
+		mcp.WithString("buildFlags",
+			mcp.Description("Flags passed to the go toolchain (e.g. \"-race -ldflags=-X=main.version=dev\")"),
+		),
+		mcp.WithArray("tags",
+			mcp.Description("Build tags"),
+			mcp.WithStringItems(),
+		),
+		mcp.WithArray("args",
+			mcp.Description("Arguments passed to the program"),
+			mcp.WithStringItems(),
+		),
+		mcp.WithArray("env",
+			mcp.Description("Extra environment variables for the program as KEY=VALUE"),
+			mcp.WithStringItems(),
+		),
+		mcp.WithString("dir",
+			mcp.Description("Working directory for the build and the program (default: the sidecar's working directory)"),
+		),
+		mcp.WithBoolean("select",
+			mcp.Description("Make the new session the selected one (default: true)"),
+		),
+		mcp.WithNumber("idleTimeout",
+			mcp.Description("Seconds the session may go unused before it is closed, stopping its Delve (default: 0, never)"),
+		),
+	), makeLaunch(sessions))
+
+	// attach
+	s.AddTool(mcp.NewTool("attach",
+		mcp.WithDescription("Attach a headless Delve managed by the sidecar to a running process and open a session for it. "+
+			"Closing the session detaches and lets the process continue unless kill is requested"),
+		mcp.WithNumber("pid",
+			mcp.Required(),
+			mcp.Description("Process ID to attach to (see list_processes)"),
+		),
+		mcp.WithString("name",
+			mcp.Description("Session name (default: pid-<pid>)"),
+		),
+		mcp.WithBoolean("select",
+			mcp.Description("Make the new session the selected one (default: true)"),
+		),
+		mcp.WithNumber("idleTimeout",
+			mcp.Description("Seconds the session may go unused before it is closed, stopping its Delve (default: 0, never)"),
+		),
+	), makeAttach(sessions))
+
+	// exec
+	s.AddTool(mcp.NewTool("exec",
+		mcp.WithDescription("Start a prebuilt Go binary under a headless Delve managed by the sidecar without rebuilding it, "+
+			"opening a new session and reporting the binary's build info"),
+		mcp.WithString("binary",
+			mcp.Required(),
+			mcp.Description("Path to the executable"),
+		),
+		mcp.WithString("name",
+			mcp.Description("Session name (default: file name of the binary)"),
+		),
+		mcp.WithArray("args",
+			mcp.Description("Arguments passed to the program"),
+			mcp.WithStringItems(),
+		),
+		mcp.WithArray("env",
+			mcp.Description("Extra environment variables for the program as KEY=VALUE"),
+			mcp.WithStringItems(),
+		),
+		mcp.WithString("dir",
+			mcp.Description("Working directory for the program (default: the sidecar's working directory)"),
+		),
+		mcp.WithBoolean("stopOnEntry",
+			mcp.Description("Keep the program stopped at entry until continue is called (default: true)"),
+		),
+		mcp.WithBoolean("select",
+			mcp.Description("Make the new session the selected one (default: true)"),
+		),
+		mcp.WithNumber("idleTimeout",
+			mcp.Description("Seconds the session may go unused before it is closed, stopping its Delve (default: 0, never)"),
+		),
+	), makeExec(sessions))
+
+	// debug_test
+	s.AddTool(mcp.NewTool("debug_test",
+		mcp.WithDescription("Build a package's tests and run the ones matching a -run pattern under a headless Delve managed by "+
+			"the sidecar. The test binary stops on entry so breakpoints can be set; continue reports pass/fail and output when it exits"),
+		mcp.WithString("package",
+			mcp.Required(),
+			mcp.Description("Package path containing the test (e.g. ./internal/server)"),
+		),
+		mcp.WithString("run",
+			mcp.Required(),
+			mcp.Description("Test name pattern as for go test -run (e.g. ^TestHandler$)"),
+		),
+		mcp.WithString("subtest",
+			mcp.Description("Subtest path appended to the run pattern (e.g. invalid_input/empty)"),
+		),
+		mcp.WithNumber("count",
+			mcp.Description("Number of times to run the test (default: 1)"),
+		),
+		mcp.WithString("name",
+			mcp.Description("Session name (default: last element of the package path)"),
+		),
+		mcp.WithString("buildFlags",
+			mcp.Description("Flags passed to the go toolchain"),
+		),
+		mcp.WithArray("tags",
+			mcp.Description("Build tags"),
+			mcp.WithStringItems(),
+		),
+		mcp.WithArray("args",
+			mcp.Description("Additional test binary flags (e.g. -test.timeout=30s)"),
+			mcp.WithStringItems(),
+		),
+		mcp.WithArray("env",
+			mcp.Description("Extra environment variables for the test as KEY=VALUE"),
+			mcp.WithStringItems(),
+		),
+		mcp.WithString("dir",
+			mcp.Description("Working directory for the build (default: the sidecar's working directory)"),
+		),
+		mcp.WithBoolean("select",
+			mcp.Description("Make the new session the selected one (default: true)"),
+		),
+		mcp.WithNumber("idleTimeout",
+			mcp.Description("Seconds the session may go unused before it is closed, stopping its Delve (default: 0, never)"),
+		),
+	), makeDebugTest(sessions))
+
+	// open_core
+	s.AddTool(mcp.NewTool("open_core",
+		mcp.WithDescription("Open a core dump for post-mortem analysis under a headless Delve managed by the sidecar. "+
+			"Execution tools are unavailable in the resulting session"),
+		mcp.WithString("binary",
+			mcp.Required(),
+			mcp.Description("Path to the executable that produced the core dump"),
+		),
+		mcp.WithString("core",
+			mcp.Required(),
+			mcp.Description("Path to the core dump (e.g. from GOTRACEBACK=crash)"),
+		),
+		mcp.WithString("name",
+			mcp.Description("Session name (default: file name of the core dump)"),
+		),
+		mcp.WithBoolean("select",
+			mcp.Description("Make the new session the selected one (default: true)"),
+		),
+		mcp.WithNumber("idleTimeout",
+			mcp.Description("Seconds the session may go unused before it is closed, stopping its Delve (default: 0, never)"),
+		),
+	), makeOpenCore(sessions))
+
+	// list_processes
+	s.AddTool(mcp.NewTool("list_processes",
+		mcp.WithDescription("List running processes whose executable is a Go binary, for use with attach"),
+		mcp.WithString("filter",
+			mcp.Description("Only include processes whose executable, module path or command line contains this text"),
+		),
+	), makeListProcesses())
+}
+
+func makeLaunch(sessions *session.Manager) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		pkg, err := request.RequireString("package")
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("package parameter error: %v", err)), nil
+		}
+
+		cfg := debugger.LaunchConfig{
+			Mode:       debugger.ModeDebug,
+			Target:     pkg,
+			BuildFlags: request.GetString("buildFlags", ""),
+			Tags:       request.GetStringSlice("tags", nil),
+			Args:       request.GetStringSlice("args", nil),
+			Env:        request.GetStringSlice("env", nil),
+			Dir:        request.GetString("dir", ""),
+		}
+		return launchSession(ctx, request, sessions, sessionName(request, pkg), cfg, nil)
+	}
+}
+
+func makeAttach(sessions *session.Manager) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		pid, err := request.RequireInt("pid")
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("pid parameter error: %v", err)), nil
+		}
+
+		cfg := debugger.LaunchConfig{
+			Mode: debugger.ModeAttach,
+			Pid:  pid,
+		}
+		return launchSession(ctx, request, sessions, request.GetString("name", fmt.Sprintf("pid-%d", pid)), cfg, nil)
+	}
+}
+
+func makeExec(sessions *session.Manager) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		binary, err := request.RequireString("binary")
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("binary parameter error: %v", err)), nil
+		}
+
+		cfg := debugger.LaunchConfig{
+			Mode:     debugger.ModeExec,
+			Target:   binary,
+			Args:     request.GetStringSlice("args", nil),
+			Env:      request.GetStringSlice("env", nil),
+			Dir:      request.GetString("dir", ""),
+			Continue: !request.GetBool("stopOnEntry", true),
+		}
+		return launchSession(ctx, request, sessions, sessionName(request, binary), cfg, nil)
+	}
+}
+
+func makeDebugTest(sessions *session.Manager) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		pkg, err := request.RequireString("package")
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("package parameter error: %v", err)), nil
+		}
+		run, err := request.RequireString("run")
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("run parameter error: %v", err)), nil
+		}
+		count := 1
+		if v, err := request.RequireInt("count"); err == nil {
+			count = v
+		}
+
+		cfg := debugger.LaunchConfig{
+			Mode:       debugger.ModeTest,
+			Target:     pkg,
+			BuildFlags: request.GetString("buildFlags", ""),
+			Tags:       request.GetStringSlice("tags", nil),
+			TestFlags:  debugger.GoTestFlags(run, request.GetString("subtest", ""), count),
+			Args:       request.GetStringSlice("args", nil),
+			Env:        request.GetStringSlice("env", nil),
+			Dir:        request.GetString("dir", ""),
+		}
+		return launchSession(ctx, request, sessions, sessionName(request, pkg), cfg, map[string]interface{}{
+			"testFlags": cfg.ProgramArgs(),
+		})
+	}
+}
+
+func makeOpenCore(sessions *session.Manager) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		binary, err := request.RequireString("binary")
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("binary parameter error: %v", err)), nil
+		}
+		core, err := request.RequireString("core")
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("core parameter error: %v", err)), nil
+		}
+
+		cfg := debugger.LaunchConfig{
+			Mode:   debugger.ModeCore,
+			Target: binary,
+			Core:   core,
+		}
+		return launchSession(ctx, request, sessions, sessionName(request, core), cfg, nil)
+	}
+}
+
+func makeListProcesses() server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		procs, err := debugger.ListGoProcesses()
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("list processes failed: %v", err)), nil
+		}
+
+		filter := request.GetString("filter", "")
+		matched := make([]debugger.GoProcess, 0, len(procs))
+		for _, p := range procs {
+			if filter == "" || strings.Contains(p.Exe, filter) || strings.Contains(p.Path, filter) ||
+				strings.Contains(strings.Join(p.Cmdline, " "), filter) {
+				matched = append(matched, p)
+			}
+		}
+
+		return jsonResult(map[string]interface{}{
+			"processes": matched,
+		})
+	}
+}
+
+// launchSession starts Delve for cfg and opens a session named name for it,
+// selecting it unless the request sets select to false. Entries in extra are
+// added to the result.
+func launchSession(ctx context.Context, request mcp.CallToolRequest, sessions *session.Manager, name string, cfg debugger.LaunchConfig, extra map[string]interface{}) (*mcp.CallToolResult, error) {
+	if sessions.Has(name) {
+		return mcp.NewToolResultError(fmt.Sprintf("%v: %s", session.ErrExists, name)), nil
+	}
+
+	proc, err := debugger.Launch(ctx, cfg)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("launch failed: %v", err)), nil
+	}
+	sess, err := sessions.OpenProcess(name, proc)
+	if err != nil {
+		proc.Stop()
+		return mcp.NewToolResultError(fmt.Sprintf("open session failed: %v", err)), nil
+	}
+	if v, err := request.RequireInt("idleTimeout"); err == nil {
+		sess.SetIdleTimeout(time.Duration(v) * time.Second)
+	}
+	if request.GetBool("select", true) {
+		sessions.Select(name)
+	}
+
+	result := map[string]interface{}{
+		"success": true,
+		"session": sessionInfo(sess, sessions.Current()),
+		"message": fmt.Sprintf("Delve (%s) listening on %s as session %s", describeLaunch(cfg), proc.Addr, name),
+	}
+	if proc.BuildInfo != nil {
+		result["buildInfo"] = proc.BuildInfo
+	}
+	for k, v := range extra {
+		result[k] = v
+	}
+	return jsonResult(result)
+}
+
+// describeLaunch summarizes what a launch configuration debugs.
+func describeLaunch(cfg debugger.LaunchConfig) string {
+	switch cfg.Mode {
+	case debugger.ModeAttach:
+		return fmt.Sprintf("attach %d", cfg.Pid)
+	case debugger.ModeCore:
+		return fmt.Sprintf("core %s %s", cfg.Target, cfg.Core)
+	}
+	return cfg.Mode + " " + cfg.Target
+}
+
+// sessionName returns the requested session name or one derived from target.
+func sessionName(request mcp.CallToolRequest, target string) string {
+	if name := request.GetString("name", ""); name != "" {
+		return name
+	}
+	base := filepath.Base(filepath.Clean(target))
+	if base == "." || base == string(filepath.Separator) {
+		return "launch"
+	}
+	return base
+}
@@ -0,0 +1,89 @@
+package tools
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestLaunchTools_Arguments(t *testing.T) {
+	h := newHarness(t)
+	h.callError("launch", nil, "package parameter error")
+	h.callError("attach", nil, "pid parameter error")
+	h.callError("exec", nil, "binary parameter error")
+	h.callError("debug_test", map[string]interface{}{"package": "./pkg"}, "run parameter error")
+	h.callError("open_core", map[string]interface{}{"binary": "/bin/app"}, "core parameter error")
+}
+
+func TestLaunch_ExistingSession(t *testing.T) {
+	h := newHarness(t)
+	h.callError("launch", map[string]interface{}{"package": "./cmd/app", "name": "default"}, "already exists")
+	h.callError("attach", map[string]interface{}{"pid": 1, "name": "default"}, "already exists")
+
+	// A refused launch is not a use of the existing session.
+	sess := h.sessions.List()[0]
+	last := sess.LastUsed()
+	time.Sleep(time.Millisecond)
+	h.callError("launch", map[string]interface{}{"package": "./cmd/app", "name": "default"}, "already exists")
+	if !sess.LastUsed().Equal(last) {
+		t.Errorf("LastUsed moved from %s to %s", last, sess.LastUsed())
+	}
+}
+
+func TestLaunch_DelveMissing(t *testing.T) {
+	h := newHarness(t)
+	t.Setenv("PATH", t.TempDir())
+
+	h.callError("launch", map[string]interface{}{"package": "./cmd/app"}, "launch failed")
+	if n := len(h.sessions.List()); n != 1 {
+		t.Errorf("%d sessions after a failed launch, want 1", n)
+	}
+}
+
+func TestExec_NotABinary(t *testing.T) {
+	h := newHarness(t)
+	h.callError("exec", map[string]interface{}{"binary": "/definitely/not/here"}, "/definitely/not/here")
+	h.callError("exec", map[string]interface{}{"binary": "bin/app", "dir": "/definitely/not"}, "/definitely/not/bin/app")
+}
+
+func TestLaunch_IdleTimeout(t *testing.T) {
+	h := newHarness(t)
+	if sess := h.launch("plain"); sess.IdleTimeout() != 0 {
+		t.Errorf("launched session idle timeout = %s, want 0", sess.IdleTimeout())
+	}
+	h.callJSON("launch", map[string]interface{}{"package": "./cmd/app", "name": "reaped", "idleTimeout": 90})
+	sess, err := h.sessions.Get("reaped")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sess.IdleTimeout() != 90*time.Second {
+		t.Errorf("idle timeout = %s, want 1m30s", sess.IdleTimeout())
+	}
+}
+
+func TestExec_BuildInfo(t *testing.T) {
+	h := newHarness(t)
+	h.delve.InstallDlv(t)
+	exe, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := h.callJSON("exec", map[string]interface{}{"binary": exe, "name": "release"})
+	if bi, _ := out["buildInfo"].(map[string]interface{}); bi["goVersion"] == nil {
+		t.Fatalf("exec result buildInfo = %v", out["buildInfo"])
+	}
+
+	sessions := h.callJSON("list_sessions", nil)["sessions"].([]interface{})
+	for _, s := range sessions {
+		info := s.(map[string]interface{})
+		if info["name"] != "release" {
+			continue
+		}
+		process := info["process"].(map[string]interface{})
+		if bi, _ := process["buildInfo"].(map[string]interface{}); bi["goVersion"] == nil {
+			t.Fatalf("list_sessions buildInfo = %v", process["buildInfo"])
+		}
+		return
+	}
+	t.Fatalf("session release not listed: %v", sessions)
+}
@@ -0,0 +1,318 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/kjbreil/dlc-sidecar/internal/debugger"
+	"github.com/kjbreil/dlc-sidecar/internal/session"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+func registerLifecycle(s *server.MCPServer, sessions *session.Manager) {
+	// restart
+	addSessionTool(s, sessions, mcp.NewTool("restart",
+		mcp.WithDescription("Restart the target from the beginning, optionally rebuilding it and changing its arguments or environment. "+
+			"Breakpoints are kept unless keepBreakpoints is false; those Delve cannot re-apply, for instance because a rebuild moved "+
+			"their line, are reported"),
+		mcp.WithBoolean("rebuild",
+			mcp.Description("Rebuild the program before restarting; only for sessions where Delve built it (launch, debug_test) (default: false)"),
+		),
+		mcp.WithArray("args",
+			mcp.Description("New program arguments, replacing the current ones (default: keep the current arguments)"),
+			mcp.WithStringItems(),
+		),
+		mcp.WithArray("env",
+			mcp.Description("Extra environment variables as KEY=VALUE. Delve cannot change the environment of a running target, "+
+				"so this restarts the sidecar-managed Delve itself, rebuilding the program unless it was started with exec. "+
+				"Trace and logpoint output carries over; watchpoints are cleared"),
+			mcp.WithStringItems(),
+		),
+		mcp.WithBoolean("keepBreakpoints",
+			mcp.Description("Re-apply existing breakpoints after the restart (default: true)"),
+		),
+	), liveOnly(makeRestart(sessions)))
+
+	// detach
+	addSessionTool(s, sessions, mcp.NewTool("detach",
+		mcp.WithDescription("End the debug session, detaching Delve from the target. The session is closed afterwards"),
+		mcp.WithBoolean("continue",
+			mcp.Description("Let the target run on without the debugger; false halts it and disconnects, leaving it stopped "+
+				"under a Delve started elsewhere for another client (default: true)"),
+		),
+	), makeDetach(sessions))
+
+	// kill
+	addSessionTool(s, sessions, mcp.NewTool("kill",
+		mcp.WithDescription("End the debug session and kill the target. The session is closed afterwards"),
+	), liveOnly(makeKill(sessions)))
+}
+
+func makeDetach(sessions *session.Manager) sessionHandler {
+	return func(ctx context.Context, request mcp.CallToolRequest, sess *session.Session) (*mcp.CallToolResult, error) {
+		policy, target := session.ExitContinue, "running"
+		if !request.GetBool("continue", true) {
+			if sess.Process != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("session %s runs a Delve started by the sidecar, which stops with the session; "+
+					"use continue=true or kill", sess.Name)), nil
+			}
+			policy, target = session.ExitHalt, "halted"
+		}
+		return endSession(sessions, sess, policy, target)
+	}
+}
+
+func makeKill(sessions *session.Manager) sessionHandler {
+	return func(ctx context.Context, request mcp.CallToolRequest, sess *session.Session) (*mcp.CallToolResult, error) {
+		return endSession(sessions, sess, session.ExitKill, "killed")
+	}
+}
+
+// endSession ends sess with policy and reports the target's fate.
+func endSession(sessions *session.Manager, sess *session.Session, policy session.ExitPolicy, target string) (*mcp.CallToolResult, error) {
+	if err := sessions.End(sess.Name, policy); err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("session %s closed, but ending it failed: %v", sess.Name, err)), nil
+	}
+	return jsonResult(map[string]interface{}{
+		"success": true,
+		"session": sess.Name,
+		"target":  target,
+		"message": fmt.Sprintf("Session %s ended; target %s", sess.Name, target),
+	})
+}
+
+func makeRestart(sessions *session.Manager) sessionHandler {
+	return func(ctx context.Context, request mcp.CallToolRequest, sess *session.Session) (*mcp.CallToolResult, error) {
+		if r := sess.Pool.CurrentRun(); r != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("%s is running (run %d); halt the target before restarting", r.Command, r.ID)), nil
+		}
+
+		rebuild := request.GetBool("rebuild", false)
+		_, rebuildSet := request.GetArguments()["rebuild"]
+		keep := request.GetBool("keepBreakpoints", true)
+		args := request.GetStringSlice("args", nil)
+		_, newArgs := request.GetArguments()["args"]
+		env := request.GetStringSlice("env", nil)
+
+		if len(env) > 0 {
+			if rebuildSet {
+				return relaunch(ctx, sessions, sess, args, newArgs, env, keep, &rebuild)
+			}
+			return relaunch(ctx, sessions, sess, args, newArgs, env, keep, nil)
+		}
+
+		if !keep {
+			if err := clearUserBreakpoints(ctx, sess.Pool); err != nil {
+				return rpcError(err), nil
+			}
+		}
+		restartArgs := args
+		if newArgs && sess.Process != nil {
+			// Keep the flags that select a debug_test session's tests.
+			cfg := sess.Process.Config
+			cfg.Args = args
+			restartArgs = cfg.ProgramArgs()
+		}
+		discarded, err := sess.Pool.Restart(ctx, debugger.RestartIn{
+			Rebuild:   rebuild,
+			ResetArgs: newArgs,
+			NewArgs:   restartArgs,
+		})
+		if err != nil {
+			return rpcError(err), nil
+		}
+
+		result := restartResult(sess.Name, keep, discarded)
+		result["rebuilt"] = rebuild
+		if newArgs {
+			result["args"] = args
+		}
+		return breakpointsChanged(ctx, sess, result)
+	}
+}
+
+// relaunch restarts a session whose Delve the sidecar started with a new
+// environment, which Delve's Restart cannot change. A new Delve is launched
+// and, once it runs, replaces the old one under the same session name; the
+// old one is killed. Breakpoints are recreated on it when keep is set, and
+// the trace and logpoint output carries over. Watchpoints watch memory of the
+// old process and are dropped. A new Delve rebuilds the program unless it
+// runs a prebuilt binary; rebuild, if set, must agree.
+func relaunch(ctx context.Context, sessions *session.Manager, sess *session.Session, args []string, newArgs bool, env []string, keep bool, rebuild *bool) (*mcp.CallToolResult, error) {
+	if sess.Process == nil {
+		return mcp.NewToolResultError(fmt.Sprintf("session %s connects to a Delve started elsewhere; "+
+			"env can only be changed for sessions started with launch, exec or debug_test", sess.Name)), nil
+	}
+	cfg := sess.Process.Config
+	switch cfg.Mode {
+	case debugger.ModeAttach, debugger.ModeCore:
+		return mcp.NewToolResultError(fmt.Sprintf("session %s did not start its target; env cannot be changed", sess.Name)), nil
+	}
+	rebuilt := cfg.Mode != debugger.ModeExec
+	if rebuild != nil && *rebuild != rebuilt {
+		if rebuilt {
+			return mcp.NewToolResultError(fmt.Sprintf("changing env relaunches Delve, which rebuilds the program of session %s; "+
+				"rebuild=false cannot be combined with env", sess.Name)), nil
+		}
+		return mcp.NewToolResultError(fmt.Sprintf("session %s runs a prebuilt binary that cannot be rebuilt", sess.Name)), nil
+	}
+
+	bps, err := sess.Pool.ListBreakpoints(ctx, false)
+	if err != nil {
+		return rpcError(err), nil
+	}
+
+	cfg.Env = append(append([]string{}, cfg.Env...), env...)
+	if newArgs {
+		cfg.Args = args
+	}
+	proc, err := debugger.Launch(ctx, cfg)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("relaunch failed, session %s is unchanged: %v", sess.Name, err)), nil
+	}
+	if m := sess.Manifest(); m != nil && !keep {
+		// The new Delve would otherwise restore the saved breakpoints.
+		if err := m.Save(sess.Name, nil); err != nil {
+			proc.Stop()
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+	}
+
+	selected := sessions.Current() == sess.Name
+	idle, kill := sess.IdleTimeout(), sess.KillOnClose()
+	sess.SetKillOnClose(true)
+	// The session is removed even if stopping the old Delve fails.
+	closeErr := sessions.Close(sess.Name)
+	next, err := sessions.OpenProcess(sess.Name, proc)
+	if err != nil {
+		proc.Stop()
+		return mcp.NewToolResultError(fmt.Sprintf("open session failed: %v", err)), nil
+	}
+	next.SetIdleTimeout(idle)
+	next.SetKillOnClose(kill)
+	next.Traces.Inherit(sess.Traces)
+	next.Logs.Inherit(sess.Logs)
+	if selected {
+		sessions.Select(next.Name)
+	}
+
+	// The new Delve restores the saved breakpoints on connect when they are
+	// persisted; only the rest are recreated here.
+	existing, err := next.Pool.ListBreakpoints(ctx, false)
+	if err != nil {
+		return rpcError(err), nil
+	}
+	set := make(map[string]bool)
+	for _, bp := range existing {
+		set[fmt.Sprintf("%s:%d", bp.File, bp.Line)] = true
+	}
+	var discarded []debugger.DiscardedBreakpoint
+	var watches []map[string]interface{}
+	for _, bp := range bps {
+		if bp.WatchExpr != "" {
+			watches = append(watches, map[string]interface{}{"id": bp.ID, "expr": bp.WatchExpr})
+			continue
+		}
+		if !keep || set[fmt.Sprintf("%s:%d", bp.File, bp.Line)] {
+			continue
+		}
+		got, err := next.Pool.CreateBreakpoint(ctx, recreatedBreakpoint(bp))
+		if err != nil {
+			discarded = append(discarded, debugger.DiscardedBreakpoint{Breakpoint: bp, Reason: err.Error()})
+			continue
+		}
+		if t, ok := sess.Logs.Template(bp.ID); ok {
+			next.Logs.SetTemplate(got.ID, t)
+		}
+	}
+
+	result := restartResult(next.Name, keep, discarded)
+	result["rebuilt"] = rebuilt
+	result["relaunched"] = true
+	if len(watches) > 0 {
+		result["watchpointsCleared"] = watches
+	}
+	result["env"] = cfg.Env
+	if closeErr != nil {
+		result["stopError"] = fmt.Sprintf("stopping the previous Delve failed: %v", closeErr)
+	}
+	if newArgs {
+		result["args"] = args
+	}
+	return breakpointsChanged(ctx, next, result)
+}
+
+// clearUserBreakpoints deletes every breakpoint set by a client, leaving
+// Delve's internal ones in place.
+func clearUserBreakpoints(ctx context.Context, pool *debugger.Pool) error {
+	bps, err := pool.ListBreakpoints(ctx, false)
+	if err != nil {
+		return err
+	}
+	for _, bp := range bps {
+		if bp.ID <= 0 {
+			continue
+		}
+		if _, err := pool.ClearBreakpoint(ctx, bp.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// recreatedBreakpoint copies the user-settable parts of bp into a request
+// that sets it again on another Delve.
+func recreatedBreakpoint(bp *debugger.Breakpoint) *debugger.Breakpoint {
+	return &debugger.Breakpoint{
+		Name:         bp.Name,
+		File:         bp.File,
+		Line:         bp.Line,
+		Cond:         bp.Cond,
+		HitCond:      bp.HitCond,
+		HitCondPerG:  bp.HitCondPerG,
+		Tracepoint:   bp.Tracepoint,
+		TraceReturn:  bp.TraceReturn,
+		Goroutine:    bp.Goroutine,
+		Stacktrace:   bp.Stacktrace,
+		Variables:    bp.Variables,
+		LoadArgs:     bp.LoadArgs,
+		LoadLocals:   bp.LoadLocals,
+		Disabled:     bp.Disabled,
+		RootFuncName: bp.RootFuncName,
+	}
+}
+
+// restartResult describes a completed restart, listing the breakpoints that
+// could not be re-applied.
+func restartResult(name string, keep bool, discarded []debugger.DiscardedBreakpoint) map[string]interface{} {
+	list := make([]map[string]interface{}, 0, len(discarded))
+	for _, d := range discarded {
+		entry := map[string]interface{}{"reason": d.Reason}
+		if bp := d.Breakpoint; bp != nil {
+			entry["id"] = bp.ID
+			entry["file"] = bp.File
+			entry["line"] = bp.Line
+			if bp.FunctionName != "" {
+				entry["function"] = bp.FunctionName
+			}
+		}
+		list = append(list, entry)
+	}
+
+	breakpoints := "kept"
+	if !keep {
+		breakpoints = "cleared"
+	}
+	message := fmt.Sprintf("Session %s restarted", name)
+	if len(discarded) > 0 {
+		message += fmt.Sprintf("; %d breakpoint(s) could not be re-applied", len(discarded))
+	}
+	return map[string]interface{}{
+		"success":              true,
+		"session":              name,
+		"breakpoints":          breakpoints,
+		"discardedBreakpoints": list,
+		"message":              message,
+	}
+}
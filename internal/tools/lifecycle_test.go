@@ -0,0 +1,230 @@
+package tools
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/kjbreil/dlc-sidecar/internal/debugger"
+	"github.com/kjbreil/dlc-sidecar/internal/debugger/debuggertest"
+	"github.com/kjbreil/dlc-sidecar/internal/session"
+)
+
+func TestRestart(t *testing.T) {
+	h := newHarness(t)
+	h.callJSON("set_breakpoint", map[string]interface{}{"file": "/src/main.go", "line": 12})
+	h.delve.QueueStop(debugger.DebuggerState{Exited: true, ExitStatus: 1})
+	h.callJSON("continue", nil)
+
+	out := h.callJSON("restart", map[string]interface{}{"rebuild": true, "args": []interface{}{"-v", "serve"}})
+	if out["success"] != true || out["rebuilt"] != true || out["breakpoints"] != "kept" {
+		t.Errorf("result = %v", out)
+	}
+	if got := out["discardedBreakpoints"].([]interface{}); len(got) != 0 {
+		t.Errorf("discarded = %v, want none", got)
+	}
+	if h.delve.State().Exited {
+		t.Error("target still exited after restart")
+	}
+
+	calls := h.delve.Calls("Restart")
+	in := calls[len(calls)-1].Args.(debugger.RestartIn)
+	if !in.Rebuild || !in.ResetArgs || len(in.NewArgs) != 2 || in.NewArgs[1] != "serve" {
+		t.Errorf("Restart request = %+v", in)
+	}
+	if len(h.delve.Breakpoints()) != 1 {
+		t.Error("breakpoint was not kept")
+	}
+
+	h.callJSON("restart", nil)
+	calls = h.delve.Calls("Restart")
+	if in := calls[len(calls)-1].Args.(debugger.RestartIn); in.Rebuild || in.ResetArgs {
+		t.Errorf("Restart request without options = %+v", in)
+	}
+}
+
+func TestRestart_DiscardedBreakpoints(t *testing.T) {
+	h := newHarness(t)
+	h.callJSON("set_breakpoint", map[string]interface{}{"file": "/src/main.go", "line": 12})
+	h.callJSON("set_breakpoint", map[string]interface{}{"file": "/src/main.go", "line": 40})
+	h.delve.DiscardOnRestart(2, "could not find /src/main.go:40")
+
+	out := h.callJSON("restart", map[string]interface{}{"rebuild": true})
+	got := out["discardedBreakpoints"].([]interface{})
+	if len(got) != 1 {
+		t.Fatalf("discarded = %v", got)
+	}
+	d := got[0].(map[string]interface{})
+	if d["id"] != float64(2) || d["line"] != float64(40) || d["reason"] != "could not find /src/main.go:40" {
+		t.Errorf("discarded breakpoint = %v", d)
+	}
+	if out["message"] != "Session default restarted; 1 breakpoint(s) could not be re-applied" {
+		t.Errorf("message = %v", out["message"])
+	}
+}
+
+func TestRestart_DiscardBreakpoints(t *testing.T) {
+	h := newHarness(t)
+	h.callJSON("set_breakpoint", map[string]interface{}{"file": "/src/main.go", "line": 12})
+
+	out := h.callJSON("restart", map[string]interface{}{"keepBreakpoints": false})
+	if out["breakpoints"] != "cleared" {
+		t.Errorf("breakpoints = %v", out["breakpoints"])
+	}
+	if bps := h.delve.Breakpoints(); len(bps) != 0 {
+		t.Errorf("delve breakpoints = %+v, want none", bps)
+	}
+}
+
+func TestRestart_Errors(t *testing.T) {
+	h := newHarness(t)
+	h.callError("restart", map[string]interface{}{"env": []interface{}{"DEBUG=1"}},
+		"env can only be changed for sessions started with launch, exec or debug_test")
+
+	h.delve.Fail("Restart", errors.New("cannot rebuild a program attached to"))
+	h.callError("restart", map[string]interface{}{"rebuild": true}, "RPC call failed: Restart: cannot rebuild")
+	h.delve.Fail("Restart", nil)
+
+	h.callJSON("continue", map[string]interface{}{"async": true})
+	h.waitFor(h.delve.Running)
+	h.callError("restart", nil, "halt the target before restarting")
+	if len(h.delve.Calls("Restart")) != 1 {
+		t.Error("restart reached Delve while the target was running")
+	}
+}
+
+func TestRestart_Relaunch(t *testing.T) {
+	h := newHarness(t)
+	old := h.launch("app")
+	h.callJSON("set_tracepoint", map[string]interface{}{"file": "/src/main.go", "line": 12})
+	h.callJSON("set_breakpoint", map[string]interface{}{"file": "/src/main.go", "line": 30})
+	h.delve.SetEval("n", debugger.Variable{Type: "int", Kind: int(reflect.Int), Addr: 0xc000012340, Value: "3"})
+	h.callJSON("set_watchpoint", map[string]interface{}{"expr": "n"})
+	h.delve.HitBreakpoint(1)
+	h.delve.HitBreakpoint(2)
+	h.callJSON("continue", nil)
+
+	h.callError("restart", map[string]interface{}{"env": []interface{}{"DEBUG=1"}, "rebuild": false},
+		"rebuild=false cannot be combined with env")
+	out := h.callJSON("restart", map[string]interface{}{"env": []interface{}{"DEBUG=1"}, "rebuild": true})
+	if out["relaunched"] != true || out["rebuilt"] != true || out["breakpoints"] != "kept" {
+		t.Errorf("result = %v", out)
+	}
+	cleared, _ := out["watchpointsCleared"].([]interface{})
+	if len(cleared) != 1 || cleared[0].(map[string]interface{})["expr"] != "n" {
+		t.Errorf("watchpointsCleared = %v", out["watchpointsCleared"])
+	}
+	if out := h.callJSON("get_trace_hits", nil); out["count"] != float64(1) {
+		t.Errorf("get_trace_hits after relaunch = %v, want the earlier hit", out)
+	}
+	next, err := h.sessions.Get("app")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if next == old || !old.Process.Exited() {
+		t.Error("the old Delve was not replaced")
+	}
+	if env := next.Process.Config.Env; len(env) != 1 || env[0] != "DEBUG=1" {
+		t.Errorf("env = %q", env)
+	}
+}
+
+func TestRestart_RelaunchFailureKeepsSession(t *testing.T) {
+	h := newHarness(t)
+	manifest := session.NewManifest(filepath.Join(t.TempDir(), "breakpoints.json"))
+	h.sessions.PersistBreakpoints(manifest)
+	old := h.launch("app")
+	h.callJSON("set_breakpoint", map[string]interface{}{"session": "app", "file": "/src/main.go", "line": 12})
+
+	h.callError("restart", map[string]interface{}{
+		"session":         "app",
+		"env":             []interface{}{debuggertest.BuildErrorEnv + "=1"},
+		"keepBreakpoints": false,
+	}, "relaunch failed, session app is unchanged: delve exited before listening")
+
+	sess, err := h.sessions.Get("app")
+	if err != nil || sess != old || old.Process.Exited() {
+		t.Fatalf("session after a failed relaunch = %v, %v", sess, err)
+	}
+	if detached, _ := h.delve.Detached(); detached {
+		t.Error("the old Delve was detached")
+	}
+	if saved, err := manifest.Load("app"); err != nil || len(saved) != 1 {
+		t.Errorf("saved breakpoints = %v, %v, want the one set", saved, err)
+	}
+	h.callJSON("list_breakpoints", map[string]interface{}{"session": "app"})
+}
+
+func TestRestart_KeepsTestFlags(t *testing.T) {
+	h := newHarness(t)
+	h.delve.InstallDlv(t)
+	h.callJSON("debug_test", map[string]interface{}{"package": "./pkg/store", "run": "TestGet", "name": "store"})
+	want := []string{"-test.v", "-test.run", "TestGet", "-test.count", "1", "-test.short"}
+
+	h.callJSON("restart", map[string]interface{}{"session": "store", "args": []interface{}{"-test.short"}})
+	calls := h.delve.Calls("Restart")
+	if in := calls[len(calls)-1].Args.(debugger.RestartIn); !reflect.DeepEqual(in.NewArgs, want) {
+		t.Errorf("Restart args = %q, want %q", in.NewArgs, want)
+	}
+
+	h.callJSON("restart", map[string]interface{}{"session": "store", "args": []interface{}{"-test.short"}, "env": []interface{}{"DEBUG=1"}})
+	sess, err := h.sessions.Get("store")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := sess.Process.Config.ProgramArgs(); !reflect.DeepEqual(got, want) {
+		t.Errorf("relaunched args = %q, want %q", got, want)
+	}
+}
+
+func TestDetach(t *testing.T) {
+	h := newHarness(t)
+	out := h.callJSON("detach", nil)
+	if out["target"] != "running" || out["message"] != "Session default ended; target running" {
+		t.Errorf("result = %v", out)
+	}
+	if detached, kill := h.delve.Detached(); !detached || kill {
+		t.Errorf("detached = %v, kill = %v, want a detach that keeps the target", detached, kill)
+	}
+	if _, err := h.sessions.Get("default"); err == nil {
+		t.Error("session still open after detach")
+	}
+}
+
+func TestDetach_LeaveHalted(t *testing.T) {
+	h := newHarness(t)
+	h.callJSON("continue", map[string]interface{}{"async": true})
+	h.waitFor(h.delve.Running)
+
+	out := h.callJSON("detach", map[string]interface{}{"continue": false})
+	if out["target"] != "halted" {
+		t.Errorf("result = %v", out)
+	}
+	if detached, _ := h.delve.Detached(); detached {
+		t.Error("detach with continue=false should leave Delve attached")
+	}
+	calls := h.delve.Calls("Command")
+	if last := calls[len(calls)-1].Args.(debugger.DebuggerCommand); last.Name != debugger.CmdHalt {
+		t.Errorf("last command = %s, want halt", last.Name)
+	}
+}
+
+func TestKill(t *testing.T) {
+	h := newHarness(t)
+	h.callJSON("continue", map[string]interface{}{"async": true})
+	h.waitFor(h.delve.Running)
+
+	out := h.callJSON("kill", nil)
+	if out["target"] != "killed" {
+		t.Errorf("result = %v", out)
+	}
+	if detached, kill := h.delve.Detached(); !detached || !kill {
+		t.Errorf("detached = %v, kill = %v, want a killing detach", detached, kill)
+	}
+	if h.delve.Running() {
+		t.Error("target still running; kill should halt it before detaching")
+	}
+
+	h.callError("kill", nil, "no session selected")
+}
@@ -0,0 +1,125 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/kjbreil/dlc-sidecar/internal/debugger"
+	"github.com/kjbreil/dlc-sidecar/internal/session"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+func registerLogpoints(s *server.MCPServer, sessions *session.Manager) {
+	// set_logpoint
+	addSessionTool(s, sessions, mcp.NewTool("set_logpoint",
+		mcp.WithDescription("Set a logpoint: a tracepoint that prints a message instead of stopping. Expressions in "+
+			"{braces} are evaluated on every hit and the rendered lines are buffered by the sidecar while continue "+
+			"runs; read them with get_logpoint_output"),
+		withBreakpointLocation(),
+		mcp.WithString("message",
+			mcp.Required(),
+			mcp.Description("Message template with {expression} placeholders (e.g. \"user={u.ID} n={len(items)}\")"),
+		),
+		mcp.WithString("condition",
+			mcp.Description("Go expression; only hits where it evaluates to true are logged"),
+		),
+	), makeSetLogpoint())
+
+	// get_logpoint_output
+	addSessionTool(s, sessions, mcp.NewTool("get_logpoint_output",
+		mcp.WithDescription("Return the lines printed by logpoints, oldest first"),
+		mcp.WithNumber("id",
+			mcp.Description("Only return lines of this logpoint"),
+		),
+		mcp.WithNumber("goroutineID",
+			mcp.Description("Only return lines printed on this goroutine"),
+		),
+		mcp.WithNumber("after",
+			mcp.Description("Only return lines with a sequence number above this, to poll for new lines"),
+		),
+		mcp.WithNumber("limit",
+			mcp.Description("Maximum number of lines to return, keeping the most recent (default: 100)"),
+		),
+		mcp.WithBoolean("clear",
+			mcp.Description("Discard every buffered line after returning them (default: false)"),
+		),
+	), makeGetLogpointOutput())
+}
+
+func makeSetLogpoint() sessionHandler {
+	return func(ctx context.Context, request mcp.CallToolRequest, sess *session.Session) (*mcp.CallToolResult, error) {
+		msg, err := request.RequireString("message")
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("message parameter error: %v", err)), nil
+		}
+		tmpl, err := debugger.ParseLogTemplate(msg)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("message parameter error: %v", err)), nil
+		}
+		for _, expr := range tmpl.Expressions() {
+			if err := checkExpr(expr); err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("message parameter error: %v", err)), nil
+			}
+		}
+		want := &debugger.Breakpoint{
+			Tracepoint: true,
+			Variables:  tmpl.Expressions(),
+			Cond:       request.GetString("condition", ""),
+		}
+		if want.Cond != "" {
+			if err := checkExpr(want.Cond); err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("condition parameter error: %v", err)), nil
+			}
+		}
+
+		return setBreakpoints(ctx, request, sess, want, "Logpoint", func(bp *debugger.Breakpoint, info map[string]interface{}) {
+			sess.Logs.SetTemplate(bp.ID, tmpl)
+			info["logMessage"] = msg
+		})
+	}
+}
+
+func makeGetLogpointOutput() sessionHandler {
+	return func(ctx context.Context, request mcp.CallToolRequest, sess *session.Session) (*mcp.CallToolResult, error) {
+		filter := debugger.TraceFilter{
+			BreakpointID: request.GetInt("id", 0),
+			GoroutineID:  int64(request.GetInt("goroutineID", 0)),
+			After:        int64(request.GetInt("after", 0)),
+			Limit:        request.GetInt("limit", 100),
+		}
+
+		lines := sess.Logs.Lines(filter)
+		dropped := sess.Logs.Dropped()
+		if request.GetBool("clear", false) {
+			sess.Logs.Clear()
+		}
+
+		list := make([]map[string]interface{}, 0, len(lines))
+		for _, l := range lines {
+			info := map[string]interface{}{
+				"seq":         l.Seq,
+				"at":          l.At.Format(time.RFC3339Nano),
+				"id":          l.BreakpointID,
+				"goroutineID": l.GoroutineID,
+				"file":        l.File,
+				"line":        l.Line,
+				"message":     l.Message,
+			}
+			if l.Function != "" {
+				info["function"] = l.Function
+			}
+			list = append(list, info)
+		}
+		result := map[string]interface{}{
+			"lines":   list,
+			"count":   len(list),
+			"dropped": dropped,
+		}
+		if len(lines) > 0 {
+			result["lastSeq"] = lines[len(lines)-1].Seq
+		}
+		return jsonResult(result)
+	}
+}
@@ -0,0 +1,102 @@
+package tools
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/kjbreil/dlc-sidecar/internal/debugger"
+)
+
+func TestSetLogpoint(t *testing.T) {
+	h := newHarness(t)
+	out := h.callJSON("set_logpoint", map[string]interface{}{
+		"file":      "/src/main.go",
+		"line":      12,
+		"message":   "user={u.ID} n={len(items)}",
+		"condition": "u != nil",
+	})
+	bp := out["breakpoint"].(map[string]interface{})
+	if bp["tracepoint"] != true || bp["logMessage"] != "user={u.ID} n={len(items)}" || out["message"] != "Logpoint set at /src/main.go:12 (ID: 1)" {
+		t.Errorf("result = %v", out)
+	}
+	got := h.delve.Breakpoints()[0]
+	if !got.Tracepoint || got.Cond != "u != nil" || !reflect.DeepEqual(got.Variables, []string{"u.ID", "len(items)"}) {
+		t.Errorf("delve breakpoint = %+v", got)
+	}
+
+	out = h.callJSON("list_breakpoints", nil)
+	if listed := out["breakpoints"].([]interface{})[0].(map[string]interface{}); listed["logMessage"] != "user={u.ID} n={len(items)}" {
+		t.Errorf("listed = %v", listed)
+	}
+
+	h.callError("set_logpoint", map[string]interface{}{"file": "/src/main.go", "line": 12}, "message parameter error")
+	h.callError("set_logpoint", map[string]interface{}{"file": "/src/main.go", "line": 12, "message": "n={n"},
+		"message parameter error: invalid log message")
+	h.callError("set_logpoint", map[string]interface{}{"file": "/src/main.go", "line": 12, "message": "n={n +}"},
+		"message parameter error: invalid expression")
+}
+
+func TestAmendLogpoint(t *testing.T) {
+	h := newHarness(t)
+	h.callJSON("set_logpoint", map[string]interface{}{"file": "/src/main.go", "line": 12, "message": "n={n}"})
+
+	h.callError("amend_breakpoint", map[string]interface{}{"id": 1, "expressions": []string{"m"}},
+		"breakpoint 1 is a logpoint and its expressions cannot be amended")
+	h.callError("amend_breakpoint", map[string]interface{}{"id": 1, "tracepoint": false},
+		"breakpoint 1 is a logpoint and its tracepoint cannot be amended")
+	if got := h.delve.Breakpoints()[0]; !got.Tracepoint || !reflect.DeepEqual(got.Variables, []string{"n"}) {
+		t.Errorf("rejected amendment changed the breakpoint: %+v", got)
+	}
+
+	h.callJSON("amend_breakpoint", map[string]interface{}{"id": 1, "condition": "n > 1"})
+	out := h.callJSON("list_breakpoints", nil)
+	listed := out["breakpoints"].([]interface{})[0].(map[string]interface{})
+	if listed["condition"] != "n > 1" || listed["logMessage"] != "n={n}" {
+		t.Errorf("listed = %v", listed)
+	}
+}
+
+func TestLogpointOutput(t *testing.T) {
+	h := newHarness(t)
+	h.delve.SetEval("u.ID", debugger.Variable{Type: "int", Kind: int(reflect.Int), Value: "42"})
+	h.delve.SetEval("name", debugger.Variable{Type: "string", Kind: int(reflect.String), Value: "ann", Len: 3})
+	h.callJSON("set_logpoint", map[string]interface{}{"file": "/src/main.go", "line": 12, "message": "user={u.ID} name={name}"})
+	h.callJSON("set_tracepoint", map[string]interface{}{"file": "/src/main.go", "line": 20})
+	h.callJSON("set_breakpoint", map[string]interface{}{"file": "/src/main.go", "line": 30})
+
+	h.delve.HitBreakpoint(1)
+	h.delve.HitBreakpoint(2)
+	h.delve.HitBreakpoint(1)
+	h.delve.HitBreakpoint(3)
+	if out := h.callJSON("continue", nil); out["line"] != float64(30) {
+		t.Fatalf("continue stopped at %v, want the breakpoint past the logpoint", out)
+	}
+
+	out := h.callJSON("get_logpoint_output", nil)
+	lines := out["lines"].([]interface{})
+	if out["count"] != float64(2) || out["lastSeq"] != float64(2) {
+		t.Fatalf("get_logpoint_output = %v", out)
+	}
+	line := lines[0].(map[string]interface{})
+	if line["message"] != `user=42 name="ann"` || line["id"] != float64(1) || line["line"] != float64(12) || line["goroutineID"] != float64(1) {
+		t.Errorf("line = %v", line)
+	}
+	// Logpoint hits are not trace hits.
+	if out := h.callJSON("get_trace_hits", nil); out["count"] != float64(1) {
+		t.Errorf("get_trace_hits = %v", out)
+	}
+
+	if out := h.callJSON("get_logpoint_output", map[string]interface{}{"after": 1}); out["count"] != float64(1) {
+		t.Errorf("after 1 = %v", out)
+	}
+	h.callJSON("get_logpoint_output", map[string]interface{}{"clear": true})
+	if out := h.callJSON("get_logpoint_output", nil); out["count"] != float64(0) {
+		t.Errorf("after clear = %v", out)
+	}
+
+	// A cleared logpoint no longer has a template.
+	h.callJSON("clear_breakpoint", map[string]interface{}{"id": 1})
+	if _, ok := h.sessions.List()[0].Logs.Template(1); ok {
+		t.Error("template kept after clear_breakpoint")
+	}
+}
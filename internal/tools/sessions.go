@@ -0,0 +1,197 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/kjbreil/dlc-sidecar/internal/debugger"
+	"github.com/kjbreil/dlc-sidecar/internal/session"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+func registerSessions(s *server.MCPServer, sessions *session.Manager) {
+	// open_session
+	s.AddTool(mcp.NewTool("open_session",
+		mcp.WithDescription("Open a named debug session connected to a Delve instance"),
+		mcp.WithString("name",
+			mcp.Required(),
+			mcp.Description("Name used to refer to the session in other tools"),
+		),
+		mcp.WithString("addr",
+			mcp.Required(),
+			mcp.Description("Address of the headless Delve server: host:port, tcp://host:port or unix:/path/to/socket"),
+		),
+		mcp.WithBoolean("select",
+			mcp.Description("Make this the selected session for tools called without a session argument (default: false)"),
+		),
+		mcp.WithNumber("idleTimeout",
+			mcp.Description("Seconds the session may go unused before it is closed; 0 disables reaping (default: server setting)"),
+		),
+	), makeOpenSession(sessions))
+
+	// list_sessions
+	s.AddTool(mcp.NewTool("list_sessions",
+		mcp.WithDescription("List open debug sessions and which one is selected"),
+	), makeListSessions(sessions))
+
+	// select_session
+	s.AddTool(mcp.NewTool("select_session",
+		mcp.WithDescription("Select the session used by tools called without a session argument"),
+		mcp.WithString("name",
+			mcp.Required(),
+			mcp.Description("Name of the session to select"),
+		),
+	), makeSelectSession(sessions))
+
+	// close_session
+	s.AddTool(mcp.NewTool("close_session",
+		mcp.WithDescription("Close a debug session and its connection to Delve"),
+		mcp.WithString("name",
+			mcp.Required(),
+			mcp.Description("Name of the session to close"),
+		),
+		mcp.WithBoolean("kill",
+			mcp.Description("For sessions whose Delve the sidecar started: kill the target instead of detaching and "+
+				"letting it continue (default: true for launched programs, false for attached processes)"),
+		),
+	), makeCloseSession(sessions))
+}
+
+func makeOpenSession(sessions *session.Manager) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		name, err := request.RequireString("name")
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("name parameter error: %v", err)), nil
+		}
+		addr, err := request.RequireString("addr")
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("addr parameter error: %v", err)), nil
+		}
+
+		if _, _, err := debugger.ParseAddr(addr); err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		sess, err := sessions.Open(name, addr)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("open session failed: %v", err)), nil
+		}
+		if v, err := request.RequireInt("idleTimeout"); err == nil {
+			sess.SetIdleTimeout(time.Duration(v) * time.Second)
+		}
+		if v, err := request.RequireBool("select"); err == nil && v {
+			if err := sessions.Select(name); err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("select session failed: %v", err)), nil
+			}
+		}
+
+		return jsonResult(map[string]interface{}{
+			"success": true,
+			"session": sessionInfo(sess, sessions.Current()),
+			"message": fmt.Sprintf("Session %s opened for %s", name, addr),
+		})
+	}
+}
+
+func makeListSessions(sessions *session.Manager) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		current := sessions.Current()
+		list := make([]map[string]interface{}, 0)
+		for _, sess := range sessions.List() {
+			list = append(list, sessionInfo(sess, current))
+		}
+
+		return jsonResult(map[string]interface{}{
+			"sessions": list,
+			"selected": current,
+		})
+	}
+}
+
+func makeSelectSession(sessions *session.Manager) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		name, err := request.RequireString("name")
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("name parameter error: %v", err)), nil
+		}
+		if err := sessions.Select(name); err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		return jsonResult(map[string]interface{}{
+			"success": true,
+			"message": fmt.Sprintf("Session %s selected", name),
+		})
+	}
+}
+
+func makeCloseSession(sessions *session.Manager) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		name, err := request.RequireString("name")
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("name parameter error: %v", err)), nil
+		}
+		if v, err := request.RequireBool("kill"); err == nil {
+			sess, err := sessions.Get(name)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			sess.SetKillOnClose(v)
+		}
+		if err := sessions.Close(name); err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("close session failed: %v", err)), nil
+		}
+
+		return jsonResult(map[string]interface{}{
+			"success": true,
+			"message": fmt.Sprintf("Session %s closed", name),
+		})
+	}
+}
+
+// sessionInfo describes a session for list and open results.
+func sessionInfo(sess *session.Session, current string) map[string]interface{} {
+	info := map[string]interface{}{
+		"name":     sess.Name,
+		"addr":     sess.Pool.Addr(),
+		"selected": sess.Name == current,
+		"created":  sess.Created.Format(time.RFC3339),
+		"lastUsed": sess.LastUsed().Format(time.RFC3339),
+	}
+	if d := sess.IdleTimeout(); d > 0 {
+		info["idleTimeout"] = d.String()
+	}
+	if r := sess.Pool.CurrentRun(); r != nil {
+		info["running"] = r.ID
+	}
+	if proc := sess.Process; proc != nil {
+		process := map[string]interface{}{
+			"dlvPid": proc.Pid(),
+			"mode":   proc.Config.Mode,
+			"exited": proc.Exited(),
+		}
+		if proc.Config.Target != "" {
+			process["target"] = proc.Config.Target
+		}
+		if proc.Config.Core != "" {
+			process["core"] = proc.Config.Core
+		}
+		if proc.Config.Pid != 0 {
+			process["targetPid"] = proc.Config.Pid
+		}
+		if proc.BuildInfo != nil {
+			process["buildInfo"] = proc.BuildInfo
+		}
+		if err := proc.Err(); err != nil {
+			process["error"] = err.Error()
+		}
+		info["process"] = process
+		info["killOnClose"] = sess.KillOnClose()
+		if sess.PostMortem() {
+			info["postMortem"] = true
+		}
+	}
+	return info
+}
@@ -0,0 +1,64 @@
+package tools
+
+import (
+	"testing"
+
+	"github.com/kjbreil/dlc-sidecar/internal/debugger/debuggertest"
+)
+
+func TestOpenSession(t *testing.T) {
+	h := newHarness(t)
+	other := debuggertest.NewServer(t)
+
+	out := h.callJSON("open_session", map[string]interface{}{
+		"name":        "server",
+		"addr":        other.Addr,
+		"select":      true,
+		"idleTimeout": 60,
+	})
+	sess := out["session"].(map[string]interface{})
+	if sess["name"] != "server" || sess["selected"] != true || sess["idleTimeout"] != "1m0s" {
+		t.Errorf("session = %v", sess)
+	}
+
+	// Tools now default to the selected session, and session= overrides it.
+	h.callJSON("set_breakpoint", map[string]interface{}{"file": "/src/server.go", "line": 3})
+	h.callJSON("set_breakpoint", map[string]interface{}{"file": "/src/client.go", "line": 4, "session": "default"})
+	if bps := other.Breakpoints(); len(bps) != 1 || bps[0].File != "/src/server.go" {
+		t.Errorf("server breakpoints = %+v", bps)
+	}
+	if bps := h.delve.Breakpoints(); len(bps) != 1 || bps[0].File != "/src/client.go" {
+		t.Errorf("default breakpoints = %+v", bps)
+	}
+}
+
+func TestOpenSession_Errors(t *testing.T) {
+	h := newHarness(t)
+	h.callError("open_session", map[string]interface{}{"addr": "localhost:1"}, "name parameter error")
+	h.callError("open_session", map[string]interface{}{"name": "x"}, "addr parameter error")
+	h.callError("open_session", map[string]interface{}{"name": "x", "addr": "unix:"}, "unix")
+	h.callError("open_session", map[string]interface{}{"name": "default", "addr": h.delve.Addr}, "already exists")
+}
+
+func TestListSelectCloseSessions(t *testing.T) {
+	h := newHarness(t)
+	other := debuggertest.NewServer(t)
+	h.callJSON("open_session", map[string]interface{}{"name": "server", "addr": other.Addr})
+
+	out := h.callJSON("list_sessions", nil)
+	if out["selected"] != "default" || len(out["sessions"].([]interface{})) != 2 {
+		t.Errorf("list_sessions = %v", out)
+	}
+
+	h.callJSON("select_session", map[string]interface{}{"name": "server"})
+	if h.sessions.Current() != "server" {
+		t.Errorf("selected = %q, want server", h.sessions.Current())
+	}
+	h.callError("select_session", map[string]interface{}{"name": "nope"}, "nope")
+
+	h.callJSON("close_session", map[string]interface{}{"name": "server"})
+	h.callError("close_session", map[string]interface{}{"name": "server"}, "close session failed")
+	if n := len(h.sessions.List()); n != 1 {
+		t.Errorf("%d sessions left, want 1", n)
+	}
+}
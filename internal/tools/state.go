@@ -3,20 +3,36 @@ package tools
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/kjbreil/dlc-sidecar/internal/debugger"
+	"github.com/kjbreil/dlc-sidecar/internal/session"
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
 )
 
-func registerState(s *server.MCPServer, pool *debugger.Pool) {
+// connectionNotification is the MCP notification method sent when a
+// session's health monitor sees the connection state change.
+const connectionNotification = "notifications/debugger/connection"
+
+// logNotification is the MCP method for log messages; connection changes
+// are also logged so clients that only surface logs still see them.
+const logNotification = "notifications/message"
+
+func registerState(s *server.MCPServer, sessions *session.Manager) {
 	// get_state
-	s.AddTool(mcp.NewTool("get_state",
+	addSessionTool(s, sessions, mcp.NewTool("get_state",
 		mcp.WithDescription("Get the current debugger state including position, goroutine, and thread info"),
-	), makeGetState(pool))
+	), makeGetState())
+
+	// connection_status
+	addSessionTool(s, sessions, mcp.NewTool("connection_status",
+		mcp.WithDescription("Report the Delve server this session talks to: address, Delve and Go versions, backend, "+
+			"target PID and command line, and whether the target has exited"),
+	), makeConnectionStatus())
 
 	// stacktrace
-	s.AddTool(mcp.NewTool("stacktrace",
+	addSessionTool(s, sessions, mcp.NewTool("stacktrace",
 		mcp.WithDescription("Get a stacktrace of the current goroutine"),
 		mcp.WithNumber("goroutineID",
 			mcp.Description("Goroutine ID (default: -1 for current)"),
@@ -27,33 +43,150 @@ func registerState(s *server.MCPServer, pool *debugger.Pool) {
 		mcp.WithBoolean("full",
 			mcp.Description("Include local variables and arguments in each frame (default: false)"),
 		),
-	), makeStacktrace(pool))
+	), makeStacktrace())
 
 	// list_goroutines
-	s.AddTool(mcp.NewTool("list_goroutines",
+	addSessionTool(s, sessions, mcp.NewTool("list_goroutines",
 		mcp.WithDescription("List all goroutines in the debugged process"),
 		mcp.WithNumber("count",
 			mcp.Description("Maximum number of goroutines to return (default: 100)"),
 		),
-	), makeListGoroutines(pool))
+	), makeListGoroutines())
+
+	sessions.OnOpen(func(sess *session.Session) {
+		if sess.Monitor == nil {
+			return
+		}
+		sess.Monitor.OnChange(func(t debugger.Transition) {
+			s.SendNotificationToAllClients(connectionNotification, transitionInfo(sess, t))
+			level, msg := transitionMessage(sess, t)
+			s.SendNotificationToAllClients(logNotification, map[string]interface{}{
+				"level":  level,
+				"logger": "dlc-sidecar",
+				"data":   msg,
+			})
+		})
+	})
+}
+
+// transitionInfo describes a connection state change for a notification.
+func transitionInfo(sess *session.Session, t debugger.Transition) map[string]interface{} {
+	info := map[string]interface{}{
+		"session":  sess.Name,
+		"addr":     sess.Pool.Addr(),
+		"state":    t.To.String(),
+		"previous": t.From.String(),
+		"at":       t.At.Format(time.RFC3339),
+	}
+	if t.Err != nil {
+		info["error"] = t.Err.Error()
+	}
+	if t.Attempts > 0 {
+		info["attempts"] = t.Attempts
+	}
+	if t.To == debugger.ConnTargetExited {
+		info["exitStatus"] = t.ExitStatus
+	}
+	return info
 }
 
-func makeGetState(pool *debugger.Pool) server.ToolHandlerFunc {
-	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-		req := debugger.StateIn{NonBlocking: true}
-		var resp debugger.StateOut
-		if err := pool.Call("State", req, &resp); err != nil {
-			return mcp.NewToolResultError(fmt.Sprintf("RPC call failed: %v", err)), nil
+// transitionMessage returns the log level and message for a connection
+// state change.
+func transitionMessage(sess *session.Session, t debugger.Transition) (mcp.LoggingLevel, string) {
+	switch t.To {
+	case debugger.ConnDisconnected:
+		return mcp.LoggingLevelWarning, fmt.Sprintf("session %s: lost connection to Delve at %s: %v", sess.Name, sess.Pool.Addr(), t.Err)
+	case debugger.ConnTargetExited:
+		return mcp.LoggingLevelNotice, fmt.Sprintf("session %s: target exited with status %d", sess.Name, t.ExitStatus)
+	}
+	if t.From == debugger.ConnDisconnected {
+		return mcp.LoggingLevelInfo, fmt.Sprintf("session %s: reconnected to Delve at %s after %d failed attempts", sess.Name, sess.Pool.Addr(), t.Attempts)
+	}
+	return mcp.LoggingLevelInfo, fmt.Sprintf("session %s: connected to Delve at %s", sess.Name, sess.Pool.Addr())
+}
+
+// healthInfo describes a session's connection health for connection_status.
+func healthInfo(h debugger.Health) map[string]interface{} {
+	info := map[string]interface{}{
+		"state": h.State.String(),
+		"since": h.Since.Format(time.RFC3339),
+	}
+	if !h.LastPing.IsZero() {
+		info["lastPing"] = h.LastPing.Format(time.RFC3339)
+	}
+	if h.Err != nil {
+		info["error"] = h.Err.Error()
+		info["attempts"] = h.Attempts
+	}
+	if !h.NextAttempt.IsZero() {
+		info["nextAttempt"] = h.NextAttempt.Format(time.RFC3339)
+	}
+	return info
+}
+
+func makeGetState() sessionHandler {
+	return func(ctx context.Context, request mcp.CallToolRequest, sess *session.Session) (*mcp.CallToolResult, error) {
+		st, err := sess.Pool.State(ctx, true)
+		if err != nil {
+			return rpcError(err), nil
 		}
 
 		return jsonResult(map[string]interface{}{
-			"state": resp.State,
+			"state": st,
 		})
 	}
 }
 
-func makeStacktrace(pool *debugger.Pool) server.ToolHandlerFunc {
-	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+func makeConnectionStatus() sessionHandler {
+	return func(ctx context.Context, request mcp.CallToolRequest, sess *session.Session) (*mcp.CallToolResult, error) {
+		result := map[string]interface{}{
+			"session":   sess.Name,
+			"addr":      sess.Pool.Addr(),
+			"connected": false,
+		}
+		if sess.Monitor != nil {
+			result["health"] = healthInfo(sess.Monitor.Health())
+		}
+		if err := sess.Pool.Connect(); err != nil {
+			result["error"] = err.Error()
+			return jsonResult(result)
+		}
+		result["connected"] = true
+		if info := sess.Pool.Info(); info != nil {
+			result["delveVersion"] = info.DelveVersion
+			result["apiVersion"] = info.APIVersion
+			result["backend"] = info.Backend
+			result["targetGoVersion"] = info.TargetGoVersion
+			result["multiclient"] = info.Multiclient
+			result["connectedAt"] = info.ConnectedAt
+			if len(info.Warnings) > 0 {
+				result["warnings"] = info.Warnings
+			}
+		}
+		if sess.PostMortem() {
+			result["postMortem"] = true
+		}
+
+		st, err := sess.Pool.State(ctx, true)
+		if err != nil {
+			result["stateError"] = err.Error()
+			return jsonResult(result)
+		}
+		if st != nil {
+			result["pid"] = st.Pid
+			result["commandLine"] = st.TargetCommandLine
+			result["running"] = st.Running
+			result["exited"] = st.Exited
+			if st.Exited {
+				result["exitStatus"] = st.ExitStatus
+			}
+		}
+		return jsonResult(result)
+	}
+}
+
+func makeStacktrace() sessionHandler {
+	return func(ctx context.Context, request mcp.CallToolRequest, sess *session.Session) (*mcp.CallToolResult, error) {
 		goroutineID := int64(-1)
 		depth := 50
 		full := false
@@ -67,44 +200,36 @@ func makeStacktrace(pool *debugger.Pool) server.ToolHandlerFunc {
 			full = v
 		}
 
-		req := debugger.StacktraceIn{
-			Id:    goroutineID,
-			Depth: depth,
-			Full:  full,
-		}
+		var cfg *debugger.LoadConfig
 		if full {
-			cfg := debugger.DefaultLoadConfig()
-			req.Cfg = &cfg
+			c := debugger.DefaultLoadConfig()
+			cfg = &c
 		}
-		var resp debugger.StacktraceOut
-		if err := pool.Call("Stacktrace", req, &resp); err != nil {
-			return mcp.NewToolResultError(fmt.Sprintf("RPC call failed: %v", err)), nil
+		frames, err := sess.Pool.Stacktrace(ctx, goroutineID, depth, cfg)
+		if err != nil {
+			return rpcError(err), nil
 		}
 
 		return jsonResult(map[string]interface{}{
-			"frames": resp.Locations,
+			"frames": frames,
 		})
 	}
 }
 
-func makeListGoroutines(pool *debugger.Pool) server.ToolHandlerFunc {
-	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+func makeListGoroutines() sessionHandler {
+	return func(ctx context.Context, request mcp.CallToolRequest, sess *session.Session) (*mcp.CallToolResult, error) {
 		count := 100
 		if v, err := request.RequireInt("count"); err == nil {
 			count = v
 		}
 
-		req := debugger.ListGoroutinesIn{
-			Start: 0,
-			Count: count,
-		}
-		var resp debugger.ListGoroutinesOut
-		if err := pool.Call("ListGoroutines", req, &resp); err != nil {
-			return mcp.NewToolResultError(fmt.Sprintf("RPC call failed: %v", err)), nil
+		goroutines, _, err := sess.Pool.ListGoroutines(ctx, 0, count)
+		if err != nil {
+			return rpcError(err), nil
 		}
 
 		return jsonResult(map[string]interface{}{
-			"goroutines": resp.Goroutines,
+			"goroutines": goroutines,
 		})
 	}
 }
@@ -0,0 +1,164 @@
+package tools
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kjbreil/dlc-sidecar/internal/debugger"
+	"github.com/kjbreil/dlc-sidecar/internal/debugger/debuggertest"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestGetState(t *testing.T) {
+	h := newHarness(t)
+	out := h.callJSON("get_state", nil)
+	st := out["state"].(map[string]interface{})
+	if st["Pid"] != float64(4242) || st["Running"] != false {
+		t.Errorf("state = %v", st)
+	}
+	if !h.delve.Calls("State")[0].Args.(debugger.StateIn).NonBlocking {
+		t.Error("get_state should not block on a running target")
+	}
+}
+
+func TestConnectionStatus(t *testing.T) {
+	h := newHarness(t)
+	out := h.callJSON("connection_status", nil)
+
+	want := map[string]interface{}{
+		"session":         "default",
+		"addr":            h.delve.Addr,
+		"connected":       true,
+		"delveVersion":    "Version: 1.23.1 (debuggertest)",
+		"apiVersion":      float64(2),
+		"backend":         "native",
+		"targetGoVersion": "go1.23.2",
+		"multiclient":     true,
+		"pid":             float64(4242),
+		"commandLine":     "/tmp/__debug_bin",
+		"running":         false,
+		"exited":          false,
+	}
+	for k, v := range want {
+		if out[k] != v {
+			t.Errorf("%s = %v, want %v", k, out[k], v)
+		}
+	}
+	if _, ok := out["postMortem"]; ok {
+		t.Error("live session reported as post-mortem")
+	}
+}
+
+func TestConnectionStatus_Warnings(t *testing.T) {
+	h := newHarness(t)
+	h.delve.SetMulticlient(false)
+	h.delve.SetState(debugger.DebuggerState{Pid: 1, Exited: true, ExitStatus: 3})
+
+	out := h.callJSON("connection_status", nil)
+	if out["multiclient"] != false || out["warnings"] == nil {
+		t.Errorf("multiclient = %v, warnings = %v", out["multiclient"], out["warnings"])
+	}
+	if out["exited"] != true || out["exitStatus"] != float64(3) {
+		t.Errorf("exited = %v, exitStatus = %v", out["exited"], out["exitStatus"])
+	}
+}
+
+func TestStacktrace(t *testing.T) {
+	h := newHarness(t)
+	h.delve.SetStack(-1,
+		debugger.Stackframe{
+			Location: debugger.Location{File: "/src/main.go", Line: 12},
+			Locals:   []debugger.Variable{{Name: "n"}},
+		},
+		debugger.Stackframe{Location: debugger.Location{File: "/src/main.go", Line: 30}},
+	)
+
+	out := h.callJSON("stacktrace", nil)
+	frames := out["frames"].([]interface{})
+	if len(frames) != 2 {
+		t.Fatalf("frames = %v", frames)
+	}
+	if frames[0].(map[string]interface{})["Locals"] != nil {
+		t.Error("locals should only be loaded with full=true")
+	}
+	in := h.delve.Calls("Stacktrace")[0].Args.(debugger.StacktraceIn)
+	if in.Id != -1 || in.Depth != 50 || in.Full {
+		t.Errorf("default request = %+v", in)
+	}
+
+	out = h.callJSON("stacktrace", map[string]interface{}{"depth": 0, "full": true})
+	frames = out["frames"].([]interface{})
+	if len(frames) != 1 || frames[0].(map[string]interface{})["Locals"] == nil {
+		t.Errorf("full frames = %v", frames)
+	}
+}
+
+func TestListGoroutines(t *testing.T) {
+	h := newHarness(t)
+	var gs []*debugger.Goroutine
+	for i := int64(1); i <= 5; i++ {
+		gs = append(gs, &debugger.Goroutine{ID: i})
+	}
+	h.delve.SetGoroutines(gs...)
+
+	out := h.callJSON("list_goroutines", map[string]interface{}{"count": 3})
+	if n := len(out["goroutines"].([]interface{})); n != 3 {
+		t.Errorf("got %d goroutines, want 3", n)
+	}
+	out = h.callJSON("list_goroutines", nil)
+	if n := len(out["goroutines"].([]interface{})); n != 5 {
+		t.Errorf("got %d goroutines, want 5", n)
+	}
+
+	h.delve.Fail("ListGoroutines", errors.New("boom"))
+	h.callError("list_goroutines", nil, "RPC call failed: ListGoroutines: boom")
+}
+
+func TestConnectionNotifications(t *testing.T) {
+	h := newHarness(t)
+	h.sessions.MonitorHealth(debugger.MonitorConfig{
+		Interval:   10 * time.Millisecond,
+		MinBackoff: 10 * time.Millisecond,
+		MaxBackoff: 10 * time.Millisecond,
+	})
+	delve := debuggertest.NewServer(t)
+	h.callJSON("open_session", map[string]interface{}{"name": "watched", "addr": delve.Addr})
+
+	n := h.notification(connectionNotification)
+	if f := n.Params.AdditionalFields; f["session"] != "watched" || f["state"] != "connected" || f["previous"] != "unknown" {
+		t.Errorf("connect notification = %v", f)
+	}
+
+	delve.Close()
+	n = h.notification(connectionNotification)
+	if f := n.Params.AdditionalFields; f["state"] != "disconnected" || f["error"] == nil {
+		t.Errorf("disconnect notification = %v", f)
+	}
+	n = h.notification(logNotification)
+	if f := n.Params.AdditionalFields; f["level"] != mcp.LoggingLevelWarning ||
+		!strings.Contains(f["data"].(string), "session watched: lost connection to Delve") {
+		t.Errorf("log notification = %v", f)
+	}
+
+	out := h.callJSON("connection_status", map[string]interface{}{"session": "watched"})
+	health := out["health"].(map[string]interface{})
+	if health["state"] != "disconnected" || health["attempts"] == nil {
+		t.Errorf("health = %v", health)
+	}
+}
+
+func TestConnectionNotifications_TargetExited(t *testing.T) {
+	h := newHarness(t)
+	h.sessions.MonitorHealth(debugger.MonitorConfig{Interval: 10 * time.Millisecond})
+	delve := debuggertest.NewServer(t)
+	h.callJSON("open_session", map[string]interface{}{"name": "watched", "addr": delve.Addr})
+	h.notification(connectionNotification)
+
+	delve.SetState(debugger.DebuggerState{Exited: true, ExitStatus: 1})
+	n := h.notification(connectionNotification)
+	if f := n.Params.AdditionalFields; f["state"] != "target exited" || f["exitStatus"] != 1 {
+		t.Errorf("exit notification = %v", f)
+	}
+}
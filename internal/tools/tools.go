@@ -1,14 +1,57 @@
 package tools
 
 import (
-	"github.com/kjbreil/dlc-sidecar/internal/debugger"
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/kjbreil/dlc-sidecar/internal/session"
+	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
 )
 
 // Register adds all debugging tools to the MCP server.
-func Register(s *server.MCPServer, pool *debugger.Pool) {
-	registerBreakpoints(s, pool)
-	registerExecution(s, pool)
-	registerVariables(s, pool)
-	registerState(s, pool)
+func Register(s *server.MCPServer, sessions *session.Manager) {
+	registerSessions(s, sessions)
+	registerLaunch(s, sessions)
+	registerBreakpoints(s, sessions)
+	registerTracing(s, sessions)
+	registerLogpoints(s, sessions)
+	registerBreakpointFiles(s, sessions)
+	registerWatchpoints(s, sessions)
+	registerExecution(s, sessions)
+	registerVariables(s, sessions)
+	registerState(s, sessions)
+	registerLifecycle(s, sessions)
+}
+
+// sessionHandler is a tool handler bound to the debug session named in the request.
+type sessionHandler func(ctx context.Context, request mcp.CallToolRequest, sess *session.Session) (*mcp.CallToolResult, error)
+
+// addSessionTool registers tool with an optional session argument and
+// resolves that session, or the selected one, before invoking h.
+func addSessionTool(s *server.MCPServer, sessions *session.Manager, tool mcp.Tool, h sessionHandler) {
+	mcp.WithString("session",
+		mcp.Description("Name of the debug session to use (default: the selected session)"),
+	)(&tool)
+
+	s.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		sess, err := sessions.Get(request.GetString("session", ""))
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		return h(ctx, request, sess)
+	})
+}
+
+// rpcError converts a failed debugger call into a tool error result, calling
+// out timeouts and cancellations so the agent knows the target may still be running.
+func rpcError(err error) *mcp.CallToolResult {
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return mcp.NewToolResultError(fmt.Sprintf("RPC call timed out: %v (the target may still be running; use halt to stop it)", err))
+	case errors.Is(err, context.Canceled):
+		return mcp.NewToolResultError(fmt.Sprintf("RPC call canceled: %v", err))
+	}
+	return mcp.NewToolResultError(fmt.Sprintf("RPC call failed: %v", err))
 }
@@ -0,0 +1,154 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/kjbreil/dlc-sidecar/internal/debugger"
+	"github.com/kjbreil/dlc-sidecar/internal/session"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+func registerTracing(s *server.MCPServer, sessions *session.Manager) {
+	// set_tracepoint
+	addSessionTool(s, sessions, mcp.NewTool("set_tracepoint",
+		mcp.WithDescription("Set a tracepoint: a breakpoint that records data and lets the target continue instead of stopping. "+
+			"Hits are buffered by the sidecar while continue runs; read them with get_trace_hits"),
+		withBreakpointLocation(),
+		mcp.WithArray("expressions",
+			mcp.Description("Expressions to evaluate and record on each hit"),
+			mcp.WithStringItems(),
+		),
+		mcp.WithBoolean("args",
+			mcp.Description("Record the function arguments on each hit (default: false)"),
+		),
+		mcp.WithBoolean("locals",
+			mcp.Description("Record the local variables on each hit (default: false)"),
+		),
+		mcp.WithNumber("stackDepth",
+			mcp.Description("Number of stack frames to record on each hit (default: 0)"),
+		),
+		mcp.WithString("condition",
+			mcp.Description("Go expression; only hits where it evaluates to true are recorded"),
+		),
+	), makeSetTracepoint())
+
+	// get_trace_hits
+	addSessionTool(s, sessions, mcp.NewTool("get_trace_hits",
+		mcp.WithDescription("Return the tracepoint hits buffered by the sidecar, oldest first"),
+		mcp.WithNumber("id",
+			mcp.Description("Only return hits of this tracepoint"),
+		),
+		mcp.WithNumber("goroutineID",
+			mcp.Description("Only return hits on this goroutine"),
+		),
+		mcp.WithNumber("after",
+			mcp.Description("Only return hits with a sequence number above this, to poll for new hits"),
+		),
+		mcp.WithNumber("limit",
+			mcp.Description("Maximum number of hits to return, keeping the most recent (default: 100)"),
+		),
+		mcp.WithBoolean("clear",
+			mcp.Description("Discard every buffered hit after returning them (default: false)"),
+		),
+	), makeGetTraceHits())
+}
+
+func makeSetTracepoint() sessionHandler {
+	return func(ctx context.Context, request mcp.CallToolRequest, sess *session.Session) (*mcp.CallToolResult, error) {
+		want := &debugger.Breakpoint{
+			Tracepoint: true,
+			Variables:  request.GetStringSlice("expressions", nil),
+			Stacktrace: request.GetInt("stackDepth", 0),
+			Cond:       request.GetString("condition", ""),
+		}
+		for _, expr := range want.Variables {
+			if err := checkExpr(expr); err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("expressions parameter error: %v", err)), nil
+			}
+		}
+		if want.Cond != "" {
+			if err := checkExpr(want.Cond); err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("condition parameter error: %v", err)), nil
+			}
+		}
+		if want.Stacktrace < 0 {
+			return mcp.NewToolResultError("stackDepth parameter error: must not be negative"), nil
+		}
+		if request.GetBool("args", false) {
+			cfg := debugger.DefaultLoadConfig()
+			want.LoadArgs = &cfg
+		}
+		if request.GetBool("locals", false) {
+			cfg := debugger.DefaultLoadConfig()
+			want.LoadLocals = &cfg
+		}
+
+		return setBreakpoints(ctx, request, sess, want, "Tracepoint", nil)
+	}
+}
+
+func makeGetTraceHits() sessionHandler {
+	return func(ctx context.Context, request mcp.CallToolRequest, sess *session.Session) (*mcp.CallToolResult, error) {
+		filter := debugger.TraceFilter{
+			BreakpointID: request.GetInt("id", 0),
+			GoroutineID:  int64(request.GetInt("goroutineID", 0)),
+			After:        int64(request.GetInt("after", 0)),
+			Limit:        request.GetInt("limit", 100),
+		}
+
+		hits := sess.Traces.Hits(filter)
+		dropped := sess.Traces.Dropped()
+		if request.GetBool("clear", false) {
+			sess.Traces.Clear()
+		}
+
+		list := make([]map[string]interface{}, 0, len(hits))
+		for _, h := range hits {
+			list = append(list, traceHitInfo(h))
+		}
+		result := map[string]interface{}{
+			"hits":    list,
+			"count":   len(list),
+			"dropped": dropped,
+		}
+		if len(hits) > 0 {
+			result["lastSeq"] = hits[len(hits)-1].Seq
+		}
+		return jsonResult(result)
+	}
+}
+
+// traceHitInfo describes a recorded tracepoint hit and the data it captured.
+func traceHitInfo(h debugger.TraceHit) map[string]interface{} {
+	info := map[string]interface{}{
+		"seq":         h.Seq,
+		"at":          h.At.Format(time.RFC3339Nano),
+		"goroutineID": h.GoroutineID,
+		"file":        h.File,
+		"line":        h.Line,
+	}
+	if h.Breakpoint != nil {
+		info["id"] = h.Breakpoint.ID
+	}
+	if h.Function != "" {
+		info["function"] = h.Function
+	}
+	if bi := h.Info; bi != nil {
+		if len(bi.Variables) > 0 {
+			info["expressions"] = bi.Variables
+		}
+		if len(bi.Arguments) > 0 {
+			info["args"] = bi.Arguments
+		}
+		if len(bi.Locals) > 0 {
+			info["locals"] = bi.Locals
+		}
+		if len(bi.Stacktrace) > 0 {
+			info["stacktrace"] = bi.Stacktrace
+		}
+	}
+	return info
+}
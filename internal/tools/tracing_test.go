@@ -0,0 +1,92 @@
+package tools
+
+import (
+	"testing"
+
+	"github.com/kjbreil/dlc-sidecar/internal/debugger"
+)
+
+func TestSetTracepoint(t *testing.T) {
+	h := newHarness(t)
+	out := h.callJSON("set_tracepoint", map[string]interface{}{
+		"file":        "/src/main.go",
+		"line":        12,
+		"expressions": []interface{}{"req.URL", "n"},
+		"args":        true,
+		"stackDepth":  2,
+	})
+	bp := out["breakpoint"].(map[string]interface{})
+	if bp["tracepoint"] != true || bp["stackDepth"] != float64(2) || len(bp["expressions"].([]interface{})) != 2 {
+		t.Errorf("breakpoint = %v", bp)
+	}
+	got := h.delve.Breakpoints()[0]
+	if !got.Tracepoint || got.LoadArgs == nil || got.LoadLocals != nil || got.Stacktrace != 2 || got.Variables[0] != "req.URL" {
+		t.Errorf("delve breakpoint = %+v", got)
+	}
+
+	h.callError("set_tracepoint", map[string]interface{}{"file": "/src/main.go", "line": 12, "expressions": []interface{}{"a +"}},
+		"expressions parameter error: invalid expression")
+	h.callError("set_tracepoint", map[string]interface{}{"file": "/src/main.go", "line": 12, "stackDepth": -1},
+		"stackDepth parameter error")
+}
+
+func TestTraceHits(t *testing.T) {
+	h := newHarness(t)
+	h.delve.SetEval("n", debugger.Variable{Name: "n", Type: "int", Value: "7"})
+	h.delve.SetStack(-1, debugger.Stackframe{Location: debugger.Location{File: "/src/main.go", Line: 12}})
+	h.callJSON("set_tracepoint", map[string]interface{}{"file": "/src/main.go", "line": 12, "expressions": []interface{}{"n"}, "stackDepth": 1})
+	h.callJSON("set_breakpoint", map[string]interface{}{"file": "/src/main.go", "line": 30})
+
+	h.delve.HitBreakpoint(1)
+	h.delve.HitBreakpoint(1)
+	h.delve.HitBreakpoint(2)
+	out := h.callJSON("continue", nil)
+	if out["line"] != float64(30) {
+		t.Fatalf("continue stopped at %v, want the breakpoint past the tracepoint", out)
+	}
+
+	out = h.callJSON("get_trace_hits", nil)
+	hits := out["hits"].([]interface{})
+	if out["count"] != float64(2) || len(hits) != 2 || out["lastSeq"] != float64(2) {
+		t.Fatalf("get_trace_hits = %v", out)
+	}
+	hit := hits[0].(map[string]interface{})
+	if hit["id"] != float64(1) || hit["goroutineID"] != float64(1) || hit["line"] != float64(12) {
+		t.Errorf("hit = %v", hit)
+	}
+	if v := hit["expressions"].([]interface{})[0].(map[string]interface{}); v["value"] != "7" {
+		t.Errorf("expression = %v", v)
+	}
+	if frames := hit["stacktrace"].([]interface{}); len(frames) != 1 {
+		t.Errorf("stacktrace = %v", frames)
+	}
+
+	if out := h.callJSON("get_trace_hits", map[string]interface{}{"after": 1}); out["count"] != float64(1) {
+		t.Errorf("after 1 = %v", out)
+	}
+	if out := h.callJSON("get_trace_hits", map[string]interface{}{"id": 2}); out["count"] != float64(0) {
+		t.Errorf("id 2 = %v", out)
+	}
+	if out := h.callJSON("get_trace_hits", map[string]interface{}{"goroutineID": 5}); out["count"] != float64(0) {
+		t.Errorf("goroutine 5 = %v", out)
+	}
+	h.callJSON("get_trace_hits", map[string]interface{}{"clear": true})
+	if out := h.callJSON("get_trace_hits", nil); out["count"] != float64(0) {
+		t.Errorf("after clear = %v", out)
+	}
+}
+
+func TestTraceHits_HaltStopsTracing(t *testing.T) {
+	h := newHarness(t)
+	h.callJSON("set_tracepoint", map[string]interface{}{"file": "/src/main.go", "line": 12})
+	h.callJSON("continue", map[string]interface{}{"async": true})
+	h.waitFor(h.delve.Running)
+
+	h.delve.HitBreakpoint(1)
+	h.waitFor(func() bool { return len(h.sessions.List()[0].Traces.Hits(debugger.TraceFilter{})) == 1 })
+	h.waitFor(h.delve.Running)
+	h.callJSON("halt", nil)
+	if out := h.callJSON("wait_for_stop", nil); out["status"] != "stopped" {
+		t.Errorf("wait_for_stop = %v", out)
+	}
+}
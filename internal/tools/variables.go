@@ -5,13 +5,14 @@ import (
 	"fmt"
 
 	"github.com/kjbreil/dlc-sidecar/internal/debugger"
+	"github.com/kjbreil/dlc-sidecar/internal/session"
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
 )
 
-func registerVariables(s *server.MCPServer, pool *debugger.Pool) {
+func registerVariables(s *server.MCPServer, sessions *session.Manager) {
 	// list_local_vars
-	s.AddTool(mcp.NewTool("list_local_vars",
+	addSessionTool(s, sessions, mcp.NewTool("list_local_vars",
 		mcp.WithDescription("List all local variables in the current scope"),
 		mcp.WithNumber("goroutineID",
 			mcp.Description("Goroutine ID to scope the request to (default: -1 for current)"),
@@ -19,10 +20,10 @@ func registerVariables(s *server.MCPServer, pool *debugger.Pool) {
 		mcp.WithNumber("frame",
 			mcp.Description("Stack frame index (default: 0 for current frame)"),
 		),
-	), makeListLocalVars(pool))
+	), makeListLocalVars())
 
 	// list_function_args
-	s.AddTool(mcp.NewTool("list_function_args",
+	addSessionTool(s, sessions, mcp.NewTool("list_function_args",
 		mcp.WithDescription("List all arguments of the current function"),
 		mcp.WithNumber("goroutineID",
 			mcp.Description("Goroutine ID to scope the request to (default: -1 for current)"),
@@ -30,10 +31,10 @@ func registerVariables(s *server.MCPServer, pool *debugger.Pool) {
 		mcp.WithNumber("frame",
 			mcp.Description("Stack frame index (default: 0 for current frame)"),
 		),
-	), makeListFunctionArgs(pool))
+	), makeListFunctionArgs())
 
 	// eval
-	s.AddTool(mcp.NewTool("eval",
+	addSessionTool(s, sessions, mcp.NewTool("eval",
 		mcp.WithDescription("Evaluate an expression in the current scope and return the result"),
 		mcp.WithString("expr",
 			mcp.Required(),
@@ -45,66 +46,50 @@ func registerVariables(s *server.MCPServer, pool *debugger.Pool) {
 		mcp.WithNumber("frame",
 			mcp.Description("Stack frame index (default: 0 for current frame)"),
 		),
-	), makeEval(pool))
+	), makeEval())
 }
 
-func makeListLocalVars(pool *debugger.Pool) server.ToolHandlerFunc {
-	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-		scope := evalScope(request)
-		req := debugger.ListLocalVarsIn{
-			Scope: scope,
-			Cfg:   debugger.DefaultLoadConfig(),
-		}
-		var resp debugger.ListLocalVarsOut
-		if err := pool.Call("ListLocalVars", req, &resp); err != nil {
-			return mcp.NewToolResultError(fmt.Sprintf("RPC call failed: %v", err)), nil
+func makeListLocalVars() sessionHandler {
+	return func(ctx context.Context, request mcp.CallToolRequest, sess *session.Session) (*mcp.CallToolResult, error) {
+		vars, err := sess.Pool.ListLocalVars(ctx, evalScope(request), debugger.DefaultLoadConfig())
+		if err != nil {
+			return rpcError(err), nil
 		}
 
 		return jsonResult(map[string]interface{}{
-			"variables": resp.Variables,
+			"variables": vars,
 		})
 	}
 }
 
-func makeListFunctionArgs(pool *debugger.Pool) server.ToolHandlerFunc {
-	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-		scope := evalScope(request)
-		req := debugger.ListFunctionArgsIn{
-			Scope: scope,
-			Cfg:   debugger.DefaultLoadConfig(),
-		}
-		var resp debugger.ListFunctionArgsOut
-		if err := pool.Call("ListFunctionArgs", req, &resp); err != nil {
-			return mcp.NewToolResultError(fmt.Sprintf("RPC call failed: %v", err)), nil
+func makeListFunctionArgs() sessionHandler {
+	return func(ctx context.Context, request mcp.CallToolRequest, sess *session.Session) (*mcp.CallToolResult, error) {
+		args, err := sess.Pool.ListFunctionArgs(ctx, evalScope(request), debugger.DefaultLoadConfig())
+		if err != nil {
+			return rpcError(err), nil
 		}
 
 		return jsonResult(map[string]interface{}{
-			"args": resp.Args,
+			"args": args,
 		})
 	}
 }
 
-func makeEval(pool *debugger.Pool) server.ToolHandlerFunc {
-	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+func makeEval() sessionHandler {
+	return func(ctx context.Context, request mcp.CallToolRequest, sess *session.Session) (*mcp.CallToolResult, error) {
 		expr, err := request.RequireString("expr")
 		if err != nil {
 			return mcp.NewToolResultError(fmt.Sprintf("expr parameter error: %v", err)), nil
 		}
 
-		scope := evalScope(request)
 		cfg := debugger.DefaultLoadConfig()
-		req := debugger.EvalIn{
-			Scope: scope,
-			Expr:  expr,
-			Cfg:   &cfg,
-		}
-		var resp debugger.EvalOut
-		if err := pool.Call("Eval", req, &resp); err != nil {
-			return mcp.NewToolResultError(fmt.Sprintf("RPC call failed: %v", err)), nil
+		v, err := sess.Pool.Eval(ctx, evalScope(request), expr, &cfg)
+		if err != nil {
+			return rpcError(err), nil
 		}
 
 		return jsonResult(map[string]interface{}{
-			"variable": resp.Variable,
+			"variable": v,
 		})
 	}
 }
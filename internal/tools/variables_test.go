@@ -0,0 +1,59 @@
+package tools
+
+import (
+	"testing"
+
+	"github.com/kjbreil/dlc-sidecar/internal/debugger"
+)
+
+func TestListLocalVars(t *testing.T) {
+	h := newHarness(t)
+	h.delve.SetLocals(debugger.Variable{Name: "n", Type: "int", Value: "3"})
+
+	out := h.callJSON("list_local_vars", nil)
+	vars := out["variables"].([]interface{})
+	if len(vars) != 1 || vars[0].(map[string]interface{})["value"] != "3" {
+		t.Errorf("variables = %v", vars)
+	}
+
+	in := h.delve.Calls("ListLocalVars")[0].Args.(debugger.ListLocalVarsIn)
+	if in.Scope.GoroutineID != -1 || in.Scope.Frame != 0 {
+		t.Errorf("default scope = %+v, want current goroutine, frame 0", in.Scope)
+	}
+	if in.Cfg != debugger.DefaultLoadConfig() {
+		t.Errorf("load config = %+v", in.Cfg)
+	}
+}
+
+func TestListFunctionArgs(t *testing.T) {
+	h := newHarness(t)
+	h.delve.SetArgs(debugger.Variable{Name: "req", Type: "*main.Request"})
+
+	out := h.callJSON("list_function_args", map[string]interface{}{"goroutineID": 7, "frame": 2})
+	args := out["args"].([]interface{})
+	if len(args) != 1 || args[0].(map[string]interface{})["name"] != "req" {
+		t.Errorf("args = %v", args)
+	}
+
+	in := h.delve.Calls("ListFunctionArgs")[0].Args.(debugger.ListFunctionArgsIn)
+	if in.Scope.GoroutineID != 7 || in.Scope.Frame != 2 {
+		t.Errorf("scope = %+v, want goroutine 7 frame 2", in.Scope)
+	}
+}
+
+func TestEval(t *testing.T) {
+	h := newHarness(t)
+	h.delve.SetEval("cfg.Addr", debugger.Variable{Name: "cfg.Addr", Type: "string", Value: ":8080"})
+
+	out := h.callJSON("eval", map[string]interface{}{"expr": "cfg.Addr", "frame": 1})
+	if v := out["variable"].(map[string]interface{}); v["value"] != ":8080" {
+		t.Errorf("variable = %v", v)
+	}
+	in := h.delve.Calls("Eval")[0].Args.(debugger.EvalIn)
+	if in.Scope.Frame != 1 || in.Cfg == nil {
+		t.Errorf("eval request = %+v", in)
+	}
+
+	h.callError("eval", map[string]interface{}{"expr": "missing"}, "could not find symbol value for missing")
+	h.callError("eval", nil, "expr parameter error")
+}
@@ -0,0 +1,122 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/kjbreil/dlc-sidecar/internal/debugger"
+	"github.com/kjbreil/dlc-sidecar/internal/session"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+func registerWatchpoints(s *server.MCPServer, sessions *session.Manager) {
+	// set_watchpoint
+	addSessionTool(s, sessions, mcp.NewTool("set_watchpoint",
+		mcp.WithDescription("Set a hardware watchpoint that stops the target when the memory of a variable or field is "+
+			"accessed. The stop reports the accessing code's location and the old and new value. A watchpoint on a "+
+			"stack variable is cleared when its function returns"),
+		mcp.WithString("expr",
+			mcp.Required(),
+			mcp.Description("Addressable expression to watch, at most a word in size (e.g. s.count, *p, arr[3])"),
+		),
+		mcp.WithString("mode",
+			mcp.Description("Accesses to stop on (default: write)"),
+			mcp.Enum("write", "read", "read-write"),
+		),
+		mcp.WithNumber("goroutineID",
+			mcp.Description("Goroutine whose scope expr is evaluated in (default: -1 for current)"),
+		),
+		mcp.WithNumber("frame",
+			mcp.Description("Stack frame whose scope expr is evaluated in (default: 0 for current frame)"),
+		),
+	), liveOnly(makeSetWatchpoint()))
+}
+
+func makeSetWatchpoint() sessionHandler {
+	return func(ctx context.Context, request mcp.CallToolRequest, sess *session.Session) (*mcp.CallToolResult, error) {
+		expr, err := request.RequireString("expr")
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("expr parameter error: %v", err)), nil
+		}
+		if err := checkExpr(expr); err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("expr parameter error: %v", err)), nil
+		}
+		typ, err := debugger.ParseWatchType(request.GetString("mode", "write"))
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("mode parameter error: %v", err)), nil
+		}
+
+		bp, w, err := sess.Watches.Set(ctx, evalScope(request), expr, typ)
+		if err != nil {
+			return rpcError(err), nil
+		}
+
+		info := breakpointInfo(bp)
+		addWatchInfo(info, w)
+		return jsonResult(map[string]interface{}{
+			"success":    true,
+			"watchpoint": info,
+			"message":    fmt.Sprintf("Watchpoint set on %s for %s access (ID: %d)", expr, typ, bp.ID),
+		})
+	}
+}
+
+// addWatchInfo adds the scope and last seen value of a watchpoint to its
+// breakpointInfo.
+func addWatchInfo(info map[string]interface{}, w debugger.Watch) {
+	info["scope"] = map[string]interface{}{
+		"goroutineID": w.Scope.GoroutineID,
+		"frame":       w.Scope.Frame,
+	}
+	if w.TypeName != "" {
+		info["type"] = w.TypeName
+	}
+	if w.Value != "" {
+		info["value"] = w.Value
+	}
+}
+
+// addWatchEvents reports the watchpoint the target stopped at, with the
+// access that triggered it, and the watchpoints cleared because their
+// variable went out of scope.
+func addWatchEvents(result map[string]interface{}, st *debugger.DebuggerState) {
+	if st.CurrentThread != nil && st.CurrentThread.Breakpoint != nil {
+		if h, ok := st.CurrentThread.Breakpoint.UserData.(*debugger.WatchHit); ok {
+			hit := map[string]interface{}{
+				"id":          h.Watch.ID,
+				"expr":        h.Watch.Expr,
+				"mode":        h.Watch.Type.String(),
+				"file":        h.File,
+				"line":        h.Line,
+				"function":    h.Function,
+				"goroutineID": h.GoroutineID,
+			}
+			if h.Old != "" {
+				hit["oldValue"] = h.Old
+			}
+			if h.Err != "" {
+				hit["valueError"] = h.Err
+			} else {
+				hit["newValue"] = h.New
+				hit["changed"] = h.Old != "" && h.Old != h.New
+			}
+			result["watchpoint"] = hit
+		}
+	}
+
+	var gone []map[string]interface{}
+	for _, bp := range st.WatchOutOfScope {
+		info := map[string]interface{}{
+			"id":   bp.ID,
+			"expr": bp.WatchExpr,
+		}
+		if w, ok := bp.UserData.(*debugger.Watch); ok && w.Value != "" {
+			info["lastValue"] = w.Value
+		}
+		gone = append(gone, info)
+	}
+	if len(gone) > 0 {
+		result["watchpointsOutOfScope"] = gone
+	}
+}
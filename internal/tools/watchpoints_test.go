@@ -0,0 +1,80 @@
+package tools
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/kjbreil/dlc-sidecar/internal/debugger"
+)
+
+func TestSetWatchpoint(t *testing.T) {
+	h := newHarness(t)
+	h.delve.SetEval("cfg.retries", debugger.Variable{Name: "retries", Type: "int", Kind: int(reflect.Int), Addr: 0xc000012340, Value: "3"})
+	out := h.callJSON("set_watchpoint", map[string]interface{}{"expr": "cfg.retries", "frame": 1})
+	wp := out["watchpoint"].(map[string]interface{})
+	if wp["watchExpr"] != "cfg.retries" || wp["watchType"] != "write" || wp["value"] != "3" || wp["addr"] != "0xc000012340" {
+		t.Errorf("watchpoint = %v", wp)
+	}
+	call := h.delve.Calls("CreateWatchpoint")[0].Args.(debugger.CreateWatchpointIn)
+	if call.Type != debugger.WatchWrite || call.Scope.Frame != 1 || call.Scope.GoroutineID != -1 {
+		t.Errorf("CreateWatchpoint = %+v", call)
+	}
+
+	// The writer runs elsewhere, so the value is read again by address.
+	h.delve.SetEval("*(*int)(0xc000012340)", debugger.Variable{Type: "int", Kind: int(reflect.Int), Value: "0"})
+	h.delve.HitWatchpoint(1, debugger.Location{File: "/src/reset.go", Line: 17, Function: &debugger.Function{Name: "main.reset"}})
+	out = h.callJSON("continue", nil)
+	hit, ok := out["watchpoint"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("continue = %v, want a watchpoint hit", out)
+	}
+	if hit["id"] != float64(1) || hit["file"] != "/src/reset.go" || hit["line"] != float64(17) || hit["function"] != "main.reset" {
+		t.Errorf("hit location = %v", hit)
+	}
+	if hit["oldValue"] != "3" || hit["newValue"] != "0" || hit["changed"] != true {
+		t.Errorf("hit values = %v", hit)
+	}
+
+	out = h.callJSON("list_breakpoints", nil)
+	list := out["breakpoints"].([]interface{})
+	if len(list) != 1 {
+		t.Fatalf("list_breakpoints = %v", out)
+	}
+	if bp := list[0].(map[string]interface{}); bp["watchExpr"] != "cfg.retries" || bp["value"] != "0" || bp["type"] != "int" {
+		t.Errorf("listed watchpoint = %v", bp)
+	}
+}
+
+func TestWatchpoint_OutOfScope(t *testing.T) {
+	h := newHarness(t)
+	h.delve.SetEval("n", debugger.Variable{Name: "n", Type: "int", Kind: int(reflect.Int), Addr: 0xc000098f08, Value: "1"})
+	h.callJSON("set_watchpoint", map[string]interface{}{"expr": "n", "mode": "read-write"})
+	if got := h.delve.Breakpoints()[0].WatchType; got != debugger.WatchRead|debugger.WatchWrite {
+		t.Errorf("watch type = %v, want read-write", got)
+	}
+
+	h.delve.EndWatchScope(1, debugger.Location{File: "/src/main.go", Line: 40})
+	out := h.callJSON("continue", nil)
+	gone, ok := out["watchpointsOutOfScope"].([]interface{})
+	if !ok || len(gone) != 1 {
+		t.Fatalf("continue = %v, want the watchpoint out of scope", out)
+	}
+	if w := gone[0].(map[string]interface{}); w["id"] != float64(1) || w["expr"] != "n" || w["lastValue"] != "1" {
+		t.Errorf("out of scope = %v", w)
+	}
+	if len(h.delve.Calls("ClearBreakpoint")) != 1 {
+		t.Error("watchpoint out of scope was not cleared")
+	}
+	sess, _ := h.sessions.Get("")
+	if _, ok := sess.Watches.Get(1); ok {
+		t.Error("watchpoint out of scope is still tracked")
+	}
+}
+
+func TestSetWatchpoint_Errors(t *testing.T) {
+	h := newHarness(t)
+	h.callError("set_watchpoint", map[string]interface{}{}, "expr parameter error")
+	h.callError("set_watchpoint", map[string]interface{}{"expr": "a +"}, "expr parameter error: invalid expression")
+	h.callError("set_watchpoint", map[string]interface{}{"expr": "n", "mode": "execute"}, "mode parameter error")
+	h.callError("set_watchpoint", map[string]interface{}{"expr": "missing"}, "could not find symbol value for missing")
+}
@@ -1,45 +1,177 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"log"
 	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/kjbreil/dlc-sidecar/internal/debugger"
+	"github.com/kjbreil/dlc-sidecar/internal/session"
 	"github.com/kjbreil/dlc-sidecar/internal/tools"
 	"github.com/mark3labs/mcp-go/server"
 )
 
+// reapInterval is how often idle sessions are checked for.
+const reapInterval = 30 * time.Second
+
 func main() {
-	port := flag.Int("port", 0, "Delve debugger port (default: 2345, or DLV_PORT env var)")
+	addrFlag := flag.String("addr", "", "Delve debugger address: host:port, tcp://host:port or unix:/path (default: DLV_ADDR env var)")
+	port := flag.Int("port", 0, "Delve debugger port on localhost (default: 2345, or DLV_PORT env var)")
+	maxSessions := flag.Int("max-sessions", 8, "Maximum number of concurrent debug sessions (0 for unlimited)")
+	idleTimeout := flag.Duration("idle-timeout", 30*time.Minute, "Close sessions opened via open_session after this long unused (0 to disable); sessions that start Delve are only closed when given their own idleTimeout")
+	launch := flag.String("launch", "", "Package to build and start under a sidecar-managed Delve instead of connecting to -port; remaining arguments are passed to the program")
+	execBinary := flag.String("exec", "", "Prebuilt binary to start under a sidecar-managed Delve; remaining arguments are passed to the program")
+	buildFlags := flag.String("build-flags", "", "Build flags for -launch")
+	tags := flag.String("tags", "", "Comma-separated build tags for -launch")
+	wd := flag.String("wd", "", "Working directory for -launch and -exec")
+	cont := flag.Bool("continue", false, "Start the -launch or -exec program running instead of stopped on entry")
+	onExit := flag.String("on-exit", "", "What happens to debug targets when the sidecar exits: continue, kill or halt (default: as close_session)")
+	breakpointsFile := flag.String("breakpoints-file", session.DefaultManifestPath, "Project-local file breakpoints are saved to and restored from when Delve restarts (empty to disable)")
+	healthInterval := flag.Duration("health-interval", debugger.DefaultMonitorConfig().Interval, "How often each session pings Delve to detect disconnects and target exit (0 to disable)")
 	flag.Parse()
 
-	addr := resolveAddr(*port)
+	policy, err := session.ParseExitPolicy(*onExit)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	sessions := session.NewManager(session.Limits{
+		MaxSessions: *maxSessions,
+		IdleTimeout: *idleTimeout,
+	})
+	if *healthInterval > 0 {
+		cfg := debugger.DefaultMonitorConfig()
+		cfg.Interval = *healthInterval
+		sessions.MonitorHealth(cfg)
+	}
+	if *breakpointsFile != "" {
+		sessions.PersistBreakpoints(session.NewManifest(*breakpointsFile))
+	}
 
-	pool := debugger.NewPool(addr)
-	defer pool.Close()
+	if *launch != "" && *execBinary != "" {
+		log.Fatal("-launch and -exec are mutually exclusive")
+	}
+
+	var def *session.Session
+	switch {
+	case *execBinary != "":
+		def, err = openLaunched(debugger.LaunchConfig{
+			Mode:     debugger.ModeExec,
+			Target:   *execBinary,
+			Args:     flag.Args(),
+			Dir:      *wd,
+			Continue: *cont,
+		}, sessions)
+	case *launch != "":
+		cfg := debugger.LaunchConfig{
+			Mode:       debugger.ModeDebug,
+			Target:     *launch,
+			BuildFlags: *buildFlags,
+			Args:       flag.Args(),
+			Dir:        *wd,
+			Continue:   *cont,
+		}
+		if *tags != "" {
+			cfg.Tags = strings.Split(*tags, ",")
+		}
+		def, err = openLaunched(cfg, sessions)
+	default:
+		var addr string
+		addr, err = resolveAddr(*addrFlag, *port)
+		if err == nil {
+			def, err = sessions.Open(session.DefaultName, addr)
+		}
+	}
+	if err != nil {
+		log.Fatalf("Session error: %v", err)
+	}
+	// The startup session is never reaped.
+	def.SetIdleTimeout(0)
+	sessions.StartReaper(reapInterval)
 
 	s := server.NewMCPServer(
 		"dlc-sidecar",
 		"1.0.0",
 		server.WithToolCapabilities(true),
+		server.WithLogging(),
 	)
 
-	tools.Register(s, pool)
+	tools.Register(s, sessions)
 
-	if err := server.ServeStdio(s); err != nil {
-		log.Fatalf("Server error: %v", err)
+	// Serve until stdin closes or a signal arrives, then end every session
+	// according to the exit policy. Both cancel ctx, so tool calls blocked on
+	// a running target return instead of holding up the shutdown.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	ctx, cancel := context.WithCancel(ctx)
+	err = server.NewStdioServer(s).Listen(ctx, cancelOnEOF{os.Stdin, cancel}, os.Stdout)
+	cancel()
+	stop()
+	if errors.Is(err, context.Canceled) {
+		err = nil
+	}
+	if err != nil {
+		log.Printf("Server error: %v", err)
+	}
+	if endErr := sessions.EndAll(policy); endErr != nil {
+		log.Printf("Shutdown: %v", endErr)
+	}
+	if err != nil {
+		os.Exit(1)
+	}
+}
+
+// cancelOnEOF is a reader that calls cancel once r reports io.EOF.
+type cancelOnEOF struct {
+	r      io.Reader
+	cancel context.CancelFunc
+}
+
+func (c cancelOnEOF) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	if errors.Is(err, io.EOF) {
+		c.cancel()
+	}
+	return n, err
+}
+
+// openLaunched starts Delve for cfg and opens it as the default session.
+func openLaunched(cfg debugger.LaunchConfig, sessions *session.Manager) (*session.Session, error) {
+	proc, err := debugger.Launch(context.Background(), cfg)
+	if err != nil {
+		return nil, err
+	}
+	sess, err := sessions.OpenProcess(session.DefaultName, proc)
+	if err != nil {
+		proc.Stop()
+		return nil, err
 	}
+	return sess, nil
 }
 
-// resolveAddr determines the Delve debugger address from flag, env var, or default.
-func resolveAddr(flagPort int) string {
-	if flagPort != 0 {
-		return fmt.Sprintf("localhost:%d", flagPort)
+// resolveAddr determines the Delve debugger address from flags, env vars, or
+// default, in the order -addr, -port, DLV_ADDR, DLV_PORT, and validates it.
+func resolveAddr(flagAddr string, flagPort int) (string, error) {
+	addr := debugger.DefaultAddr
+	switch {
+	case flagAddr != "":
+		addr = flagAddr
+	case flagPort != 0:
+		addr = fmt.Sprintf("localhost:%d", flagPort)
+	case os.Getenv("DLV_ADDR") != "":
+		addr = os.Getenv("DLV_ADDR")
+	case os.Getenv("DLV_PORT") != "":
+		addr = fmt.Sprintf("localhost:%s", os.Getenv("DLV_PORT"))
 	}
-	if envPort := os.Getenv("DLV_PORT"); envPort != "" {
-		return fmt.Sprintf("localhost:%s", envPort)
+	if _, _, err := debugger.ParseAddr(addr); err != nil {
+		return "", err
 	}
-	return debugger.DefaultAddr
+	return addr, nil
 }
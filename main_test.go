@@ -1,16 +1,26 @@
 package main
 
 import (
+	"context"
+	"fmt"
+	"io"
 	"os"
 	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
 )
 
 func TestResolveAddr(t *testing.T) {
 	tests := []struct {
 		name     string
+		flagAddr string
 		flagPort int
+		envAddr  string
 		envPort  string
 		want     string
+		wantErr  bool
 	}{
 		{
 			name:     "flag takes precedence",
@@ -30,21 +40,94 @@ func TestResolveAddr(t *testing.T) {
 			envPort:  "",
 			want:     "localhost:2345",
 		},
+		{
+			name:     "addr flag takes precedence over port flag",
+			flagAddr: "unix:/run/dlv.sock",
+			flagPort: 2346,
+			want:     "unix:/run/dlv.sock",
+		},
+		{
+			name:    "DLV_ADDR takes precedence over DLV_PORT",
+			envAddr: "tcp://debug-vm:2345",
+			envPort: "9999",
+			want:    "tcp://debug-vm:2345",
+		},
+		{
+			name:     "port flag takes precedence over DLV_ADDR",
+			flagPort: 2348,
+			envAddr:  "remote:2345",
+			want:     "localhost:2348",
+		},
+		{
+			name:     "invalid address rejected",
+			flagAddr: "remote-host",
+			wantErr:  true,
+		},
+		{
+			name:    "invalid DLV_PORT rejected",
+			envPort: "abc",
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if tt.envPort != "" {
-				os.Setenv("DLV_PORT", tt.envPort)
-				defer os.Unsetenv("DLV_PORT")
-			} else {
-				os.Unsetenv("DLV_PORT")
-			}
+			setEnv(t, "DLV_ADDR", tt.envAddr)
+			setEnv(t, "DLV_PORT", tt.envPort)
 
-			got := resolveAddr(tt.flagPort)
+			got, err := resolveAddr(tt.flagAddr, tt.flagPort)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("resolveAddr(%q, %d) = %q, want error", tt.flagAddr, tt.flagPort, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("resolveAddr(%q, %d): %v", tt.flagAddr, tt.flagPort, err)
+			}
 			if got != tt.want {
-				t.Errorf("resolveAddr(%d) = %q, want %q", tt.flagPort, got, tt.want)
+				t.Errorf("resolveAddr(%q, %d) = %q, want %q", tt.flagAddr, tt.flagPort, got, tt.want)
 			}
 		})
 	}
 }
+
+// setEnv sets or, for an empty value, unsets key for the duration of the test.
+func setEnv(t *testing.T, key, value string) {
+	t.Helper()
+	if value != "" {
+		t.Setenv(key, value)
+		return
+	}
+	if old, ok := os.LookupEnv(key); ok {
+		os.Unsetenv(key)
+		t.Cleanup(func() { os.Setenv(key, old) })
+	}
+}
+
+func TestCancelOnEOF(t *testing.T) {
+	s := server.NewMCPServer("test", "1.0.0", server.WithToolCapabilities(true))
+	started := make(chan struct{})
+	s.AddTool(mcp.NewTool("block"), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		close(started)
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+
+	stdin, w := io.Pipe()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error, 1)
+	go func() {
+		done <- server.NewStdioServer(s).Listen(ctx, cancelOnEOF{stdin, cancel}, io.Discard)
+	}()
+
+	fmt.Fprintln(w, `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"block"}}`)
+	<-started
+	w.Close()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Listen did not return after stdin closed during a tool call")
+	}
+}